@@ -16,10 +16,24 @@ import (
 // App est la structure principale du backend
 // Toutes ses méthodes publiques sont accessibles depuis React
 type App struct {
-	ctx        context.Context
-	indexer    *services.Indexer
-	tagService *services.TagService
-	dataDir    string
+	ctx               context.Context
+	indexer           *services.Indexer
+	tagService        *services.TagService
+	smartAlbumService *services.SmartAlbumService
+	albumService      *services.AlbumService
+	historyService    *services.HistoryService
+	autoTagService    *services.AutoTagService
+	eventService      *services.EventClusterService
+	faceRegionService *services.FaceRegionService
+	faceService       *services.FaceService
+	metadataService   *services.MetadataService
+	cullingService    *services.CullingService
+	captionService    *services.CaptionService
+	favoriteService   *services.FavoriteService
+	trashService      *services.TrashService
+	fileService       *services.FileOperationService
+	importService     *services.ImportService
+	dataDir           string
 }
 
 // NewApp crée une nouvelle instance de App
@@ -51,6 +65,37 @@ func (a *App) startup(ctx context.Context) {
 	// Initialiser les services seulement si la DB est prête
 	a.indexer = services.NewIndexer(a.dataDir)
 	a.tagService = services.NewTagService()
+	a.smartAlbumService = services.NewSmartAlbumService()
+	a.albumService = services.NewAlbumService()
+	a.historyService = services.NewHistoryService()
+	a.autoTagService = services.NewAutoTagService()
+	a.eventService = services.NewEventClusterService()
+	a.faceRegionService = services.NewFaceRegionService()
+	a.faceService = services.NewFaceService(a.dataDir)
+	a.metadataService = services.NewMetadataService()
+	a.cullingService = services.NewCullingService()
+	a.captionService = services.NewCaptionService()
+	a.favoriteService = services.NewFavoriteService()
+	a.trashService = services.NewTrashService(a.dataDir)
+	a.fileService = services.NewFileOperationService()
+	a.importService = services.NewImportService(a.dataDir)
+
+	// Aligner l'index plein texte sur les photos existantes
+	if err := services.EnsureSearchIndex(); err != nil {
+		fmt.Printf("Warning: could not build search index: %v\n", err)
+	}
+
+	// Compléter la forme normalisée des noms de tags créés avant son ajout
+	if err := services.EnsureTagNameKeys(); err != nil {
+		fmt.Printf("Warning: could not normalize tag names: %v\n", err)
+	}
+
+	// Vider en tâche de fond les photos restées trop longtemps dans la corbeille
+	go func() {
+		if _, err := a.trashService.PurgeExpiredTrash(); err != nil {
+			fmt.Printf("Warning: could not purge trash: %v\n", err)
+		}
+	}()
 }
 
 // shutdown est appelé à la fermeture de l'application
@@ -92,13 +137,14 @@ func (a *App) IndexFolder(folderPath string) (int, error) {
 	return count, nil
 }
 
-// GetIndexedPictures retourne toutes les photos indexées
-func (a *App) GetIndexedPictures() ([]models.Picture, error) {
+// GetIndexedPictures retourne une page des photos indexées
+// Passer le nextCursor de la page précédente pour obtenir la suivante
+func (a *App) GetIndexedPictures(page services.PageRequest) (*services.PicturePage, error) {
 	if a.indexer == nil {
 		return nil, fmt.Errorf("indexer not initialized")
 	}
 
-	return a.indexer.GetIndexedPictures()
+	return a.indexer.GetIndexedPictures(page)
 }
 
 // GetPictureCount retourne le nombre total de photos indexées
@@ -110,7 +156,7 @@ func (a *App) GetPictureCount() (int64, error) {
 	return a.indexer.GetPictureCount()
 }
 
-// DeletePicture supprime une photo de l'index et optionnellement du disque
+// DeletePicture supprime une photo de l'index et optionnellement du disque (mise à la corbeille)
 func (a *App) DeletePicture(picturePath string, deleteFromDisk bool) error {
 	if a.indexer == nil {
 		return fmt.Errorf("indexer not initialized")
@@ -119,6 +165,24 @@ func (a *App) DeletePicture(picturePath string, deleteFromDisk bool) error {
 	return a.indexer.DeletePicture(picturePath, deleteFromDisk)
 }
 
+// GetExcludedPictures retourne les photos retirées de l'index, ignorées par l'indexeur
+func (a *App) GetExcludedPictures() ([]models.ExcludedPicture, error) {
+	if a.indexer == nil {
+		return nil, fmt.Errorf("indexer not initialized")
+	}
+
+	return a.indexer.GetExcludedPictures()
+}
+
+// IncludePicture réintègre une photo retirée de l'index
+func (a *App) IncludePicture(picturePath string) error {
+	if a.indexer == nil {
+		return fmt.Errorf("indexer not initialized")
+	}
+
+	return a.indexer.IncludePicture(picturePath)
+}
+
 // === Gestion des dossiers surveillés ===
 
 // AddWatchedFolder ajoute un dossier à la liste des dossiers surveillés
@@ -157,6 +221,15 @@ func (a *App) UpdateWatchedFolder(folderPath string, name string, autoReindex bo
 	return a.indexer.UpdateWatchedFolder(folderPath, name, autoReindex)
 }
 
+// SetIgnorePatterns remplace les motifs glob ignorés à l'indexation d'un dossier surveillé (ex: "@eaDir", "*.tmp")
+func (a *App) SetIgnorePatterns(folderPath string, patterns []string) error {
+	if a.indexer == nil {
+		return fmt.Errorf("indexer not initialized")
+	}
+
+	return a.indexer.SetIgnorePatterns(folderPath, patterns)
+}
+
 // IndexWatchedFolder indexe un dossier surveillé spécifique
 func (a *App) IndexWatchedFolder(folderPath string) (int, error) {
 	if a.indexer == nil {
@@ -179,13 +252,14 @@ func (a *App) ReindexAllWatchedFolders() (int, error) {
 
 // === Gestion des tags ===
 
-// CreateTag crée un nouveau tag
-func (a *App) CreateTag(name string, tagType string, color string) error {
+// CreateTag crée un nouveau tag, éventuellement sous un tag parent (vide = racine)
+// Retourne les tags existants au nom proche (avertissement de quasi-doublon)
+func (a *App) CreateTag(name string, tagType string, color string, parentName string) ([]string, error) {
 	if a.tagService == nil {
-		return fmt.Errorf("tag service not initialized")
+		return nil, fmt.Errorf("tag service not initialized")
 	}
 
-	return a.tagService.CreateTag(name, models.TagType(tagType), color)
+	return a.tagService.CreateTag(name, models.TagType(tagType), color, parentName)
 }
 
 // GetAllTags retourne tous les tags
@@ -206,13 +280,13 @@ func (a *App) GetAllTagsWithCount() ([]services.TagWithCount, error) {
 	return a.tagService.GetAllTagsWithCount()
 }
 
-// UpdateTag met à jour un tag existant
-func (a *App) UpdateTag(name string, tagType string, color string) error {
+// UpdateTag met à jour un tag existant (type, couleur et tag parent, vide = racine)
+func (a *App) UpdateTag(name string, tagType string, color string, parentName string) error {
 	if a.tagService == nil {
 		return fmt.Errorf("tag service not initialized")
 	}
 
-	return a.tagService.UpdateTag(name, models.TagType(tagType), color)
+	return a.tagService.UpdateTag(name, models.TagType(tagType), color, parentName)
 }
 
 // DeleteTag supprime un tag et toutes ses associations
@@ -224,6 +298,105 @@ func (a *App) DeleteTag(name string) error {
 	return a.tagService.DeleteTag(name)
 }
 
+// RenameTag renomme un tag en conservant toutes ses associations
+func (a *App) RenameTag(oldName string, newName string) error {
+	if a.tagService == nil {
+		return fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.RenameTag(oldName, newName)
+}
+
+// MergeTags fusionne des tags dans un tag cible (les tags sources sont supprimés)
+func (a *App) MergeTags(sourceNames []string, targetName string) error {
+	if a.tagService == nil {
+		return fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.MergeTags(sourceNames, targetName)
+}
+
+// GetTagTypes retourne les types de tags dans l'ordre d'affichage
+func (a *App) GetTagTypes() ([]models.TagTypeDefinition, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.GetTagTypes()
+}
+
+// CreateTagType crée un type de tag (ex: projet, client, boîtier)
+func (a *App) CreateTagType(name string, label string, icon string, color string, sortOrder int) (*models.TagTypeDefinition, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.CreateTagType(name, label, icon, color, sortOrder)
+}
+
+// UpdateTagType met à jour le libellé, l'icône, la couleur par défaut et l'ordre d'un type
+func (a *App) UpdateTagType(name string, label string, icon string, color string, sortOrder int) error {
+	if a.tagService == nil {
+		return fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.UpdateTagType(name, label, icon, color, sortOrder)
+}
+
+// DeleteTagType supprime un type défini par l'utilisateur (ses tags passent en "other")
+func (a *App) DeleteTagType(name string) error {
+	if a.tagService == nil {
+		return fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.DeleteTagType(name)
+}
+
+// AddTagAlias ajoute un alias à un tag
+func (a *App) AddTagAlias(tagName string, alias string) error {
+	if a.tagService == nil {
+		return fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.AddTagAlias(tagName, alias)
+}
+
+// RemoveTagAlias supprime un alias
+func (a *App) RemoveTagAlias(alias string) error {
+	if a.tagService == nil {
+		return fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.RemoveTagAlias(alias)
+}
+
+// GetTagAliases retourne les alias d'un tag
+func (a *App) GetTagAliases(tagName string) ([]models.TagAlias, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.GetTagAliases(tagName)
+}
+
+// FindSimilarTags retourne les tags existants dont le nom ressemble au nom donné
+func (a *App) FindSimilarTags(name string) ([]string, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.FindSimilarTags(name)
+}
+
+// SuggestTags propose des tags pour une photo, classés par pertinence avec leurs justifications
+func (a *App) SuggestTags(picturePath string) ([]services.TagSuggestion, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.SuggestTags(picturePath)
+}
+
 // AddTagToPicture associe un tag à une photo
 func (a *App) AddTagToPicture(picturePath string, tagName string) error {
 	if a.tagService == nil {
@@ -233,6 +406,33 @@ func (a *App) AddTagToPicture(picturePath string, tagName string) error {
 	return a.tagService.AddTagToPicture(picturePath, tagName)
 }
 
+// AddTagsToPictures associe plusieurs tags à plusieurs photos (résultat par couple photo/tag)
+func (a *App) AddTagsToPictures(picturePaths []string, tagNames []string) ([]services.BulkTagResult, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.AddTagsToPictures(picturePaths, tagNames)
+}
+
+// RemoveTagsFromPictures dissocie plusieurs tags de plusieurs photos
+func (a *App) RemoveTagsFromPictures(picturePaths []string, tagNames []string) ([]services.BulkTagResult, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.RemoveTagsFromPictures(picturePaths, tagNames)
+}
+
+// ReplaceTagsOnPictures remplace l'ensemble des tags de plusieurs photos
+func (a *App) ReplaceTagsOnPictures(picturePaths []string, tagNames []string) ([]services.BulkTagResult, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.ReplaceTagsOnPictures(picturePaths, tagNames)
+}
+
 // RemoveTagFromPicture dissocie un tag d'une photo
 func (a *App) RemoveTagFromPicture(picturePath string, tagName string) error {
 	if a.tagService == nil {
@@ -251,12 +451,728 @@ func (a *App) GetTagsForPicture(picturePath string) ([]models.Tag, error) {
 	return a.tagService.GetTagsForPicture(picturePath)
 }
 
-// SearchPicturesAdvanced effectue une recherche avancée par tags
+// GetTagsForPictureWithRegions retourne les tags d'une photo et ses zones de visage
+func (a *App) GetTagsForPictureWithRegions(picturePath string) (*services.PictureTagsWithRegions, error) {
+	if a.tagService == nil {
+		return nil, fmt.Errorf("tag service not initialized")
+	}
+
+	return a.tagService.GetTagsForPictureWithRegions(picturePath)
+}
+
+// SearchPicturesAdvanced effectue une recherche avancée par tags, paginée
 // Exemple: (Clara AND Romaric) AND (Paris OR Compiegne)
-func (a *App) SearchPicturesAdvanced(criteria services.SearchCriteria) ([]models.Picture, error) {
+func (a *App) SearchPicturesAdvanced(criteria services.SearchCriteria, page services.PageRequest) (*services.PicturePage, error) {
 	if a.tagService == nil {
 		return nil, fmt.Errorf("tag service not initialized")
 	}
 
-	return a.tagService.SearchPicturesAdvanced(criteria)
+	return a.tagService.SearchPicturesAdvanced(criteria, page)
+}
+
+// === Tri des photos (notes, étiquettes, marques) ===
+
+// SetRating note une photo de 0 (aucune note) à 5 étoiles
+func (a *App) SetRating(picturePath string, rating int) error {
+	if a.cullingService == nil {
+		return fmt.Errorf("culling service not initialized")
+	}
+
+	return a.cullingService.SetRating(picturePath, rating)
+}
+
+// SetRatings note plusieurs photos à la fois (retourne le nombre de photos modifiées)
+func (a *App) SetRatings(picturePaths []string, rating int) (int, error) {
+	if a.cullingService == nil {
+		return 0, fmt.Errorf("culling service not initialized")
+	}
+
+	return a.cullingService.SetRatings(picturePaths, rating)
+}
+
+// SetColorLabel pose une étiquette de couleur sur une photo ("red", "yellow", "green", "blue", "purple" ou "" pour retirer)
+func (a *App) SetColorLabel(picturePath string, label models.ColorLabel) error {
+	if a.cullingService == nil {
+		return fmt.Errorf("culling service not initialized")
+	}
+
+	return a.cullingService.SetColorLabel(picturePath, label)
+}
+
+// SetColorLabels pose une étiquette de couleur sur plusieurs photos (retourne le nombre de photos modifiées)
+func (a *App) SetColorLabels(picturePaths []string, label models.ColorLabel) (int, error) {
+	if a.cullingService == nil {
+		return 0, fmt.Errorf("culling service not initialized")
+	}
+
+	return a.cullingService.SetColorLabels(picturePaths, label)
+}
+
+// SetPickFlag marque une photo retenue ("pick"), rejetée ("reject") ou non marquée ("unflagged")
+func (a *App) SetPickFlag(picturePath string, flag models.PickFlag) error {
+	if a.cullingService == nil {
+		return fmt.Errorf("culling service not initialized")
+	}
+
+	return a.cullingService.SetPickFlag(picturePath, flag)
+}
+
+// SetPickFlags marque plusieurs photos à la fois (retourne le nombre de photos modifiées)
+func (a *App) SetPickFlags(picturePaths []string, flag models.PickFlag) (int, error) {
+	if a.cullingService == nil {
+		return 0, fmt.Errorf("culling service not initialized")
+	}
+
+	return a.cullingService.SetPickFlags(picturePaths, flag)
+}
+
+// === Titres, légendes et notes ===
+
+// GetPictureCaption retourne le titre, la légende et les notes privées d'une photo
+func (a *App) GetPictureCaption(picturePath string) (*services.PictureCaption, error) {
+	if a.captionService == nil {
+		return nil, fmt.Errorf("caption service not initialized")
+	}
+
+	return a.captionService.GetPictureCaption(picturePath)
+}
+
+// SetPictureCaption remplace le titre, la légende et les notes privées d'une photo
+func (a *App) SetPictureCaption(picturePath string, caption services.PictureCaption) error {
+	if a.captionService == nil {
+		return fmt.Errorf("caption service not initialized")
+	}
+
+	return a.captionService.SetPictureCaption(picturePath, caption)
+}
+
+// === Favoris et historique de consultation ===
+
+// ToggleFavorite ajoute ou retire une photo des favoris et retourne son nouvel état
+func (a *App) ToggleFavorite(picturePath string) (bool, error) {
+	if a.favoriteService == nil {
+		return false, fmt.Errorf("favorite service not initialized")
+	}
+
+	return a.favoriteService.ToggleFavorite(picturePath)
+}
+
+// SetFavorites ajoute ou retire plusieurs photos des favoris (retourne le nombre de photos modifiées)
+func (a *App) SetFavorites(picturePaths []string, favorite bool) (int, error) {
+	if a.favoriteService == nil {
+		return 0, fmt.Errorf("favorite service not initialized")
+	}
+
+	return a.favoriteService.SetFavorites(picturePaths, favorite)
+}
+
+// GetFavorites retourne une page des photos favorites correspondant aux critères
+func (a *App) GetFavorites(criteria services.SearchCriteria, page services.PageRequest) (*services.PicturePage, error) {
+	if a.favoriteService == nil {
+		return nil, fmt.Errorf("favorite service not initialized")
+	}
+
+	return a.favoriteService.GetFavorites(criteria, page)
+}
+
+// MarkViewed enregistre l'ouverture d'une photo dans la visionneuse
+func (a *App) MarkViewed(picturePath string) error {
+	if a.favoriteService == nil {
+		return fmt.Errorf("favorite service not initialized")
+	}
+
+	return a.favoriteService.MarkViewed(picturePath)
+}
+
+// GetRecentlyViewed retourne une page des photos vues, les plus récemment ouvertes d'abord
+func (a *App) GetRecentlyViewed(criteria services.SearchCriteria, page services.PageRequest) (*services.PicturePage, error) {
+	if a.favoriteService == nil {
+		return nil, fmt.Errorf("favorite service not initialized")
+	}
+
+	return a.favoriteService.GetRecentlyViewed(criteria, page)
+}
+
+// GetMostViewed retourne une page des photos vues, les plus souvent ouvertes d'abord
+func (a *App) GetMostViewed(criteria services.SearchCriteria, page services.PageRequest) (*services.PicturePage, error) {
+	if a.favoriteService == nil {
+		return nil, fmt.Errorf("favorite service not initialized")
+	}
+
+	return a.favoriteService.GetMostViewed(criteria, page)
+}
+
+// ClearViewHistory efface l'historique de consultation
+func (a *App) ClearViewHistory() error {
+	if a.favoriteService == nil {
+		return fmt.Errorf("favorite service not initialized")
+	}
+
+	return a.favoriteService.ClearViewHistory()
+}
+
+// === Règles de tag automatique ===
+
+// CreateAutoTagRule crée une règle de tag automatique sur les chemins (patternType: "glob" ou "regex")
+func (a *App) CreateAutoTagRule(folderPath string, pattern string, patternType string, tagTemplate string) (*models.AutoTagRule, error) {
+	if a.autoTagService == nil {
+		return nil, fmt.Errorf("auto tag service not initialized")
+	}
+
+	return a.autoTagService.CreateAutoTagRule(folderPath, pattern, models.AutoTagPatternType(patternType), tagTemplate)
+}
+
+// GetAutoTagRules récupère toutes les règles de tag automatique
+func (a *App) GetAutoTagRules() ([]models.AutoTagRule, error) {
+	if a.autoTagService == nil {
+		return nil, fmt.Errorf("auto tag service not initialized")
+	}
+
+	return a.autoTagService.GetAutoTagRules()
+}
+
+// UpdateAutoTagRule met à jour une règle de tag automatique
+func (a *App) UpdateAutoTagRule(id uint, folderPath string, pattern string, patternType string, tagTemplate string, enabled bool) error {
+	if a.autoTagService == nil {
+		return fmt.Errorf("auto tag service not initialized")
+	}
+
+	return a.autoTagService.UpdateAutoTagRule(id, folderPath, pattern, models.AutoTagPatternType(patternType), tagTemplate, enabled)
+}
+
+// DeleteAutoTagRule supprime une règle de tag automatique
+func (a *App) DeleteAutoTagRule(id uint) error {
+	if a.autoTagService == nil {
+		return fmt.Errorf("auto tag service not initialized")
+	}
+
+	return a.autoTagService.DeleteAutoTagRule(id)
+}
+
+// PreviewAutoTagRule simule une règle non enregistrée sur la bibliothèque
+func (a *App) PreviewAutoTagRule(folderPath string, pattern string, patternType string, tagTemplate string) ([]services.AutoTagMatch, error) {
+	if a.autoTagService == nil {
+		return nil, fmt.Errorf("auto tag service not initialized")
+	}
+
+	return a.autoTagService.PreviewAutoTagRule(folderPath, pattern, models.AutoTagPatternType(patternType), tagTemplate)
+}
+
+// PreviewAutoTagRules simule les règles actives sur la bibliothèque
+func (a *App) PreviewAutoTagRules() ([]services.AutoTagMatch, error) {
+	if a.autoTagService == nil {
+		return nil, fmt.Errorf("auto tag service not initialized")
+	}
+
+	return a.autoTagService.PreviewAutoTagRules()
+}
+
+// ApplyAutoTagRules ré-applique les règles actives à toute la bibliothèque
+func (a *App) ApplyAutoTagRules() ([]services.AutoTagMatch, error) {
+	if a.autoTagService == nil {
+		return nil, fmt.Errorf("auto tag service not initialized")
+	}
+
+	return a.autoTagService.ApplyAutoTagRules()
+}
+
+// === Zones de visage ===
+
+// AddFaceRegion dessine une zone de visage (coordonnées normalisées 0..1), rattachée à une personne si tagName est renseigné
+func (a *App) AddFaceRegion(picturePath string, x, y, width, height float64, tagName string) (*models.FaceRegion, error) {
+	if a.faceRegionService == nil {
+		return nil, fmt.Errorf("face region service not initialized")
+	}
+
+	return a.faceRegionService.AddFaceRegion(picturePath, x, y, width, height, tagName)
+}
+
+// UpdateFaceRegion déplace ou redimensionne une zone de visage
+func (a *App) UpdateFaceRegion(id uint, x, y, width, height float64) error {
+	if a.faceRegionService == nil {
+		return fmt.Errorf("face region service not initialized")
+	}
+
+	return a.faceRegionService.UpdateFaceRegion(id, x, y, width, height)
+}
+
+// AssignFaceRegion rattache une zone de visage à une personne (vide = non identifiée)
+func (a *App) AssignFaceRegion(id uint, tagName string) error {
+	if a.faceRegionService == nil {
+		return fmt.Errorf("face region service not initialized")
+	}
+
+	return a.faceRegionService.AssignFaceRegion(id, tagName)
+}
+
+// DeleteFaceRegion supprime une zone de visage
+func (a *App) DeleteFaceRegion(id uint) error {
+	if a.faceRegionService == nil {
+		return fmt.Errorf("face region service not initialized")
+	}
+
+	return a.faceRegionService.DeleteFaceRegion(id)
+}
+
+// GetFaceRegions retourne les zones de visage d'une photo
+func (a *App) GetFaceRegions(picturePath string) ([]models.FaceRegion, error) {
+	if a.faceRegionService == nil {
+		return nil, fmt.Errorf("face region service not initialized")
+	}
+
+	return a.faceRegionService.GetFaceRegions(picturePath)
+}
+
+// GetFaceRegionsForTag retourne les zones de visage d'une personne
+func (a *App) GetFaceRegionsForTag(tagName string) ([]models.FaceRegion, error) {
+	if a.faceRegionService == nil {
+		return nil, fmt.Errorf("face region service not initialized")
+	}
+
+	return a.faceRegionService.GetFaceRegionsForTag(tagName)
+}
+
+// === Détection de visages ===
+
+// StartFaceDetection lance la détection de visages en tâche de fond sur les photos pas encore analysées
+// L'avancement est envoyé au frontend par l'événement "faces:progress"
+func (a *App) StartFaceDetection() error {
+	if a.faceService == nil {
+		return fmt.Errorf("face service not initialized")
+	}
+
+	return a.faceService.StartFaceDetection(func(status services.FaceJobStatus) {
+		runtime.EventsEmit(a.ctx, "faces:progress", status)
+	})
+}
+
+// StopFaceDetection interrompt la détection de visages
+func (a *App) StopFaceDetection() error {
+	if a.faceService == nil {
+		return fmt.Errorf("face service not initialized")
+	}
+
+	a.faceService.StopFaceDetection()
+	return nil
+}
+
+// GetFaceJobStatus retourne l'avancement de la détection de visages
+func (a *App) GetFaceJobStatus() (services.FaceJobStatus, error) {
+	if a.faceService == nil {
+		return services.FaceJobStatus{}, fmt.Errorf("face service not initialized")
+	}
+
+	return a.faceService.GetFaceJobStatus(), nil
+}
+
+// ClusterFaces regroupe de nouveau les visages non identifiés (threshold <= 0 = seuil par défaut)
+func (a *App) ClusterFaces(threshold float64) (int, error) {
+	if a.faceService == nil {
+		return 0, fmt.Errorf("face service not initialized")
+	}
+
+	return a.faceService.ClusterFaces(threshold)
+}
+
+// GetFaceClusters retourne les groupes de visages non identifiés
+func (a *App) GetFaceClusters() ([]services.FaceCluster, error) {
+	if a.faceService == nil {
+		return nil, fmt.Errorf("face service not initialized")
+	}
+
+	return a.faceService.GetFaceClusters()
+}
+
+// NameFaceCluster rattache tous les visages d'un groupe à une personne
+func (a *App) NameFaceCluster(clusterID uint, tagName string) (int, error) {
+	if a.faceService == nil {
+		return 0, fmt.Errorf("face service not initialized")
+	}
+
+	return a.faceService.NameFaceCluster(clusterID, tagName)
+}
+
+// === Métadonnées XMP / IPTC ===
+
+// SetMetadataPolicy choisit comment l'indexation importe les métadonnées d'un dossier surveillé
+// ("merge", "file", "database" ou "ignore")
+func (a *App) SetMetadataPolicy(folderPath string, policy models.MetadataPolicy) error {
+	if a.metadataService == nil {
+		return fmt.Errorf("metadata service not initialized")
+	}
+
+	return a.metadataService.SetMetadataPolicy(folderPath, policy)
+}
+
+// GetFileMetadata lit les mots-clés, lieux, personnes et note enregistrés dans le fichier d'une photo
+func (a *App) GetFileMetadata(picturePath string) (*services.FileMetadata, error) {
+	if a.metadataService == nil {
+		return nil, fmt.Errorf("metadata service not initialized")
+	}
+
+	return a.metadataService.GetFileMetadata(picturePath)
+}
+
+// ImportMetadata relit et importe les métadonnées des photos d'un dossier ("merge" ou "file")
+func (a *App) ImportMetadata(folderPath string, policy models.MetadataPolicy) (*services.MetadataImportResult, error) {
+	if a.metadataService == nil {
+		return nil, fmt.Errorf("metadata service not initialized")
+	}
+
+	return a.metadataService.ImportMetadata(folderPath, policy)
+}
+
+// SetSidecarWriteBack active ou désactive l'écriture des tags dans les sidecars XMP d'un dossier surveillé
+func (a *App) SetSidecarWriteBack(folderPath string, enabled bool) error {
+	if a.metadataService == nil {
+		return fmt.Errorf("metadata service not initialized")
+	}
+
+	return a.metadataService.SetSidecarWriteBack(folderPath, enabled)
+}
+
+// WriteSidecars écrit les sidecars XMP de toutes les photos d'un dossier surveillé
+func (a *App) WriteSidecars(folderPath string) (*services.SidecarWriteResult, error) {
+	if a.metadataService == nil {
+		return nil, fmt.Errorf("metadata service not initialized")
+	}
+
+	return a.metadataService.WriteSidecars(folderPath)
+}
+
+// === Regroupement en événements ===
+
+// ProposeEvents regroupe les photos en événements candidats (écarts de date et de position GPS)
+func (a *App) ProposeEvents(options services.EventClusterOptions) ([]services.EventCluster, error) {
+	if a.eventService == nil {
+		return nil, fmt.Errorf("event service not initialized")
+	}
+
+	return a.eventService.ProposeEvents(options)
+}
+
+// AcceptEventCluster pose un tag d'événement (nom proposé ou renommé) sur les photos d'un regroupement
+func (a *App) AcceptEventCluster(picturePaths []string, name string) ([]services.BulkTagResult, error) {
+	if a.eventService == nil {
+		return nil, fmt.Errorf("event service not initialized")
+	}
+
+	return a.eventService.AcceptEventCluster(picturePaths, name)
+}
+
+// RejectEventCluster refuse un regroupement proposé
+func (a *App) RejectEventCluster(picturePaths []string) error {
+	if a.eventService == nil {
+		return fmt.Errorf("event service not initialized")
+	}
+
+	return a.eventService.RejectEventCluster(picturePaths)
+}
+
+// ClearRejectedEventClusters oublie les regroupements refusés
+func (a *App) ClearRejectedEventClusters() error {
+	if a.eventService == nil {
+		return fmt.Errorf("event service not initialized")
+	}
+
+	return a.eventService.ClearRejectedEventClusters()
+}
+
+// === Albums intelligents (recherches enregistrées) ===
+
+// CreateSmartAlbum enregistre des critères de recherche sous un nom
+func (a *App) CreateSmartAlbum(name string, criteria services.SearchCriteria) (*models.SmartAlbum, error) {
+	if a.smartAlbumService == nil {
+		return nil, fmt.Errorf("smart album service not initialized")
+	}
+
+	return a.smartAlbumService.CreateSmartAlbum(name, criteria)
+}
+
+// GetSmartAlbums retourne tous les albums intelligents avec leur nombre de photos actuel
+func (a *App) GetSmartAlbums() ([]services.SmartAlbumWithCount, error) {
+	if a.smartAlbumService == nil {
+		return nil, fmt.Errorf("smart album service not initialized")
+	}
+
+	return a.smartAlbumService.GetSmartAlbums()
+}
+
+// UpdateSmartAlbum renomme un album intelligent et remplace ses critères
+func (a *App) UpdateSmartAlbum(id uint, name string, criteria services.SearchCriteria) error {
+	if a.smartAlbumService == nil {
+		return fmt.Errorf("smart album service not initialized")
+	}
+
+	return a.smartAlbumService.UpdateSmartAlbum(id, name, criteria)
+}
+
+// DeleteSmartAlbum supprime un album intelligent
+func (a *App) DeleteSmartAlbum(id uint) error {
+	if a.smartAlbumService == nil {
+		return fmt.Errorf("smart album service not initialized")
+	}
+
+	return a.smartAlbumService.DeleteSmartAlbum(id)
+}
+
+// GetSmartAlbumPictures exécute un album intelligent et retourne une page de photos
+func (a *App) GetSmartAlbumPictures(id uint, page services.PageRequest) (*services.PicturePage, error) {
+	if a.smartAlbumService == nil {
+		return nil, fmt.Errorf("smart album service not initialized")
+	}
+
+	return a.smartAlbumService.GetSmartAlbumPictures(id, page)
+}
+
+// === Albums manuels ===
+
+// CreateAlbum crée un nouvel album vide dans un dossier d'albums (0 = racine)
+func (a *App) CreateAlbum(name string, description string, folderID uint) (*models.Album, error) {
+	if a.albumService == nil {
+		return nil, fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.CreateAlbum(name, description, folderID)
+}
+
+// GetAlbums retourne tous les albums avec leur nombre de photos
+func (a *App) GetAlbums() ([]services.AlbumWithCount, error) {
+	if a.albumService == nil {
+		return nil, fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.GetAlbums()
+}
+
+// UpdateAlbum met à jour le nom et la description d'un album
+func (a *App) UpdateAlbum(id uint, name string, description string) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.UpdateAlbum(id, name, description)
+}
+
+// DeleteAlbum supprime un album (les photos ne sont pas touchées)
+func (a *App) DeleteAlbum(id uint) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.DeleteAlbum(id)
+}
+
+// SetAlbumCover choisit la photo de couverture d'un album (vide pour la retirer)
+func (a *App) SetAlbumCover(id uint, picturePath string) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.SetAlbumCover(id, picturePath)
+}
+
+// AddPicturesToAlbum ajoute des photos à la fin d'un album
+// Retourne le nombre de photos réellement ajoutées
+func (a *App) AddPicturesToAlbum(id uint, picturePaths []string) (int, error) {
+	if a.albumService == nil {
+		return 0, fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.AddPicturesToAlbum(id, picturePaths)
+}
+
+// RemovePicturesFromAlbum retire des photos d'un album
+func (a *App) RemovePicturesFromAlbum(id uint, picturePaths []string) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.RemovePicturesFromAlbum(id, picturePaths)
+}
+
+// MoveAlbumPictures déplace des photos juste avant beforePath (à la fin si vide)
+func (a *App) MoveAlbumPictures(id uint, picturePaths []string, beforePath string) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.MoveAlbumPictures(id, picturePaths, beforePath)
+}
+
+// ReorderAlbum remplace l'ordre complet d'un album
+func (a *App) ReorderAlbum(id uint, picturePaths []string) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.ReorderAlbum(id, picturePaths)
+}
+
+// GetAlbumPictures retourne une page des photos d'un album, dans l'ordre de l'album par défaut
+func (a *App) GetAlbumPictures(id uint, page services.PageRequest) (*services.PicturePage, error) {
+	if a.albumService == nil {
+		return nil, fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.GetAlbumPictures(id, page)
+}
+
+// === Dossiers d'albums ===
+
+// GetAlbumTree retourne l'arborescence des dossiers et albums pour la sidebar
+func (a *App) GetAlbumTree() (*services.AlbumTree, error) {
+	if a.albumService == nil {
+		return nil, fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.GetAlbumTree()
+}
+
+// CreateAlbumFolder crée un dossier d'albums (parentID 0 = racine)
+func (a *App) CreateAlbumFolder(name string, parentID uint) (*models.AlbumFolder, error) {
+	if a.albumService == nil {
+		return nil, fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.CreateAlbumFolder(name, parentID)
+}
+
+// RenameAlbumFolder renomme un dossier d'albums
+func (a *App) RenameAlbumFolder(id uint, name string) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.RenameAlbumFolder(id, name)
+}
+
+// MoveAlbumFolder déplace un dossier d'albums (newParentID 0 = racine)
+func (a *App) MoveAlbumFolder(id uint, newParentID uint) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.MoveAlbumFolder(id, newParentID)
+}
+
+// DeleteAlbumFolder supprime un dossier d'albums, son contenu remonte d'un niveau
+func (a *App) DeleteAlbumFolder(id uint) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.DeleteAlbumFolder(id)
+}
+
+// MoveAlbum range un album dans un dossier d'albums (0 = racine)
+func (a *App) MoveAlbum(albumID uint, folderID uint) error {
+	if a.albumService == nil {
+		return fmt.Errorf("album service not initialized")
+	}
+
+	return a.albumService.MoveAlbum(albumID, folderID)
+}
+
+// === Historique (annuler / rétablir) ===
+
+// Undo annule la dernière opération (retourne nil s'il n'y a rien à annuler)
+func (a *App) Undo() (*models.Operation, error) {
+	if a.historyService == nil {
+		return nil, fmt.Errorf("history service not initialized")
+	}
+
+	return a.historyService.Undo()
+}
+
+// Redo rétablit la dernière opération annulée (retourne nil s'il n'y a rien à rétablir)
+func (a *App) Redo() (*models.Operation, error) {
+	if a.historyService == nil {
+		return nil, fmt.Errorf("history service not initialized")
+	}
+
+	return a.historyService.Redo()
+}
+
+// GetHistory retourne l'historique des opérations, les plus récentes d'abord
+func (a *App) GetHistory() ([]models.Operation, error) {
+	if a.historyService == nil {
+		return nil, fmt.Errorf("history service not initialized")
+	}
+
+	return a.historyService.GetHistory()
+}
+
+// === Corbeille ===
+
+// GetTrash retourne les photos de la corbeille, de la plus récemment supprimée à la plus ancienne
+func (a *App) GetTrash() ([]models.TrashedPicture, error) {
+	if a.trashService == nil {
+		return nil, fmt.Errorf("trash service not initialized")
+	}
+
+	return a.trashService.GetTrash()
+}
+
+// RestorePicture remet une photo de la corbeille à son emplacement d'origine, avec ses tags et ses albums
+func (a *App) RestorePicture(id uint) error {
+	if a.trashService == nil {
+		return fmt.Errorf("trash service not initialized")
+	}
+
+	return a.trashService.RestorePicture(id)
+}
+
+// EmptyTrash supprime définitivement les photos de la corbeille depuis plus de olderThanDays jours (0 = toutes)
+func (a *App) EmptyTrash(olderThanDays int) (*services.TrashPurgeResult, error) {
+	if a.trashService == nil {
+		return nil, fmt.Errorf("trash service not initialized")
+	}
+
+	return a.trashService.EmptyTrash(olderThanDays)
+}
+
+// === Opérations sur les fichiers (déplacer, copier, renommer) ===
+
+// MovePictures déplace des photos dans un dossier en tenant l'index à jour
+// options.Preview calcule les chemins cibles sans rien modifier
+func (a *App) MovePictures(picturePaths []string, folderPath string, options services.FileOperationOptions) (*services.FileOperationResult, error) {
+	if a.fileService == nil {
+		return nil, fmt.Errorf("file operation service not initialized")
+	}
+
+	return a.fileService.MovePictures(picturePaths, folderPath, options)
+}
+
+// CopyPictures copie des photos dans un dossier et indexe les copies avec leurs tags
+func (a *App) CopyPictures(picturePaths []string, folderPath string, options services.FileOperationOptions) (*services.FileOperationResult, error) {
+	if a.fileService == nil {
+		return nil, fmt.Errorf("file operation service not initialized")
+	}
+
+	return a.fileService.CopyPictures(picturePaths, folderPath, options)
+}
+
+// RenamePictures renomme des photos selon un modèle (ex: "{date:2006-01-02}_{camera}_{seq:04}{ext}")
+func (a *App) RenamePictures(picturePaths []string, pattern string, options services.FileOperationOptions) (*services.FileOperationResult, error) {
+	if a.fileService == nil {
+		return nil, fmt.Errorf("file operation service not initialized")
+	}
+
+	return a.fileService.RenamePictures(picturePaths, pattern, options)
+}
+
+// === Import depuis une carte mémoire ===
+
+// ImportFromCard importe les nouvelles photos d'un dossier source (ex: DCIM d'une carte) dans un dossier surveillé,
+// rangées par date, puis les indexe et leur pose les tags demandés
+// L'avancement est envoyé au frontend par l'événement "import:progress"
+func (a *App) ImportFromCard(options services.ImportOptions) (*services.ImportResult, error) {
+	if a.importService == nil {
+		return nil, fmt.Errorf("import service not initialized")
+	}
+
+	return a.importService.ImportFromCard(options, func(progress services.ImportProgress) {
+		runtime.EventsEmit(a.ctx, "import:progress", progress)
+	})
 }
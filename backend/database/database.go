@@ -8,6 +8,7 @@ import (
 	"easygallery/backend/models"
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -30,8 +31,11 @@ func Init(dataDir string) error {
 	dbPath := filepath.Join(dataDir, "easygallery.db")
 
 	// Ouvrir la connexion SQLite avec GORM
+	// Journal WAL et attente sur verrou: les tâches de fond (détection de visages, vidage de la corbeille)
+	// écrivent en même temps que l'interface
+	dsn := dbPath + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
 	var err error
-	DB, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+	DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
@@ -44,14 +48,63 @@ func Init(dataDir string) error {
 		&models.Tag{},
 		&models.PictureTag{},
 		&models.WatchedFolder{},
+		&models.SmartAlbum{},
+		&models.Album{},
+		&models.AlbumPicture{},
+		&models.AlbumFolder{},
+		&models.TagAlias{},
+		&models.TagTypeDefinition{},
+		&models.Operation{},
+		&models.AutoTagRule{},
+		&models.RejectedEventCluster{},
+		&models.FaceRegion{},
+		&models.FaceEmbedding{},
+		&models.FaceScan{},
+		&models.PictureView{},
+		&models.TrashedPicture{},
+		&models.ExcludedPicture{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Types de tags prédéfinis
+	if err := seedTagTypes(); err != nil {
+		return fmt.Errorf("failed to create tag types: %w", err)
+	}
+
+	// Index plein texte (FTS5), maintenu par les services
+	if err := migrateSearchIndex(); err != nil {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+
 	fmt.Println("Database initialized successfully at:", dbPath)
 	return nil
 }
 
+// seedTagTypes crée les types de tags prédéfinis s'ils n'existent pas
+// Les types déjà présents ne sont pas modifiés (libellé, icône et couleur restent personnalisables)
+func seedTagTypes() error {
+	types := models.BuiltInTagTypes()
+	return DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&types).Error
+}
+
+// SearchIndexTable est le nom de la table virtuelle FTS5 de recherche plein texte
+const SearchIndexTable = "pictures_fts"
+
+// migrateSearchIndex crée la table virtuelle FTS5 si elle n'existe pas
+// Une ligne par photo: nom de fichier, noms des dossiers parents, noms des tags et légendes
+// remove_diacritics permet de trouver "Bretagne" avec "bretagne" et "Compiègne" avec "compiegne"
+func migrateSearchIndex() error {
+	return DB.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS ` + SearchIndexTable + ` USING fts5(
+		path UNINDEXED,
+		filename,
+		folders,
+		tags,
+		captions,
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`).Error
+}
+
 // Close ferme proprement la connexion à la base de données
 func Close() error {
 	if DB == nil {
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// Album représente un album manuel: une sélection ordonnée de photos
+type Album struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`            // Identifiant
+	Name        string    `gorm:"not null" json:"name"`            // Nom de l'album
+	Description string    `json:"description"`                     // Description libre
+	CoverPath   string    `json:"coverPath"`                       // Photo de couverture (vide si aucune)
+	FolderID    *uint     `gorm:"index" json:"folderId"`           // Dossier d'albums (nil = racine)
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"` // Date de création
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updatedAt"` // Date de dernière modification
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (Album) TableName() string {
+	return "albums"
+}
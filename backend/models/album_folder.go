@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// AlbumFolder représente un dossier d'albums, pour les organiser en arborescence
+// Exemple: "Clients / 2025" contenant l'album "Acme launch"
+type AlbumFolder struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`            // Identifiant
+	Name      string    `gorm:"not null" json:"name"`            // Nom du dossier
+	ParentID  *uint     `gorm:"index" json:"parentId"`           // Dossier parent (nil = racine)
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"` // Date de création
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"` // Date de modification
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (AlbumFolder) TableName() string {
+	return "album_folders"
+}
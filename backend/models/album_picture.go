@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// AlbumPicture représente la place d'une photo dans un album
+type AlbumPicture struct {
+	AlbumID     uint      `gorm:"primaryKey" json:"albumId"`           // FK vers Album.ID
+	PicturePath string    `gorm:"primaryKey;index" json:"picturePath"` // FK vers Picture.Path
+	Position    int       `gorm:"not null;index" json:"position"`      // Rang dans l'album (0 = premier)
+	AddedAt     time.Time `gorm:"autoCreateTime" json:"addedAt"`       // Date d'ajout à l'album
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (AlbumPicture) TableName() string {
+	return "album_pictures"
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// AutoTagPatternType représente la syntaxe du motif d'une règle d'auto-tag
+type AutoTagPatternType string
+
+const (
+	AutoTagPatternGlob  AutoTagPatternType = "glob"  // Motif glob: * (un segment), ** (plusieurs segments), ?
+	AutoTagPatternRegex AutoTagPatternType = "regex" // Expression régulière
+)
+
+// AutoTagRule représente une règle de tag automatique appliquée lors de l'indexation
+// Le motif porte sur le chemin relatif au dossier surveillé (ex: "Events/2023-05 Mariage Julie/IMG_001.jpg")
+// et le modèle de tag peut reprendre les groupes capturés (ex: "event:{1}", "location:Paris")
+type AutoTagRule struct {
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	FolderPath  string             `gorm:"index" json:"folderPath"`              // Dossier surveillé concerné (vide = tous)
+	Pattern     string             `gorm:"not null" json:"pattern"`              // Motif sur le chemin relatif
+	PatternType AutoTagPatternType `gorm:"not null" json:"patternType"`          // "glob" ou "regex"
+	TagTemplate string             `gorm:"not null" json:"tagTemplate"`          // "type:nom", {n} = n-ième groupe capturé
+	Enabled     bool               `gorm:"not null;default:true" json:"enabled"` // Règle active
+	CreatedAt   time.Time          `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time          `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (AutoTagRule) TableName() string {
+	return "auto_tag_rules"
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// RejectedEventCluster mémorise un regroupement d'événement refusé par l'utilisateur
+// Le même regroupement (mêmes photos) n'est plus proposé; il réapparaît si ses photos changent
+type RejectedEventCluster struct {
+	ClusterID    string    `gorm:"primaryKey" json:"clusterId"` // Empreinte des chemins des photos du regroupement
+	StartAt      time.Time `json:"startAt"`                     // Date de la première photo
+	EndAt        time.Time `json:"endAt"`                       // Date de la dernière photo
+	PictureCount int       `json:"pictureCount"`
+	RejectedAt   time.Time `gorm:"autoCreateTime" json:"rejectedAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (RejectedEventCluster) TableName() string {
+	return "rejected_event_clusters"
+}
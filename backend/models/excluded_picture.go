@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// ExcludedPicture représente une photo retirée de l'index par l'utilisateur
+// L'indexeur l'ignore tant qu'elle n'est pas réintégrée (le fichier reste sur le disque)
+type ExcludedPicture struct {
+	Path       string    `gorm:"primaryKey" json:"path"`           // Chemin absolu du fichier
+	ExcludedAt time.Time `gorm:"autoCreateTime" json:"excludedAt"` // Date du retrait
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (ExcludedPicture) TableName() string {
+	return "excluded_pictures"
+}
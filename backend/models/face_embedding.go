@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// FaceEmbedding représente la signature numérique d'une zone de visage
+// Les visages proches ont des signatures proches; le regroupement se fait sur ces vecteurs
+type FaceEmbedding struct {
+	RegionID  uint      `gorm:"primaryKey;autoIncrement:false" json:"regionId"` // FK vers FaceRegion.ID
+	Model     string    `gorm:"not null" json:"model"`                          // Modèle ayant calculé la signature
+	Vector    []byte    `gorm:"not null" json:"-"`                              // float32 little-endian, norme 1
+	ClusterID uint      `gorm:"index;default:0" json:"clusterId"`               // Groupe de visages inconnus (0 = aucun)
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (FaceEmbedding) TableName() string {
+	return "face_embeddings"
+}
+
+// FaceScan mémorise les photos déjà analysées par la détection de visages
+type FaceScan struct {
+	PicturePath string    `gorm:"primaryKey" json:"picturePath"` // FK vers Picture.Path
+	Detector    string    `gorm:"not null" json:"detector"`      // Détecteur utilisé
+	FaceCount   int       `json:"faceCount"`                     // Nombre de visages détectés
+	Error       string    `json:"error"`                         // Erreur de lecture éventuelle (photo ignorée)
+	ScannedAt   time.Time `gorm:"autoCreateTime" json:"scannedAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (FaceScan) TableName() string {
+	return "face_scans"
+}
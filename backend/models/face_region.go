@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// FaceRegionSource indique l'origine d'une zone de visage
+type FaceRegionSource string
+
+const (
+	FaceRegionManual FaceRegionSource = "manual" // Dessinée par l'utilisateur
+	FaceRegionAuto   FaceRegionSource = "auto"   // Détectée automatiquement
+	FaceRegionXMP    FaceRegionSource = "xmp"    // Importée des métadonnées du fichier (régions MWG)
+)
+
+// FaceRegion représente un rectangle sur une photo, éventuellement rattaché à une personne
+// Les coordonnées sont normalisées entre 0 et 1 (origine en haut à gauche), indépendamment de la résolution
+type FaceRegion struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	PicturePath string           `gorm:"not null;index" json:"picturePath"`
+	X           float64          `gorm:"not null" json:"x"`      // Bord gauche (0..1)
+	Y           float64          `gorm:"not null" json:"y"`      // Bord haut (0..1)
+	Width       float64          `gorm:"not null" json:"width"`  // Largeur (0..1)
+	Height      float64          `gorm:"not null" json:"height"` // Hauteur (0..1)
+	TagName     *string          `gorm:"index" json:"tagName"`   // Tag de la personne (nil = visage non identifié)
+	Source      FaceRegionSource `gorm:"not null;default:manual" json:"source"`
+	Confidence  float64          `json:"confidence"` // Score du détecteur (0 pour une zone dessinée)
+	CreatedAt   time.Time        `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time        `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (FaceRegion) TableName() string {
+	return "face_regions"
+}
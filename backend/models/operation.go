@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Operation représente une opération enregistrée dans l'historique d'annulation
+// Changes contient l'état des lignes modifiées avant et après l'opération (JSON)
+type Operation struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Description string    `gorm:"not null" json:"description"`                // Description lisible (ex: "delete tag 'Clara'")
+	Changes     string    `gorm:"not null" json:"-"`                          // Lignes modifiées (avant/après)
+	Undone      bool      `gorm:"not null;default:false;index" json:"undone"` // Opération annulée (disponible pour rétablir)
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (Operation) TableName() string {
+	return "operations"
+}
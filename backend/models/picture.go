@@ -11,14 +11,61 @@ type Picture struct {
 	Size       int64     `json:"size"`                          // Taille en bytes
 	Width      int       `json:"width"`                         // Largeur en pixels
 	Height     int       `json:"height"`                        // Hauteur en pixels
-	CreatedAt  time.Time `json:"createdAt"`                     // Date de création du fichier
+	CreatedAt  time.Time `json:"createdAt"`                     // Date de prise de vue (EXIF) ou de création du fichier
 	ModifiedAt time.Time `json:"modifiedAt"`                    // Date de modification du fichier
 	IndexedAt  time.Time `gorm:"autoCreateTime" json:"indexedAt"` // Date d'indexation dans la DB
 
+	// Empreinte SHA-256 du contenu du fichier (détection des doublons à l'import)
+	ContentHash string `gorm:"not null;default:'';index" json:"contentHash"`
+
+	// Position GPS issue de l'EXIF (nil si absente)
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+
+	// Photo marquée comme favorite
+	Favorite bool `gorm:"not null;default:false" json:"favorite"`
+
+	// Textes libres: titre et légende (exportés dans les sidecars XMP), notes privées
+	Title   string `gorm:"not null;default:''" json:"title"`
+	Caption string `gorm:"not null;default:''" json:"caption"`
+	Notes   string `gorm:"not null;default:''" json:"notes"`
+
+	// Tri des photos: note de 0 (aucune) à 5 étoiles, étiquette de couleur et marque retenue / rejetée
+	Rating     int        `gorm:"not null;default:0" json:"rating"`
+	ColorLabel ColorLabel `gorm:"not null;default:''" json:"colorLabel"`
+	Flag       PickFlag   `gorm:"not null;default:unflagged" json:"flag"`
+
+	// Date de modification du sidecar XMP lors du dernier import des métadonnées (zéro si aucun)
+	MetadataModifiedAt time.Time `json:"metadataModifiedAt"`
+
 	// Relations
 	Tags []Tag `gorm:"many2many:picture_tags;" json:"tags"` // Tags associés à la photo
 }
 
+// ColorLabel est l'étiquette de couleur d'une photo (vide = aucune)
+type ColorLabel string
+
+const (
+	ColorLabelNone   ColorLabel = ""
+	ColorLabelRed    ColorLabel = "red"
+	ColorLabelYellow ColorLabel = "yellow"
+	ColorLabelGreen  ColorLabel = "green"
+	ColorLabelBlue   ColorLabel = "blue"
+	ColorLabelPurple ColorLabel = "purple"
+)
+
+// ColorLabels liste les étiquettes de couleur dans l'ordre d'affichage
+var ColorLabels = []ColorLabel{ColorLabelRed, ColorLabelYellow, ColorLabelGreen, ColorLabelBlue, ColorLabelPurple}
+
+// PickFlag marque une photo retenue ou rejetée lors du tri
+type PickFlag string
+
+const (
+	PickFlagNone   PickFlag = "unflagged" // Non marquée
+	PickFlagPick   PickFlag = "pick"      // Retenue
+	PickFlagReject PickFlag = "reject"    // Rejetée
+)
+
 // TableName spécifie le nom de la table dans la DB
 func (Picture) TableName() string {
 	return "pictures"
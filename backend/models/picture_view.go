@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// PictureView enregistre l'ouverture d'une photo dans la visionneuse
+// Alimente les listes "vues récemment" et "les plus vues" (historique borné)
+type PictureView struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PicturePath string    `gorm:"not null;index" json:"picturePath"`
+	ViewedAt    time.Time `gorm:"autoCreateTime;index" json:"viewedAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (PictureView) TableName() string {
+	return "picture_views"
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// SmartAlbum représente une recherche enregistrée sous un nom ("album intelligent")
+// Son contenu est recalculé à chaque consultation: les nouvelles photos y apparaissent automatiquement
+type SmartAlbum struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`              // Identifiant
+	Name         string    `gorm:"uniqueIndex;not null" json:"name"`  // Nom unique de l'album
+	CriteriaJSON string    `gorm:"column:criteria;not null" json:"-"` // Critères de recherche sérialisés en JSON
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"createdAt"`   // Date de création
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updatedAt"`   // Date de modification
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (SmartAlbum) TableName() string {
+	return "smart_albums"
+}
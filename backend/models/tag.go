@@ -4,9 +4,10 @@ import (
 	"time"
 )
 
-// TagType représente le type d'un tag
+// TagType représente le type d'un tag (clé de la table tag_types)
 type TagType string
 
+// Types prédéfinis (voir BuiltInTagTypes)
 const (
 	TagTypePerson   TagType = "person"   // Personne
 	TagTypeLocation TagType = "location" // Lieu
@@ -16,10 +17,12 @@ const (
 
 // Tag représente un tag qui peut être associé à des photos
 type Tag struct {
-	Name      string    `gorm:"primaryKey" json:"name"` // Nom unique du tag (ID)
-	Type      TagType   `gorm:"not null" json:"type"`   // Type du tag
-	Color     string    `json:"color"`                  // Couleur HEX pour l'UI (ex: "#3B82F6")
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	Name       string    `gorm:"primaryKey" json:"name"`             // Nom unique du tag (ID)
+	Type       TagType   `gorm:"not null" json:"type"`               // Type du tag (FK vers TagTypeDefinition.Name)
+	Color      string    `json:"color"`                              // Couleur HEX pour l'UI (ex: "#3B82F6")
+	ParentName *string   `gorm:"index" json:"parentName"`            // Tag parent (nil = racine), ex: Europe > France > Paris
+	NameKey    string    `gorm:"not null;default:'';index" json:"-"` // Nom sans casse ni accents, pour résoudre les noms saisis
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"createdAt"`
 
 	// Relations
 	Pictures []Picture `gorm:"many2many:picture_tags;" json:"pictures,omitempty"` // Photos associées
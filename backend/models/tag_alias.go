@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// TagAlias représente un autre nom d'un tag (ex: "Paris, France" -> "Paris")
+// Les alias sont résolus vers le tag canonique lors de l'attribution, de la recherche et de l'import
+type TagAlias struct {
+	Key       string    `gorm:"primaryKey" json:"-"`           // Alias normalisé (minuscules, sans accents)
+	Alias     string    `gorm:"not null" json:"alias"`         // Alias tel que saisi
+	TagName   string    `gorm:"not null;index" json:"tagName"` // FK vers Tag.Name (tag canonique)
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+
+	// Relations
+	Tag Tag `gorm:"foreignKey:TagName;references:Name" json:"-"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (TagAlias) TableName() string {
+	return "tag_aliases"
+}
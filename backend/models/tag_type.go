@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// TagTypeDefinition représente un type de tag (personne, lieu, projet, client...)
+// Les quatre types historiques sont créés au démarrage; les autres sont définis par l'utilisateur
+type TagTypeDefinition struct {
+	Name      TagType   `gorm:"primaryKey" json:"name"`                // Identifiant du type (ex: "person", "camera body")
+	Label     string    `gorm:"not null" json:"label"`                 // Libellé affiché
+	Icon      string    `json:"icon"`                                  // Icône (emoji) affichée dans l'UI
+	Color     string    `json:"color"`                                 // Couleur HEX par défaut des nouveaux tags
+	SortOrder int       `gorm:"not null;default:0" json:"sortOrder"`   // Ordre d'affichage
+	BuiltIn   bool      `gorm:"not null;default:false" json:"builtIn"` // Type prédéfini (non supprimable)
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (TagTypeDefinition) TableName() string {
+	return "tag_types"
+}
+
+// BuiltInTagTypes retourne les types de tags prédéfinis
+func BuiltInTagTypes() []TagTypeDefinition {
+	return []TagTypeDefinition{
+		{Name: TagTypePerson, Label: "Personnes", Icon: "👤", Color: "#3B82F6", SortOrder: 0, BuiltIn: true},
+		{Name: TagTypeLocation, Label: "Lieux", Icon: "📍", Color: "#22C55E", SortOrder: 1, BuiltIn: true},
+		{Name: TagTypeEvent, Label: "Evenements", Icon: "📅", Color: "#8B5CF6", SortOrder: 2, BuiltIn: true},
+		{Name: TagTypeOther, Label: "Autres", Icon: "🏷️", Color: "#6B7280", SortOrder: 3, BuiltIn: true},
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// TrashedPicture représente une photo supprimée, déplacée dans la corbeille
+// Snapshot conserve la ligne de la photo, ses tags et ses albums pour la restaurer à l'identique
+type TrashedPicture struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	OriginalPath         string    `gorm:"not null;index" json:"originalPath"` // Chemin de la photo avant suppression
+	TrashPath            string    `gorm:"not null" json:"trashPath"`          // Emplacement du fichier dans la corbeille
+	TrashInfoPath        string    `json:"-"`                                  // Fichier .trashinfo (corbeille freedesktop.org, vide sinon)
+	SidecarPath          string    `json:"sidecarPath"`                        // Sidecar XMP avant suppression (vide si aucun)
+	SidecarTrashPath     string    `json:"-"`                                  // Emplacement du sidecar dans la corbeille
+	SidecarTrashInfoPath string    `json:"-"`                                  // Fichier .trashinfo du sidecar
+	Size                 int64     `json:"size"`                               // Taille du fichier en bytes
+	Snapshot             string    `gorm:"not null" json:"-"`                  // État de la photo en base (JSON)
+	DeletedAt            time.Time `gorm:"autoCreateTime;index" json:"deletedAt"`
+}
+
+// TableName spécifie le nom de la table dans la DB
+func (TrashedPicture) TableName() string {
+	return "trashed_pictures"
+}
@@ -12,8 +12,21 @@ type WatchedFolder struct {
 	LastIndexedAt  time.Time `json:"lastIndexedAt"`                    // Dernière indexation
 	PictureCount   int       `json:"pictureCount"`                     // Nombre de photos indexées
 	AutoReindex    bool      `gorm:"default:false" json:"autoReindex"` // Ré-indexation automatique
+	MetadataPolicy MetadataPolicy `gorm:"not null;default:merge" json:"metadataPolicy"` // Import des métadonnées XMP / IPTC
+	WriteSidecars  bool           `gorm:"not null;default:false" json:"writeSidecars"`  // Écriture des tags dans les sidecars XMP
+	IgnorePatterns string         `gorm:"not null;default:''" json:"ignorePatterns"`    // Motifs glob ignorés à l'indexation, un par ligne
 }
 
+// MetadataPolicy règle l'import des métadonnées des fichiers (XMP / IPTC) et leurs conflits avec la base
+type MetadataPolicy string
+
+const (
+	MetadataPolicyMerge    MetadataPolicy = "merge"    // Ajoute les mots-clés du fichier; la note de la base est conservée
+	MetadataPolicyFile     MetadataPolicy = "file"     // Le fichier fait foi: note remplacée, tags absents du fichier retirés
+	MetadataPolicyDatabase MetadataPolicy = "database" // La base fait foi: import uniquement à l'ajout de la photo
+	MetadataPolicyIgnore   MetadataPolicy = "ignore"   // Aucun import
+)
+
 // TableName spécifie le nom de la table dans la DB
 func (WatchedFolder) TableName() string {
 	return "watched_folders"
@@ -0,0 +1,751 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// AlbumService gère les albums manuels et l'ordre de leurs photos
+type AlbumService struct{}
+
+// NewAlbumService crée une nouvelle instance d'AlbumService
+func NewAlbumService() *AlbumService {
+	return &AlbumService{}
+}
+
+// AlbumWithCount représente un album avec son nombre de photos
+type AlbumWithCount struct {
+	models.Album
+	PictureCount int64 `json:"pictureCount"`
+}
+
+// CreateAlbum crée un nouvel album vide dans un dossier d'albums (0 = racine)
+func (as *AlbumService) CreateAlbum(name string, description string, folderID uint) (*models.Album, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("album name cannot be empty")
+	}
+
+	folder, err := albumFolderRef(database.DB, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	album := models.Album{
+		Name:        name,
+		Description: description,
+		FolderID:    folder,
+	}
+
+	if err := database.DB.Create(&album).Error; err != nil {
+		return nil, fmt.Errorf("cannot create album: %w", err)
+	}
+
+	return &album, nil
+}
+
+// GetAlbums retourne tous les albums avec leur nombre de photos
+func (as *AlbumService) GetAlbums() ([]AlbumWithCount, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var albums []AlbumWithCount
+	err := database.DB.Model(&models.Album{}).
+		Select("albums.*, (SELECT COUNT(*) FROM album_pictures WHERE album_pictures.album_id = albums.id) AS picture_count").
+		Order("albums.name").
+		Scan(&albums).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch albums: %w", err)
+	}
+
+	return albums, nil
+}
+
+// UpdateAlbum met à jour le nom et la description d'un album
+func (as *AlbumService) UpdateAlbum(id uint, name string, description string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("album name cannot be empty")
+	}
+
+	var album models.Album
+	if err := database.DB.First(&album, id).Error; err != nil {
+		return fmt.Errorf("album %d not found", id)
+	}
+
+	album.Name = name
+	album.Description = description
+
+	if err := database.DB.Save(&album).Error; err != nil {
+		return fmt.Errorf("cannot update album: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAlbum supprime un album (les photos elles-mêmes ne sont pas touchées)
+func (as *AlbumService) DeleteAlbum(id uint) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("album_id = ?", id).Delete(&models.AlbumPicture{}).Error; err != nil {
+			return fmt.Errorf("cannot delete album pictures: %w", err)
+		}
+
+		result := tx.Delete(&models.Album{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("cannot delete album: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("album %d not found", id)
+		}
+
+		return nil
+	})
+}
+
+// SetAlbumCover choisit la photo de couverture d'un album (chemin vide pour la retirer)
+// La photo doit faire partie de l'album
+func (as *AlbumService) SetAlbumCover(id uint, picturePath string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var album models.Album
+	if err := database.DB.First(&album, id).Error; err != nil {
+		return fmt.Errorf("album %d not found", id)
+	}
+
+	if picturePath != "" {
+		var entry models.AlbumPicture
+		if err := database.DB.Where("album_id = ? AND picture_path = ?", id, picturePath).First(&entry).Error; err != nil {
+			return fmt.Errorf("picture is not in album: %s", picturePath)
+		}
+	}
+
+	album.CoverPath = picturePath
+
+	if err := database.DB.Save(&album).Error; err != nil {
+		return fmt.Errorf("cannot update album cover: %w", err)
+	}
+
+	return nil
+}
+
+// AddPicturesToAlbum ajoute des photos à la fin d'un album
+// Les photos déjà présentes gardent leur place; retourne le nombre de photos ajoutées
+func (as *AlbumService) AddPicturesToAlbum(id uint, picturePaths []string) (int, error) {
+	if err := checkDB(); err != nil {
+		return 0, err
+	}
+
+	added := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := loadAlbumOrder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		present := make(map[string]bool, len(order))
+		for _, path := range order {
+			present[path] = true
+		}
+
+		// Vérifier que les photos existent
+		var existing []string
+		if err := tx.Model(&models.Picture{}).Where("path IN ?", picturePaths).Pluck("path", &existing).Error; err != nil {
+			return fmt.Errorf("cannot fetch pictures: %w", err)
+		}
+		indexed := make(map[string]bool, len(existing))
+		for _, path := range existing {
+			indexed[path] = true
+		}
+
+		position := len(order)
+		for _, path := range picturePaths {
+			if !indexed[path] {
+				return fmt.Errorf("picture not found: %s", path)
+			}
+			if present[path] {
+				continue
+			}
+
+			entry := models.AlbumPicture{
+				AlbumID:     id,
+				PicturePath: path,
+				Position:    position,
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return fmt.Errorf("cannot add picture to album: %w", err)
+			}
+
+			present[path] = true
+			position++
+			added++
+		}
+
+		return touchAlbum(tx, id)
+	})
+
+	return added, err
+}
+
+// RemovePicturesFromAlbum retire des photos d'un album et resserre l'ordre des suivantes
+func (as *AlbumService) RemovePicturesFromAlbum(id uint, picturePaths []string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := loadAlbumOrder(tx, id); err != nil {
+			return err
+		}
+
+		if err := removeAlbumEntries(tx, []uint{id}, picturePaths); err != nil {
+			return err
+		}
+
+		return touchAlbum(tx, id)
+	})
+}
+
+// MoveAlbumPictures déplace un groupe de photos juste avant beforePath
+// (ou à la fin si beforePath est vide), en conservant leur ordre relatif
+func (as *AlbumService) MoveAlbumPictures(id uint, picturePaths []string, beforePath string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := loadAlbumOrder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		moving := make(map[string]bool, len(picturePaths))
+		for _, path := range picturePaths {
+			moving[path] = true
+		}
+		if moving[beforePath] {
+			return fmt.Errorf("cannot move pictures before one of themselves: %s", beforePath)
+		}
+
+		// Séparer les photos déplacées (dans leur ordre actuel) du reste
+		var moved, rest []string
+		for _, path := range order {
+			if moving[path] {
+				moved = append(moved, path)
+			} else {
+				rest = append(rest, path)
+			}
+		}
+		if len(moved) != len(moving) {
+			return fmt.Errorf("some pictures are not in album %d", id)
+		}
+
+		// Point d'insertion
+		insertAt := len(rest)
+		if beforePath != "" {
+			insertAt = -1
+			for i, path := range rest {
+				if path == beforePath {
+					insertAt = i
+					break
+				}
+			}
+			if insertAt < 0 {
+				return fmt.Errorf("picture is not in album: %s", beforePath)
+			}
+		}
+
+		newOrder := make([]string, 0, len(order))
+		newOrder = append(newOrder, rest[:insertAt]...)
+		newOrder = append(newOrder, moved...)
+		newOrder = append(newOrder, rest[insertAt:]...)
+
+		if err := writeAlbumOrder(tx, id, order, newOrder); err != nil {
+			return err
+		}
+
+		return touchAlbum(tx, id)
+	})
+}
+
+// ReorderAlbum remplace l'ordre complet d'un album
+// picturePaths doit contenir exactement les photos de l'album
+func (as *AlbumService) ReorderAlbum(id uint, picturePaths []string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := loadAlbumOrder(tx, id)
+		if err != nil {
+			return err
+		}
+
+		present := make(map[string]bool, len(order))
+		for _, path := range order {
+			present[path] = true
+		}
+		seen := make(map[string]bool, len(picturePaths))
+		for _, path := range picturePaths {
+			if !present[path] || seen[path] {
+				return fmt.Errorf("invalid album order: unexpected or duplicated picture %s", path)
+			}
+			seen[path] = true
+		}
+		if len(seen) != len(order) {
+			return fmt.Errorf("invalid album order: expected %d pictures, got %d", len(order), len(seen))
+		}
+
+		if err := writeAlbumOrder(tx, id, order, picturePaths); err != nil {
+			return err
+		}
+
+		return touchAlbum(tx, id)
+	})
+}
+
+// GetAlbumPictures retourne une page des photos d'un album (par défaut dans l'ordre de l'album)
+func (as *AlbumService) GetAlbumPictures(id uint, page PageRequest) (*PicturePage, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var album models.Album
+	if err := database.DB.First(&album, id).Error; err != nil {
+		return nil, fmt.Errorf("album %d not found", id)
+	}
+
+	if page.Sort == "" {
+		page.Sort = SortByAlbumOrder
+	}
+	if err := checkSortAvailable(page.Sort, false, true); err != nil {
+		return nil, err
+	}
+
+	query := picturesQuery().
+		Joins("JOIN album_pictures ON album_pictures.picture_path = pictures.path AND album_pictures.album_id = ?", id)
+
+	return paginatePictures(query, page)
+}
+
+// loadAlbumOrder vérifie que l'album existe et retourne ses photos dans l'ordre
+func loadAlbumOrder(tx *gorm.DB, id uint) ([]string, error) {
+	var album models.Album
+	if err := tx.First(&album, id).Error; err != nil {
+		return nil, fmt.Errorf("album %d not found", id)
+	}
+
+	var order []string
+	err := tx.Model(&models.AlbumPicture{}).
+		Where("album_id = ?", id).
+		Order("position").
+		Pluck("picture_path", &order).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch album pictures: %w", err)
+	}
+
+	return order, nil
+}
+
+// writeAlbumOrder enregistre un nouvel ordre en ne modifiant que les positions qui changent
+func writeAlbumOrder(tx *gorm.DB, id uint, oldOrder, newOrder []string) error {
+	for i, path := range newOrder {
+		if i < len(oldOrder) && oldOrder[i] == path {
+			continue
+		}
+		err := tx.Model(&models.AlbumPicture{}).
+			Where("album_id = ? AND picture_path = ?", id, path).
+			Update("position", i).Error
+		if err != nil {
+			return fmt.Errorf("cannot update album order: %w", err)
+		}
+	}
+	return nil
+}
+
+// touchAlbum met à jour la date de modification d'un album
+func touchAlbum(tx *gorm.DB, id uint) error {
+	if err := tx.Model(&models.Album{}).Where("id = ?", id).Update("updated_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("cannot update album: %w", err)
+	}
+	return nil
+}
+
+// removeAlbumEntries retire des photos des albums donnés, resserre l'ordre
+// et retire les couvertures devenues invalides
+func removeAlbumEntries(tx *gorm.DB, albumIDs []uint, picturePaths []string) error {
+	if len(albumIDs) == 0 || len(picturePaths) == 0 {
+		return nil
+	}
+
+	if err := tx.Where("album_id IN ? AND picture_path IN ?", albumIDs, picturePaths).Delete(&models.AlbumPicture{}).Error; err != nil {
+		return fmt.Errorf("cannot remove pictures from album: %w", err)
+	}
+
+	err := tx.Model(&models.Album{}).
+		Where("id IN ? AND cover_path IN ?", albumIDs, picturePaths).
+		Update("cover_path", "").Error
+	if err != nil {
+		return fmt.Errorf("cannot clear album cover: %w", err)
+	}
+
+	// Resserrer les positions (0..n-1) de chaque album touché
+	for _, id := range albumIDs {
+		var order []string
+		if err := tx.Model(&models.AlbumPicture{}).Where("album_id = ?", id).Order("position").Pluck("picture_path", &order).Error; err != nil {
+			return fmt.Errorf("cannot fetch album pictures: %w", err)
+		}
+		for i, path := range order {
+			err := tx.Model(&models.AlbumPicture{}).
+				Where("album_id = ? AND picture_path = ? AND position <> ?", id, path, i).
+				Update("position", i).Error
+			if err != nil {
+				return fmt.Errorf("cannot update album order: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removePicturesFromAllAlbums retire des photos de tous les albums qui les contiennent
+// Appelé quand une photo quitte l'index
+func removePicturesFromAllAlbums(tx *gorm.DB, picturePaths ...string) error {
+	if len(picturePaths) == 0 {
+		return nil
+	}
+
+	var albumIDs []uint
+	if err := tx.Model(&models.AlbumPicture{}).Where("picture_path IN ?", picturePaths).Distinct().Pluck("album_id", &albumIDs).Error; err != nil {
+		return fmt.Errorf("cannot fetch albums of pictures: %w", err)
+	}
+
+	if err := removeAlbumEntries(tx, albumIDs, picturePaths); err != nil {
+		return err
+	}
+
+	for _, id := range albumIDs {
+		if err := touchAlbum(tx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// === Dossiers d'albums ===
+
+// AlbumFolderNode représente un dossier d'albums et son contenu dans l'arborescence
+type AlbumFolderNode struct {
+	models.AlbumFolder
+	Folders      []AlbumFolderNode `json:"folders"`      // Sous-dossiers
+	Albums       []AlbumWithCount  `json:"albums"`       // Albums du dossier
+	PictureCount int64             `json:"pictureCount"` // Photos distinctes dans tout le sous-arbre
+}
+
+// AlbumTree représente l'arborescence complète des albums
+type AlbumTree struct {
+	Folders []AlbumFolderNode `json:"folders"` // Dossiers racine
+	Albums  []AlbumWithCount  `json:"albums"`  // Albums à la racine
+}
+
+// albumFolderRef vérifie qu'un dossier d'albums existe et retourne la référence à stocker
+// L'identifiant 0 désigne la racine (nil)
+func albumFolderRef(tx *gorm.DB, id uint) (*uint, error) {
+	if id == 0 {
+		return nil, nil
+	}
+
+	var folder models.AlbumFolder
+	if err := tx.First(&folder, id).Error; err != nil {
+		return nil, fmt.Errorf("album folder %d not found", id)
+	}
+
+	return &folder.ID, nil
+}
+
+// checkAlbumFolderName vérifie qu'aucun dossier frère ne porte déjà ce nom
+func checkAlbumFolderName(tx *gorm.DB, name string, parent *uint, excludeID uint) error {
+	query := tx.Model(&models.AlbumFolder{}).Where("name = ? AND id <> ?", name, excludeID)
+	if parent == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parent)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("cannot check album folder name: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("album folder '%s' already exists here", name)
+	}
+	return nil
+}
+
+// CreateAlbumFolder crée un dossier d'albums dans un dossier parent (0 = racine)
+func (as *AlbumService) CreateAlbumFolder(name string, parentID uint) (*models.AlbumFolder, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("album folder name cannot be empty")
+	}
+
+	parent, err := albumFolderRef(database.DB, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAlbumFolderName(database.DB, name, parent, 0); err != nil {
+		return nil, err
+	}
+
+	folder := models.AlbumFolder{
+		Name:     name,
+		ParentID: parent,
+	}
+
+	if err := database.DB.Create(&folder).Error; err != nil {
+		return nil, fmt.Errorf("cannot create album folder: %w", err)
+	}
+
+	return &folder, nil
+}
+
+// RenameAlbumFolder renomme un dossier d'albums
+func (as *AlbumService) RenameAlbumFolder(id uint, name string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("album folder name cannot be empty")
+	}
+
+	var folder models.AlbumFolder
+	if err := database.DB.First(&folder, id).Error; err != nil {
+		return fmt.Errorf("album folder %d not found", id)
+	}
+	if err := checkAlbumFolderName(database.DB, name, folder.ParentID, id); err != nil {
+		return err
+	}
+
+	folder.Name = name
+
+	if err := database.DB.Save(&folder).Error; err != nil {
+		return fmt.Errorf("cannot rename album folder: %w", err)
+	}
+
+	return nil
+}
+
+// MoveAlbumFolder déplace un dossier d'albums sous un autre dossier (0 = racine)
+// Un dossier ne peut pas être déplacé dans lui-même ni dans l'un de ses descendants
+func (as *AlbumService) MoveAlbumFolder(id uint, newParentID uint) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var folder models.AlbumFolder
+		if err := tx.First(&folder, id).Error; err != nil {
+			return fmt.Errorf("album folder %d not found", id)
+		}
+
+		parent, err := albumFolderRef(tx, newParentID)
+		if err != nil {
+			return err
+		}
+
+		// Prévention des cycles: le dossier ne doit pas figurer parmi les ancêtres du nouveau parent
+		if parent != nil {
+			var cycles int64
+			err := tx.Raw(`
+				WITH RECURSIVE ancestors(id, parent_id) AS (
+					SELECT id, parent_id FROM album_folders WHERE id = ?
+					UNION
+					SELECT f.id, f.parent_id FROM album_folders f JOIN ancestors a ON f.id = a.parent_id
+				)
+				SELECT COUNT(*) FROM ancestors WHERE id = ?`, *parent, id).Scan(&cycles).Error
+			if err != nil {
+				return fmt.Errorf("cannot check album folder hierarchy: %w", err)
+			}
+			if cycles > 0 {
+				return fmt.Errorf("cannot move album folder into itself or one of its subfolders")
+			}
+		}
+
+		if err := checkAlbumFolderName(tx, folder.Name, parent, id); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&folder).Update("parent_id", parent).Error; err != nil {
+			return fmt.Errorf("cannot move album folder: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteAlbumFolder supprime un dossier d'albums
+// Ses sous-dossiers et albums remontent dans le dossier parent: aucun album n'est supprimé
+func (as *AlbumService) DeleteAlbumFolder(id uint) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var folder models.AlbumFolder
+		if err := tx.First(&folder, id).Error; err != nil {
+			return fmt.Errorf("album folder %d not found", id)
+		}
+
+		if err := tx.Model(&models.AlbumFolder{}).Where("parent_id = ?", id).Update("parent_id", folder.ParentID).Error; err != nil {
+			return fmt.Errorf("cannot move subfolders: %w", err)
+		}
+		if err := tx.Model(&models.Album{}).Where("folder_id = ?", id).Update("folder_id", folder.ParentID).Error; err != nil {
+			return fmt.Errorf("cannot move albums: %w", err)
+		}
+
+		if err := tx.Delete(&folder).Error; err != nil {
+			return fmt.Errorf("cannot delete album folder: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// MoveAlbum range un album dans un dossier d'albums (0 = racine)
+func (as *AlbumService) MoveAlbum(albumID uint, folderID uint) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var album models.Album
+	if err := database.DB.First(&album, albumID).Error; err != nil {
+		return fmt.Errorf("album %d not found", albumID)
+	}
+
+	folder, err := albumFolderRef(database.DB, folderID)
+	if err != nil {
+		return err
+	}
+
+	if err := database.DB.Model(&album).Update("folder_id", folder).Error; err != nil {
+		return fmt.Errorf("cannot move album: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlbumTree retourne l'arborescence des dossiers et albums
+// Le nombre de photos d'un dossier compte les photos distinctes de tous ses albums, récursivement
+func (as *AlbumService) GetAlbumTree() (*AlbumTree, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var folders []models.AlbumFolder
+	if err := database.DB.Order("name").Find(&folders).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch album folders: %w", err)
+	}
+
+	albums, err := as.GetAlbums()
+	if err != nil {
+		return nil, err
+	}
+
+	// Nombre de photos distinctes par sous-arbre, en une requête
+	type folderCount struct {
+		RootID uint
+		Count  int64
+	}
+	var counts []folderCount
+	err = database.DB.Raw(`
+		WITH RECURSIVE subtree(root_id, id) AS (
+			SELECT id, id FROM album_folders
+			UNION ALL
+			SELECT s.root_id, f.id FROM album_folders f JOIN subtree s ON f.parent_id = s.id
+		)
+		SELECT s.root_id AS root_id, COUNT(DISTINCT ap.picture_path) AS count
+		FROM subtree s
+		JOIN albums a ON a.folder_id = s.id
+		JOIN album_pictures ap ON ap.album_id = a.id
+		GROUP BY s.root_id`).Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot count album folder pictures: %w", err)
+	}
+	countByFolder := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		countByFolder[c.RootID] = c.Count
+	}
+
+	// Regrouper par parent
+	childFolders := make(map[uint][]models.AlbumFolder)
+	for _, folder := range folders {
+		var parent uint
+		if folder.ParentID != nil {
+			parent = *folder.ParentID
+		}
+		childFolders[parent] = append(childFolders[parent], folder)
+	}
+	childAlbums := make(map[uint][]AlbumWithCount)
+	for _, album := range albums {
+		var parent uint
+		if album.FolderID != nil {
+			parent = *album.FolderID
+		}
+		childAlbums[parent] = append(childAlbums[parent], album)
+	}
+
+	var build func(parent uint) []AlbumFolderNode
+	build = func(parent uint) []AlbumFolderNode {
+		nodes := []AlbumFolderNode{}
+		for _, folder := range childFolders[parent] {
+			albums := childAlbums[folder.ID]
+			if albums == nil {
+				albums = []AlbumWithCount{}
+			}
+			nodes = append(nodes, AlbumFolderNode{
+				AlbumFolder:  folder,
+				Folders:      build(folder.ID),
+				Albums:       albums,
+				PictureCount: countByFolder[folder.ID],
+			})
+		}
+		return nodes
+	}
+
+	rootAlbums := childAlbums[0]
+	if rootAlbums == nil {
+		rootAlbums = []AlbumWithCount{}
+	}
+
+	return &AlbumTree{
+		Folders: build(0),
+		Albums:  rootAlbums,
+	}, nil
+}
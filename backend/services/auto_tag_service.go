@@ -0,0 +1,557 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AutoTagService gère les règles de tag automatique basées sur les chemins
+type AutoTagService struct{}
+
+// NewAutoTagService crée une nouvelle instance d'AutoTagService
+func NewAutoTagService() *AutoTagService {
+	return &AutoTagService{}
+}
+
+// AutoTagMatch représente un tag qu'une règle pose (ou poserait) sur une photo
+type AutoTagMatch struct {
+	RuleID        uint           `json:"ruleId"`
+	PicturePath   string         `json:"picturePath"`
+	RelativePath  string         `json:"relativePath"` // Chemin relatif au dossier surveillé, tel que comparé au motif
+	TagName       string         `json:"tagName"`      // Nom canonique si le tag existe déjà
+	TagType       models.TagType `json:"tagType"`
+	NewTag        bool           `json:"newTag"`        // Le tag sera créé
+	AlreadyTagged bool           `json:"alreadyTagged"` // La photo porte déjà ce tag
+}
+
+// autoTagGroupRef repère les références aux groupes capturés dans un modèle de tag ({1}, {2}...)
+var autoTagGroupRef = regexp.MustCompile(`\{(\d+)\}`)
+
+// compiledAutoTagRule est une règle prête à être évaluée
+type compiledAutoTagRule struct {
+	rule     models.AutoTagRule
+	re       *regexp.Regexp
+	tagType  models.TagType
+	template string // Partie "nom" du modèle
+}
+
+// globToRegexp convertit un motif glob en expression régulière ancrée
+// Chaque joker devient un groupe capturé: * (un segment), ** (plusieurs segments), ? (un caractère)
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" accepte aussi zéro dossier
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("((?:.*/)?)")
+				} else {
+					b.WriteString("(.*)")
+				}
+			} else {
+				b.WriteString("([^/]*)")
+			}
+		case '?':
+			b.WriteString("([^/])")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// compileAutoTagRule valide le motif et le modèle de tag d'une règle
+// Le modèle "type:nom" pose un tag du type donné; sans préfixe de type connu, le tag est de type "other"
+func compileAutoTagRule(db *gorm.DB, rule models.AutoTagRule) (*compiledAutoTagRule, error) {
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return nil, fmt.Errorf("pattern cannot be empty")
+	}
+
+	expr := rule.Pattern
+	switch rule.PatternType {
+	case models.AutoTagPatternGlob:
+		expr = globToRegexp(filepath.ToSlash(rule.Pattern))
+	case models.AutoTagPatternRegex:
+	default:
+		return nil, fmt.Errorf("invalid pattern type: %s", rule.PatternType)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	c := &compiledAutoTagRule{rule: rule, re: re, tagType: models.TagTypeOther, template: strings.TrimSpace(rule.TagTemplate)}
+	if prefix, name, found := strings.Cut(c.template, ":"); found {
+		var count int64
+		if err := db.Model(&models.TagTypeDefinition{}).Where("name = ?", strings.TrimSpace(prefix)).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch tag type: %w", err)
+		}
+		if count > 0 {
+			c.tagType = models.TagType(strings.TrimSpace(prefix))
+			c.template = strings.TrimSpace(name)
+		}
+	}
+	if c.template == "" {
+		return nil, fmt.Errorf("tag template cannot be empty")
+	}
+
+	for _, ref := range autoTagGroupRef.FindAllStringSubmatch(c.template, -1) {
+		n, _ := strconv.Atoi(ref[1])
+		if n > re.NumSubexp() {
+			return nil, fmt.Errorf("tag template references group {%d} but pattern has only %d", n, re.NumSubexp())
+		}
+	}
+
+	return c, nil
+}
+
+// tagName retourne le nom de tag produit par la règle pour un chemin relatif (vide = pas de correspondance)
+func (c *compiledAutoTagRule) tagName(relativePath string) string {
+	groups := c.re.FindStringSubmatch(relativePath)
+	if groups == nil {
+		return ""
+	}
+
+	name := autoTagGroupRef.ReplaceAllStringFunc(c.template, func(ref string) string {
+		n, _ := strconv.Atoi(ref[1 : len(ref)-1])
+		return groups[n]
+	})
+	return strings.Trim(strings.TrimSpace(name), "/")
+}
+
+// validateAutoTagRule vérifie qu'une règle est utilisable avant de l'enregistrer
+func validateAutoTagRule(db *gorm.DB, rule models.AutoTagRule) error {
+	if rule.FolderPath != "" {
+		var count int64
+		if err := db.Model(&models.WatchedFolder{}).Where("path = ?", rule.FolderPath).Count(&count).Error; err != nil {
+			return fmt.Errorf("cannot fetch watched folder: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("watched folder not found: %s", rule.FolderPath)
+		}
+	}
+
+	_, err := compileAutoTagRule(db, rule)
+	return err
+}
+
+// loadAutoTagRules charge et compile les règles actives
+func loadAutoTagRules(db *gorm.DB) ([]*compiledAutoTagRule, error) {
+	var rules []models.AutoTagRule
+	if err := db.Where("enabled = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch auto tag rules: %w", err)
+	}
+
+	compiled := make([]*compiledAutoTagRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compileAutoTagRule(db, rule)
+		if err != nil {
+			// Une règle devenue invalide (type supprimé...) ne bloque pas les autres
+			fmt.Printf("Warning: skipping auto tag rule %d: %v\n", rule.ID, err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// relativeToRoot retourne le chemin relatif (séparateurs "/") d'une photo sous un dossier racine
+func relativeToRoot(root string, picturePath string) (string, bool) {
+	rel, err := filepath.Rel(root, picturePath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// matchAutoTagRules évalue les règles sur des photos
+// Le chemin est relatif au dossier de la règle, ou au dossier surveillé le plus proche pour les règles globales
+// (à défaut, relatif à fallbackRoot: dossier indexé hors des dossiers surveillés)
+func matchAutoTagRules(db *gorm.DB, rules []*compiledAutoTagRule, picturePaths []string, fallbackRoot string) ([]AutoTagMatch, error) {
+	if len(rules) == 0 || len(picturePaths) == 0 {
+		return nil, nil
+	}
+
+	var roots []string
+	if err := db.Model(&models.WatchedFolder{}).Pluck("path", &roots).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch watched folders: %w", err)
+	}
+	// Le dossier surveillé le plus profond l'emporte (dossiers imbriqués)
+	sort.Slice(roots, func(i, j int) bool { return len(roots[i]) > len(roots[j]) })
+
+	type resolvedTag struct {
+		name  string
+		found bool
+	}
+	resolved := make(map[string]resolvedTag)
+	planned := make(map[string]string) // Nouveaux tags du lot, par nom normalisé
+
+	var matches []AutoTagMatch
+	for _, path := range picturePaths {
+		globalRel, hasGlobal := "", false
+		for _, root := range roots {
+			if globalRel, hasGlobal = relativeToRoot(root, path); hasGlobal {
+				break
+			}
+		}
+		if !hasGlobal && fallbackRoot != "" {
+			globalRel, hasGlobal = relativeToRoot(fallbackRoot, path)
+		}
+
+		seen := make(map[string]bool)
+		for _, rule := range rules {
+			rel, ok := globalRel, hasGlobal
+			if rule.rule.FolderPath != "" {
+				rel, ok = relativeToRoot(rule.rule.FolderPath, path)
+			}
+			if !ok {
+				continue
+			}
+
+			name := rule.tagName(rel)
+			if name == "" {
+				continue
+			}
+
+			// Les alias et variantes de casse désignent le tag existant
+			tag, cached := resolved[name]
+			if !cached {
+				canonical, found, err := resolveTagName(db, name)
+				if err != nil {
+					return nil, err
+				}
+				// Deux résultats du modèle qui ne diffèrent que par la casse ou les accents désignent le même nouveau tag
+				if !found {
+					key := normalizeTagName(canonical)
+					if first, ok := planned[key]; ok {
+						canonical = first
+					} else {
+						planned[key] = canonical
+					}
+				}
+				tag = resolvedTag{canonical, found}
+				resolved[name] = tag
+			}
+			if seen[tag.name] {
+				continue
+			}
+			seen[tag.name] = true
+
+			matches = append(matches, AutoTagMatch{
+				RuleID:       rule.rule.ID,
+				PicturePath:  path,
+				RelativePath: rel,
+				TagName:      tag.name,
+				TagType:      rule.tagType,
+				NewTag:       !tag.found,
+			})
+		}
+	}
+
+	// Marquer les associations déjà présentes
+	existing := make(map[[2]string]bool)
+	for start := 0; start < len(picturePaths); start += bulkTagBatchSize {
+		end := min(start+bulkTagBatchSize, len(picturePaths))
+		var associations []models.PictureTag
+		if err := db.Where("picture_path IN ?", picturePaths[start:end]).Find(&associations).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch picture tags: %w", err)
+		}
+		for _, a := range associations {
+			existing[[2]string{a.PicturePath, a.TagName}] = true
+		}
+	}
+	for i := range matches {
+		matches[i].AlreadyTagged = existing[[2]string{matches[i].PicturePath, matches[i].TagName}]
+	}
+
+	return matches, nil
+}
+
+// applyAutoTagMatches crée les tags manquants et pose les associations absentes
+// Retourne les correspondances effectivement appliquées
+func applyAutoTagMatches(tx *gorm.DB, matches []AutoTagMatch) ([]AutoTagMatch, error) {
+	var applied []AutoTagMatch
+	var toAdd []models.PictureTag
+	created := make(map[string]string) // Nom prévu -> nom du tag en base
+	touched := make(map[string]bool)
+	var paths []string
+
+	for _, m := range matches {
+		if m.AlreadyTagged {
+			continue
+		}
+
+		if m.NewTag {
+			name, done := created[m.TagName]
+			if !done {
+				// Une variante de casse ou d'accent a pu être créée depuis le calcul des correspondances
+				canonical, found, err := resolveTagName(tx, m.TagName)
+				if err != nil {
+					return nil, err
+				}
+				if !found {
+					typeDef, err := findTagType(tx, m.TagType)
+					if err != nil {
+						return nil, err
+					}
+					tag := models.Tag{Name: canonical, NameKey: normalizeTagName(canonical), Type: m.TagType, Color: typeDef.Color}
+					if err := tx.Create(&tag).Error; err != nil {
+						return nil, fmt.Errorf("cannot create tag: %w", err)
+					}
+				}
+				name = canonical
+				created[m.TagName] = name
+			}
+			m.TagName = name
+		}
+
+		toAdd = append(toAdd, models.PictureTag{PicturePath: m.PicturePath, TagName: m.TagName})
+		applied = append(applied, m)
+		if !touched[m.PicturePath] {
+			touched[m.PicturePath] = true
+			paths = append(paths, m.PicturePath)
+		}
+	}
+
+	if len(toAdd) == 0 {
+		return applied, nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&toAdd, bulkTagBatchSize).Error; err != nil {
+		return nil, fmt.Errorf("cannot add tags to pictures: %w", err)
+	}
+	if err := refreshSearchIndex(tx, paths...); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// applyAutoTagRules applique les règles actives aux photos nouvellement indexées
+func applyAutoTagRules(picturePaths []string, fallbackRoot string) (int, error) {
+	rules, err := loadAutoTagRules(database.DB)
+	if err != nil || len(rules) == 0 {
+		return 0, err
+	}
+
+	matches, err := matchAutoTagRules(database.DB, rules, picturePaths, fallbackRoot)
+	if err != nil {
+		return 0, err
+	}
+
+	var applied []AutoTagMatch
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		applied, err = applyAutoTagMatches(tx, matches)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Ces tags ne passent pas par l'historique: suggestions et sidecars sont mis à jour ici, après validation
+	seen := make(map[string]bool)
+	var paths []string
+	for _, m := range applied {
+		if !seen[m.PicturePath] {
+			seen[m.PicturePath] = true
+			paths = append(paths, m.PicturePath)
+		}
+	}
+	suggestionIndex.invalidate(paths...)
+	syncXMPSidecarPaths(paths)
+
+	return len(applied), nil
+}
+
+// CreateAutoTagRule crée une règle de tag automatique
+// folderPath limite la règle à un dossier surveillé (vide = tous les dossiers surveillés)
+func (as *AutoTagService) CreateAutoTagRule(folderPath string, pattern string, patternType models.AutoTagPatternType, tagTemplate string) (*models.AutoTagRule, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	rule := models.AutoTagRule{
+		FolderPath:  folderPath,
+		Pattern:     pattern,
+		PatternType: patternType,
+		TagTemplate: strings.TrimSpace(tagTemplate),
+		Enabled:     true,
+	}
+	if err := validateAutoTagRule(database.DB, rule); err != nil {
+		return nil, err
+	}
+
+	if err := database.DB.Create(&rule).Error; err != nil {
+		return nil, fmt.Errorf("cannot create auto tag rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// GetAutoTagRules récupère toutes les règles de tag automatique
+func (as *AutoTagService) GetAutoTagRules() ([]models.AutoTagRule, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var rules []models.AutoTagRule
+	if err := database.DB.Order("id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch auto tag rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// UpdateAutoTagRule met à jour une règle de tag automatique
+func (as *AutoTagService) UpdateAutoTagRule(id uint, folderPath string, pattern string, patternType models.AutoTagPatternType, tagTemplate string, enabled bool) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var rule models.AutoTagRule
+	if err := database.DB.First(&rule, id).Error; err != nil {
+		return fmt.Errorf("auto tag rule not found: %w", err)
+	}
+
+	rule.FolderPath = folderPath
+	rule.Pattern = pattern
+	rule.PatternType = patternType
+	rule.TagTemplate = strings.TrimSpace(tagTemplate)
+	rule.Enabled = enabled
+	if err := validateAutoTagRule(database.DB, rule); err != nil {
+		return err
+	}
+
+	if err := database.DB.Save(&rule).Error; err != nil {
+		return fmt.Errorf("cannot update auto tag rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAutoTagRule supprime une règle (les tags déjà posés sont conservés)
+func (as *AutoTagService) DeleteAutoTagRule(id uint) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	result := database.DB.Delete(&models.AutoTagRule{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("cannot delete auto tag rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("auto tag rule not found: %d", id)
+	}
+
+	return nil
+}
+
+// libraryPicturePaths retourne les chemins de toutes les photos indexées
+func libraryPicturePaths(db *gorm.DB) ([]string, error) {
+	var paths []string
+	if err := db.Model(&models.Picture{}).Order("path").Pluck("path", &paths).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+	}
+	return paths, nil
+}
+
+// PreviewAutoTagRule simule une règle non enregistrée sur la bibliothèque (aucune modification)
+func (as *AutoTagService) PreviewAutoTagRule(folderPath string, pattern string, patternType models.AutoTagPatternType, tagTemplate string) ([]AutoTagMatch, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	rule := models.AutoTagRule{FolderPath: folderPath, Pattern: pattern, PatternType: patternType, TagTemplate: tagTemplate, Enabled: true}
+	if err := validateAutoTagRule(database.DB, rule); err != nil {
+		return nil, err
+	}
+	compiled, err := compileAutoTagRule(database.DB, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := libraryPicturePaths(database.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return matchAutoTagRules(database.DB, []*compiledAutoTagRule{compiled}, paths, "")
+}
+
+// PreviewAutoTagRules simule les règles actives sur la bibliothèque (aucune modification)
+func (as *AutoTagService) PreviewAutoTagRules() ([]AutoTagMatch, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	rules, err := loadAutoTagRules(database.DB)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := libraryPicturePaths(database.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return matchAutoTagRules(database.DB, rules, paths, "")
+}
+
+// ApplyAutoTagRules ré-applique les règles actives à toute la bibliothèque (opération annulable)
+// Retourne les tags effectivement posés
+func (as *AutoTagService) ApplyAutoTagRules() ([]AutoTagMatch, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	matches, err := as.PreviewAutoTagRules()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths, tags []string
+	seenPaths := make(map[string]bool)
+	seenTags := make(map[string]bool)
+	for _, m := range matches {
+		if m.AlreadyTagged {
+			continue
+		}
+		if !seenPaths[m.PicturePath] {
+			seenPaths[m.PicturePath] = true
+			paths = append(paths, m.PicturePath)
+		}
+		if m.NewTag && !seenTags[m.TagName] {
+			seenTags[m.TagName] = true
+			tags = append(tags, m.TagName)
+		}
+	}
+	if len(paths) == 0 {
+		return []AutoTagMatch{}, nil
+	}
+
+	var applied []AutoTagMatch
+	scopes := append(historyScopesIn("picture_tags", "picture_path", paths), historyScopesIn("tags", "name", tags)...)
+	err = recordOperation(fmt.Sprintf("apply auto tag rules on %d pictures", len(paths)), scopes, func(tx *gorm.DB) error {
+		applied, err = applyAutoTagMatches(tx, matches)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"easygallery/backend/models"
+)
+
+func TestAutoTagRulesMergeCaseAndAccentVariants(t *testing.T) {
+	dataDir := setupTestDB(t)
+	folder := filepath.Join(t.TempDir(), "library")
+	writeTestPicture(t, filepath.Join(folder, "Été", "a.jpg"), 1)
+	writeTestPicture(t, filepath.Join(folder, "ete", "b.jpg"), 2)
+	writeTestPicture(t, filepath.Join(folder, "ETE", "c.jpg"), 3)
+
+	indexer := NewIndexer(dataDir)
+	if err := indexer.AddWatchedFolder(folder, "", false); err != nil {
+		t.Fatalf("cannot add watched folder: %v", err)
+	}
+	if _, err := indexer.IndexWatchedFolder(folder, nil); err != nil {
+		t.Fatalf("cannot index folder: %v", err)
+	}
+
+	rules := NewAutoTagService()
+	if _, err := rules.CreateAutoTagRule("", "*/*", models.AutoTagPatternGlob, "event:{1}"); err != nil {
+		t.Fatalf("cannot create rule: %v", err)
+	}
+	applied, err := rules.ApplyAutoTagRules()
+	if err != nil {
+		t.Fatalf("cannot apply rules: %v", err)
+	}
+	if len(applied) != 3 {
+		t.Errorf("got %d applied matches, want 3", len(applied))
+	}
+
+	if n := countRows(t, "tags", "type = ?", models.TagTypeEvent); n != 1 {
+		t.Errorf("got %d event tags, want 1", n)
+	}
+	if n := countRows(t, "picture_tags", "1 = 1"); n != 3 {
+		t.Errorf("got %d tagged pictures, want 3", n)
+	}
+}
+
+func TestAutoTagsAddedWhileIndexingReachSidecarsAndSuggestions(t *testing.T) {
+	dataDir := setupTestDB(t)
+	folder := filepath.Join(t.TempDir(), "library")
+	wedding := writeTestPicture(t, filepath.Join(folder, "Mariage", "a.jpg"), 1)
+	other := writeTestPicture(t, filepath.Join(folder, "Mariage", "b.jpg"), 2)
+
+	indexer := NewIndexer(dataDir)
+	if err := indexer.AddWatchedFolder(folder, "", false); err != nil {
+		t.Fatalf("cannot add watched folder: %v", err)
+	}
+	if err := NewMetadataService().SetSidecarWriteBack(folder, true); err != nil {
+		t.Fatalf("cannot enable sidecar write-back: %v", err)
+	}
+	if _, err := indexer.IndexWatchedFolder(folder, nil); err != nil {
+		t.Fatalf("cannot index folder: %v", err)
+	}
+	if _, err := NewAutoTagService().CreateAutoTagRule("", "Mariage/a.jpg", models.AutoTagPatternGlob, "event:Mariage"); err != nil {
+		t.Fatalf("cannot create rule: %v", err)
+	}
+
+	// Suggestions calculées avant que l'indexation n'applique les règles
+	tags := NewTagService()
+	if _, err := tags.SuggestTags(other); err != nil {
+		t.Fatalf("cannot suggest tags: %v", err)
+	}
+	if n, err := applyAutoTagRules([]string{wedding}, folder); err != nil || n != 1 {
+		t.Fatalf("applied %d auto tags (err=%v), want 1", n, err)
+	}
+
+	sidecar, err := os.ReadFile(wedding + ".xmp")
+	if err != nil {
+		t.Fatalf("sidecar not written: %v", err)
+	}
+	if !strings.Contains(string(sidecar), "Mariage") {
+		t.Errorf("sidecar does not hold the auto tag:\n%s", sidecar)
+	}
+
+	suggestions, err := tags.SuggestTags(other)
+	if err != nil {
+		t.Fatalf("cannot suggest tags: %v", err)
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.TagName == "Mariage" {
+			return
+		}
+	}
+	t.Errorf("suggestions computed before the auto tags are still cached: %+v", suggestions)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// CaptionService gère les textes libres des photos: titre, légende et notes privées
+type CaptionService struct{}
+
+// NewCaptionService crée une nouvelle instance de CaptionService
+func NewCaptionService() *CaptionService {
+	return &CaptionService{}
+}
+
+// PictureCaption regroupe les textes libres d'une photo
+type PictureCaption struct {
+	Title   string `json:"title"`   // Titre court
+	Caption string `json:"caption"` // Légende (ex: "Alice à gauche, Bob au centre")
+	Notes   string `json:"notes"`   // Notes privées, jamais écrites dans les sidecars
+}
+
+// GetPictureCaption retourne le titre, la légende et les notes d'une photo
+func (cs *CaptionService) GetPictureCaption(picturePath string) (*PictureCaption, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var picture models.Picture
+	if err := database.DB.Where("path = ?", picturePath).First(&picture).Error; err != nil {
+		return nil, fmt.Errorf("picture not found: %s", picturePath)
+	}
+
+	return &PictureCaption{Title: picture.Title, Caption: picture.Caption, Notes: picture.Notes}, nil
+}
+
+// SetPictureCaption remplace le titre, la légende et les notes d'une photo (vide = effacer)
+// Les textes sont indexés pour la recherche plein texte
+func (cs *CaptionService) SetPictureCaption(picturePath string, caption PictureCaption) error {
+	if err := checkPictureExists(picturePath); err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{
+		"title":   strings.TrimSpace(caption.Title),
+		"caption": strings.TrimSpace(caption.Caption),
+		"notes":   strings.TrimSpace(caption.Notes),
+	}
+
+	description := fmt.Sprintf("edit caption of '%s'", filepath.Base(picturePath))
+	scopes := []historyScope{{"pictures", "path = ?", []interface{}{picturePath}}}
+	return recordOperation(description, scopes, func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Picture{}).Where("path = ?", picturePath).Updates(values).Error; err != nil {
+			return fmt.Errorf("cannot update caption: %w", err)
+		}
+		return refreshSearchIndex(tx, picturePath)
+	})
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+)
+
+// defaultImportLayout range les photos importées par année puis par jour (format de date Go)
+const defaultImportLayout = "2006/01-02"
+
+// fileContentHash calcule l'empreinte SHA-256 du contenu d'un fichier
+func fileContentHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ImportOptions règle l'import d'une carte mémoire ou d'un appareil
+type ImportOptions struct {
+	SourcePath string   `json:"sourcePath"` // Dossier source (ex: DCIM d'une carte montée)
+	FolderPath string   `json:"folderPath"` // Dossier surveillé de destination
+	Layout     string   `json:"layout"`     // Sous-dossiers selon la date de prise de vue, format Go ("2006/01-02" par défaut)
+	Tags       []string `json:"tags"`       // Tags posés sur toutes les photos importées
+}
+
+// ImportProgress décrit l'avancement d'un import
+type ImportProgress struct {
+	Phase    string `json:"phase"`    // "scanning", "copying", "indexing" ou "tagging"
+	Current  int    `json:"current"`  // Fichier en cours (à partir de 1)
+	Total    int    `json:"total"`    // Nombre de fichiers de la phase
+	Filename string `json:"filename"` // Nom du fichier en cours
+}
+
+// ImportResult résume un import
+type ImportResult struct {
+	Imported   []string `json:"imported"`   // Chemins des photos copiées et indexées
+	Duplicates int      `json:"duplicates"` // Fichiers déjà présents dans la bibliothèque
+	Errors     []string `json:"errors"`     // Fichiers en échec
+}
+
+// importCandidate est un fichier de la source à copier
+type importCandidate struct {
+	source string
+	date   time.Time // Date de prise de vue, qui détermine le dossier de destination
+}
+
+// ImportService importe les photos d'une carte mémoire dans un dossier surveillé
+type ImportService struct {
+	indexer *Indexer
+}
+
+// NewImportService crée une nouvelle instance de ImportService
+func NewImportService(dataDir string) *ImportService {
+	return &ImportService{
+		indexer: NewIndexer(dataDir),
+	}
+}
+
+// ImportFromCard copie les photos d'un dossier source absentes de la bibliothèque (même contenu)
+// dans un dossier surveillé, rangées par date de prise de vue, puis les indexe et leur pose les tags demandés
+// Les sidecars XMP sont copiés avec leur photo; la source n'est jamais modifiée
+func (is *ImportService) ImportFromCard(options ImportOptions, onProgress func(progress ImportProgress)) (*ImportResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	if options.Layout == "" {
+		options.Layout = defaultImportLayout
+	}
+	if _, err := importFolder(options.Layout, time.Now()); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(options.SourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("source folder not found: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("source is not a directory: %s", options.SourcePath)
+	}
+
+	var folder models.WatchedFolder
+	if err := database.DB.Where("path = ?", options.FolderPath).First(&folder).Error; err != nil {
+		return nil, fmt.Errorf("watched folder not found: %s", options.FolderPath)
+	}
+
+	// Les tags sont vérifiés avant de copier quoi que ce soit
+	var tagNames []string
+	for _, name := range options.Tags {
+		canonical, found, err := resolveTagName(database.DB, name)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("tag not found: %s", name)
+		}
+		tagNames = append(tagNames, canonical)
+	}
+
+	notify := func(phase string, current, total int, path string) {
+		if onProgress != nil {
+			progress := ImportProgress{Phase: phase, Current: current, Total: total}
+			if path != "" {
+				progress.Filename = filepath.Base(path)
+			}
+			onProgress(progress)
+		}
+	}
+
+	var sources []string
+	err = filepath.Walk(options.SourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && isSupportedImage(info.Name()) {
+			sources = append(sources, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning source folder: %w", err)
+	}
+
+	result := &ImportResult{Imported: []string{}, Errors: []string{}}
+
+	// Première phase: écarter les fichiers déjà présents dans la bibliothèque (ou en double sur la carte)
+	library, err := newLibraryHashes()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []importCandidate
+	for i, source := range sources {
+		notify("scanning", i+1, len(sources), source)
+
+		candidate, duplicate, err := library.check(source)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", source, err))
+			continue
+		}
+		if duplicate {
+			result.Duplicates++
+			continue
+		}
+		candidates = append(candidates, *candidate)
+	}
+
+	// Deuxième phase: copier les nouveaux fichiers, rangés par date
+	var copied []string
+	for i, candidate := range candidates {
+		notify("copying", i+1, len(candidates), candidate.source)
+
+		target, err := copyImportedFile(candidate, options.FolderPath, options.Layout)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", candidate.source, err))
+			continue
+		}
+		copied = append(copied, target)
+	}
+
+	// Troisième phase: indexer les copies comme le ferait une indexation du dossier
+	policies, err := watchedFoldersByDepth(database.DB)
+	if err != nil {
+		return result, err
+	}
+	var added []string
+	for i, path := range copied {
+		notify("indexing", i+1, len(copied), path)
+
+		// Le fichier est nouveau: une ancienne exclusion de ce chemin ne le concerne pas
+		if err := database.DB.Delete(&models.ExcludedPicture{}, "path = ?", path).Error; err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+
+		isNew, err := is.indexer.indexImage(path, metadataPolicyFor(policies, path))
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		if isNew {
+			added = append(added, path)
+		}
+		result.Imported = append(result.Imported, path)
+	}
+	if _, err := applyAutoTagRules(added, options.FolderPath); err != nil {
+		fmt.Printf("Warning: failed to apply auto tag rules: %v\n", err)
+	}
+
+	stats := map[string]interface{}{"last_indexed_at": time.Now(), "picture_count": folder.PictureCount + len(added)}
+	if err := database.DB.Model(&folder).Updates(stats).Error; err != nil {
+		fmt.Printf("Warning: cannot update watched folder %s: %v\n", folder.Path, err)
+	}
+
+	// Dernière phase: tags de l'import, en une opération annulable
+	if len(tagNames) > 0 && len(result.Imported) > 0 {
+		notify("tagging", 1, 1, "")
+		if _, err := bulkTags("import tags", result.Imported, tagNames, false, true); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// libraryHashes détecte les fichiers déjà présents dans la bibliothèque par leur contenu
+// Les photos indexées sans empreinte ne sont lues que si leur taille correspond à un fichier importé
+type libraryHashes struct {
+	known    map[string]bool    // Empreintes connues (bibliothèque et fichiers déjà retenus)
+	unhashed map[int64][]string // Photos sans empreinte, par taille
+}
+
+// newLibraryHashes charge les empreintes de la bibliothèque
+func newLibraryHashes() (*libraryHashes, error) {
+	var pictures []models.Picture
+	if err := database.DB.Select("path", "size", "content_hash").Find(&pictures).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+	}
+
+	library := &libraryHashes{known: make(map[string]bool), unhashed: make(map[int64][]string)}
+	for _, picture := range pictures {
+		if picture.ContentHash != "" {
+			library.known[picture.ContentHash] = true
+		} else {
+			library.unhashed[picture.Size] = append(library.unhashed[picture.Size], picture.Path)
+		}
+	}
+	return library, nil
+}
+
+// check calcule l'empreinte d'un fichier source et indique s'il est déjà présent
+// Un fichier nouveau est retenu: ses doublons suivants sur la carte sont écartés
+func (l *libraryHashes) check(source string) (*importCandidate, bool, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Compléter les empreintes des photos de même taille, indexées avant leur calcul
+	for _, path := range l.unhashed[info.Size()] {
+		hash, err := fileContentHash(path)
+		if err != nil {
+			continue
+		}
+		if err := database.DB.Model(&models.Picture{}).Where("path = ?", path).Update("content_hash", hash).Error; err != nil {
+			return nil, false, fmt.Errorf("cannot update picture: %w", err)
+		}
+		l.known[hash] = true
+	}
+	delete(l.unhashed, info.Size())
+
+	hash, err := fileContentHash(source)
+	if err != nil {
+		return nil, false, err
+	}
+	if l.known[hash] {
+		return nil, true, nil
+	}
+	l.known[hash] = true
+
+	// Date de prise de vue EXIF, à défaut date du fichier
+	date := info.ModTime()
+	if exif, err := readExif(source); err == nil && !exif.DateTaken.IsZero() {
+		date = exif.DateTaken
+	}
+	return &importCandidate{source: source, date: date}, false, nil
+}
+
+// importFolder retourne le sous-dossier relatif d'une photo prise à la date donnée
+func importFolder(layout string, date time.Time) (string, error) {
+	var segments []string
+	for _, segment := range strings.Split(filepath.ToSlash(date.Format(layout)), "/") {
+		segment = strings.TrimSpace(sanitizeFileName(segment))
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid import layout: %s", layout)
+		}
+		segments = append(segments, segment)
+	}
+	return filepath.Join(segments...), nil
+}
+
+// copyImportedFile copie un fichier (et son sidecar XMP) dans le dossier de sa date
+// Un nom déjà pris par un autre fichier reçoit un suffixe (photo_2.jpg)
+func copyImportedFile(candidate importCandidate, root string, layout string) (string, error) {
+	folder, err := importFolder(layout, candidate.date)
+	if err != nil {
+		return "", err
+	}
+	folder = filepath.Join(root, folder)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", fmt.Errorf("cannot create folder: %w", err)
+	}
+
+	sidecar, _ := findXMPSidecar(candidate.source)
+	name := filepath.Join(folder, filepath.Base(candidate.source))
+	target := name
+	for n := 2; ; n++ {
+		_, err := os.Lstat(target)
+		free := os.IsNotExist(err)
+		if free && sidecar != "" {
+			_, err := os.Lstat(sidecarTargetPath(sidecar, candidate.source, target))
+			free = os.IsNotExist(err)
+		}
+		if free {
+			break
+		}
+		target = collisionTarget(name, n)
+	}
+
+	if err := copyFile(candidate.source, target); err != nil {
+		return "", fmt.Errorf("cannot copy file: %w", err)
+	}
+	if sidecar != "" {
+		if err := copyFile(sidecar, sidecarTargetPath(sidecar, candidate.source, target)); err != nil {
+			fmt.Printf("Warning: cannot copy sidecar %s: %v\n", sidecar, err)
+		}
+	}
+	return target, nil
+}
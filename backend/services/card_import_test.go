@@ -0,0 +1,93 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+)
+
+// withExifDate insère dans un JPEG un segment EXIF minimal portant une date de prise de vue ("2006:01:02 15:04:05")
+func withExifDate(data []byte, date string) []byte {
+	le := binary.LittleEndian
+	value := date + "\x00"
+
+	// IFD0 avec un pointeur vers l'IFD EXIF, qui contient DateTimeOriginal
+	var tiff bytes.Buffer
+	tiff.WriteString("II*\x00")
+	binary.Write(&tiff, le, uint32(8))
+	binary.Write(&tiff, le, uint16(1))
+	binary.Write(&tiff, le, []uint16{0x8769, 4})
+	binary.Write(&tiff, le, []uint32{1, 8 + 2 + 12 + 4})
+	binary.Write(&tiff, le, uint32(0))
+	binary.Write(&tiff, le, uint16(1))
+	binary.Write(&tiff, le, []uint16{0x9003, 2})
+	binary.Write(&tiff, le, []uint32{uint32(len(value)), 26 + 2 + 12 + 4})
+	binary.Write(&tiff, le, uint32(0))
+	tiff.WriteString(value)
+
+	var out bytes.Buffer
+	out.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(2+6+tiff.Len()))
+	out.WriteString("Exif\x00\x00")
+	out.Write(tiff.Bytes())
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+func TestImportFromCardSkipsDuplicateContent(t *testing.T) {
+	indexer, folder, paths := setupTestLibrary(t, 2)
+	card := filepath.Join(t.TempDir(), "DCIM")
+
+	// Une photo indexée avant le calcul des empreintes
+	if err := database.DB.Model(&models.Picture{}).Where("path = ?", paths[1]).Update("content_hash", "").Error; err != nil {
+		t.Fatalf("cannot clear content hash: %v", err)
+	}
+
+	// Déjà dans la bibliothèque sous un autre nom
+	writeTestPicture(t, filepath.Join(card, "100CANON", "IMG_0001.jpg"), 1)
+	writeTestPicture(t, filepath.Join(card, "100CANON", "IMG_0002.jpg"), 2)
+	// Nouvelle photo, présente deux fois sur la carte
+	fresh := withExifDate(testJPEG(t, 7), "2024:05:01 09:30:00")
+	for _, name := range []string{"100CANON/IMG_0003.jpg", "101CANON/IMG_0003_copy.jpg"} {
+		path := filepath.Join(card, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("cannot create folder: %v", err)
+		}
+		if err := os.WriteFile(path, fresh, 0644); err != nil {
+			t.Fatalf("cannot write picture: %v", err)
+		}
+	}
+
+	result, err := NewImportService(indexer.dataDir).ImportFromCard(ImportOptions{SourcePath: card, FolderPath: folder}, nil)
+	if err != nil {
+		t.Fatalf("cannot import: %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("import errors: %v", result.Errors)
+	}
+	if result.Duplicates != 3 {
+		t.Errorf("got %d duplicates, want 3", result.Duplicates)
+	}
+
+	want := filepath.Join(folder, "2024", "05-01", "IMG_0003.jpg")
+	if len(result.Imported) != 1 || result.Imported[0] != want {
+		t.Fatalf("imported %v, want [%s]", result.Imported, want)
+	}
+	if n := countRows(t, "pictures", "path = ? AND content_hash <> ''", want); n != 1 {
+		t.Errorf("imported picture not indexed with its content hash")
+	}
+
+	// Un second import de la même carte ne copie plus rien
+	again, err := NewImportService(indexer.dataDir).ImportFromCard(ImportOptions{SourcePath: card, FolderPath: folder}, nil)
+	if err != nil {
+		t.Fatalf("cannot import again: %v", err)
+	}
+	if len(again.Imported) != 0 || again.Duplicates != 4 {
+		t.Errorf("second import: imported %v, %d duplicates, want none and 4", again.Imported, again.Duplicates)
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// CullingService gère le tri des photos: notes, étiquettes de couleur et marques retenue / rejetée
+type CullingService struct{}
+
+// NewCullingService crée une nouvelle instance de CullingService
+func NewCullingService() *CullingService {
+	return &CullingService{}
+}
+
+// validColorLabel indique si une étiquette de couleur est connue (vide = aucune)
+func validColorLabel(label models.ColorLabel) bool {
+	if label == models.ColorLabelNone {
+		return true
+	}
+	for _, known := range models.ColorLabels {
+		if label == known {
+			return true
+		}
+	}
+	return false
+}
+
+// validPickFlag indique si une marque de tri est connue
+func validPickFlag(flag models.PickFlag) bool {
+	switch flag {
+	case models.PickFlagNone, models.PickFlagPick, models.PickFlagReject:
+		return true
+	}
+	return false
+}
+
+// cullingDescription décrit une opération de tri pour l'historique
+func cullingDescription(action string, paths []string) string {
+	if len(paths) == 1 {
+		return fmt.Sprintf("%s on '%s'", action, filepath.Base(paths[0]))
+	}
+	return fmt.Sprintf("%s on %d pictures", action, len(paths))
+}
+
+// updatePictures modifie une colonne de tri sur plusieurs photos en une opération annulable
+// Retourne le nombre de photos modifiées (les photos inconnues ou déjà à jour sont ignorées)
+func updatePictures(description string, paths []string, column string, value interface{}) (int, error) {
+	if err := checkDB(); err != nil {
+		return 0, err
+	}
+
+	seen := make(map[string]bool, len(paths))
+	unique := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	if len(unique) == 0 {
+		return 0, nil
+	}
+
+	updated := 0
+	err := recordOperation(description, historyScopesIn("pictures", "path", unique), func(tx *gorm.DB) error {
+		for start := 0; start < len(unique); start += bulkTagBatchSize {
+			batch := unique[start:min(start+bulkTagBatchSize, len(unique))]
+			result := tx.Model(&models.Picture{}).
+				Where("path IN ? AND "+column+" <> ?", batch, value).
+				Update(column, value)
+			if result.Error != nil {
+				return fmt.Errorf("cannot update pictures: %w", result.Error)
+			}
+			updated += int(result.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// checkPictureExists vérifie qu'une photo est indexée
+func checkPictureExists(picturePath string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.Picture{}).Where("path = ?", picturePath).Count(&count).Error; err != nil {
+		return fmt.Errorf("cannot fetch picture: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("picture not found: %s", picturePath)
+	}
+	return nil
+}
+
+// SetRating note une photo de 0 (aucune note) à 5 étoiles
+func (cs *CullingService) SetRating(picturePath string, rating int) error {
+	if err := checkPictureExists(picturePath); err != nil {
+		return err
+	}
+	_, err := cs.SetRatings([]string{picturePath}, rating)
+	return err
+}
+
+// SetRatings note plusieurs photos à la fois
+// Retourne le nombre de photos dont la note a changé
+func (cs *CullingService) SetRatings(picturePaths []string, rating int) (int, error) {
+	if rating < 0 || rating > 5 {
+		return 0, fmt.Errorf("rating must be between 0 and 5")
+	}
+
+	return updatePictures(cullingDescription(fmt.Sprintf("set rating %d", rating), picturePaths), picturePaths, "rating", rating)
+}
+
+// SetColorLabel pose une étiquette de couleur sur une photo (vide = retirer l'étiquette)
+func (cs *CullingService) SetColorLabel(picturePath string, label models.ColorLabel) error {
+	if err := checkPictureExists(picturePath); err != nil {
+		return err
+	}
+	_, err := cs.SetColorLabels([]string{picturePath}, label)
+	return err
+}
+
+// SetColorLabels pose une étiquette de couleur sur plusieurs photos à la fois
+// Retourne le nombre de photos dont l'étiquette a changé
+func (cs *CullingService) SetColorLabels(picturePaths []string, label models.ColorLabel) (int, error) {
+	label = models.ColorLabel(strings.ToLower(strings.TrimSpace(string(label))))
+	if !validColorLabel(label) {
+		return 0, fmt.Errorf("invalid color label: %s", label)
+	}
+
+	action := "clear color label"
+	if label != models.ColorLabelNone {
+		action = fmt.Sprintf("set color label %s", label)
+	}
+	return updatePictures(cullingDescription(action, picturePaths), picturePaths, "color_label", label)
+}
+
+// SetPickFlag marque une photo retenue, rejetée ou non marquée
+func (cs *CullingService) SetPickFlag(picturePath string, flag models.PickFlag) error {
+	if err := checkPictureExists(picturePath); err != nil {
+		return err
+	}
+	_, err := cs.SetPickFlags([]string{picturePath}, flag)
+	return err
+}
+
+// SetPickFlags marque plusieurs photos à la fois
+// Retourne le nombre de photos dont la marque a changé
+func (cs *CullingService) SetPickFlags(picturePaths []string, flag models.PickFlag) (int, error) {
+	if flag == "" {
+		flag = models.PickFlagNone
+	}
+	if !validPickFlag(flag) {
+		return 0, fmt.Errorf("invalid pick flag: %s", flag)
+	}
+
+	return updatePictures(cullingDescription(fmt.Sprintf("mark %s", flag), picturePaths), picturePaths, "flag", flag)
+}
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Valeurs par défaut du regroupement en événements
+const (
+	defaultEventGapHours      = 6.0  // Un écart de plus de 6 heures commence un nouvel événement
+	defaultEventMaxDistanceKm = 50.0 // Un déplacement de plus de 50 km commence un nouvel événement
+	defaultEventMinPictures   = 3    // Nombre minimal de photos pour proposer un événement
+)
+
+// EventClusterService propose des événements en regroupant les photos par date et position
+type EventClusterService struct{}
+
+// NewEventClusterService crée une nouvelle instance d'EventClusterService
+func NewEventClusterService() *EventClusterService {
+	return &EventClusterService{}
+}
+
+// EventClusterOptions paramètre le regroupement (valeurs nulles = valeurs par défaut)
+type EventClusterOptions struct {
+	GapHours      float64 `json:"gapHours"`      // Écart de temps maximal entre deux photos d'un même événement
+	MaxDistanceKm float64 `json:"maxDistanceKm"` // Distance maximale entre deux photos géolocalisées consécutives
+	MinPictures   int     `json:"minPictures"`   // Taille minimale d'un regroupement proposé
+	IncludeTagged bool    `json:"includeTagged"` // Inclure les photos portant déjà un tag d'événement
+}
+
+// EventCluster représente un événement candidat
+type EventCluster struct {
+	ID            string    `json:"id"`            // Empreinte des chemins des photos
+	SuggestedName string    `json:"suggestedName"` // Nom proposé à partir des dates et du lieu
+	StartAt       time.Time `json:"startAt"`
+	EndAt         time.Time `json:"endAt"`
+	PictureCount  int       `json:"pictureCount"`
+	PicturePaths  []string  `json:"picturePaths"` // Photos, par date de prise de vue
+	Location      string    `json:"location"`     // Tag de lieu le plus fréquent (vide si aucun)
+	Latitude      *float64  `json:"latitude"`     // Centre des positions GPS (nil si aucune)
+	Longitude     *float64  `json:"longitude"`
+}
+
+// withDefaults complète les options non renseignées
+func (o EventClusterOptions) withDefaults() EventClusterOptions {
+	if o.GapHours <= 0 {
+		o.GapHours = defaultEventGapHours
+	}
+	if o.MaxDistanceKm <= 0 {
+		o.MaxDistanceKm = defaultEventMaxDistanceKm
+	}
+	if o.MinPictures <= 0 {
+		o.MinPictures = defaultEventMinPictures
+	}
+	return o
+}
+
+// eventClusterID calcule l'identifiant stable d'un regroupement à partir de ses photos
+func eventClusterID(picturePaths []string) string {
+	sorted := append([]string(nil), picturePaths...)
+	sort.Strings(sorted)
+	sum := sha1.Sum([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// distanceKm calcule la distance entre deux positions GPS (formule de haversine)
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// eventTaggedPaths retourne les photos portant déjà un tag de type événement
+func eventTaggedPaths(db *gorm.DB) (map[string]bool, error) {
+	var paths []string
+	err := db.Model(&models.PictureTag{}).
+		Joins("JOIN tags ON tags.name = picture_tags.tag_name").
+		Where("tags.type = ?", models.TagTypeEvent).
+		Distinct().Pluck("picture_tags.picture_path", &paths).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch event tags: %w", err)
+	}
+
+	tagged := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		tagged[path] = true
+	}
+	return tagged, nil
+}
+
+// clusterPictures découpe une liste de photos triées par date en événements
+func clusterPictures(pictures []models.Picture, options EventClusterOptions) [][]models.Picture {
+	gap := time.Duration(options.GapHours * float64(time.Hour))
+
+	var clusters [][]models.Picture
+	var current []models.Picture
+	var lastLat, lastLon *float64
+
+	for _, picture := range pictures {
+		split := false
+		if len(current) > 0 {
+			previous := current[len(current)-1]
+			if picture.CreatedAt.Sub(previous.CreatedAt) > gap {
+				split = true
+			} else if picture.Latitude != nil && picture.Longitude != nil && lastLat != nil &&
+				distanceKm(*lastLat, *lastLon, *picture.Latitude, *picture.Longitude) > options.MaxDistanceKm {
+				split = true
+			}
+		}
+
+		if split {
+			clusters = append(clusters, current)
+			current = nil
+			lastLat, lastLon = nil, nil
+		}
+
+		current = append(current, picture)
+		if picture.Latitude != nil && picture.Longitude != nil {
+			lastLat, lastLon = picture.Latitude, picture.Longitude
+		}
+	}
+	if len(current) > 0 {
+		clusters = append(clusters, current)
+	}
+
+	return clusters
+}
+
+// locationTagsByPicture retourne les tags de lieu de chaque photo (clé: chemin de la photo)
+func locationTagsByPicture(db *gorm.DB, paths []string) (map[string][]string, error) {
+	locations := make(map[string][]string)
+	for start := 0; start < len(paths); start += searchIndexBatchSize {
+		end := min(start+searchIndexBatchSize, len(paths))
+		var associations []models.PictureTag
+		err := db.Joins("JOIN tags ON tags.name = picture_tags.tag_name").
+			Where("tags.type = ? AND picture_tags.picture_path IN ?", models.TagTypeLocation, paths[start:end]).
+			Find(&associations).Error
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch location tags: %w", err)
+		}
+		for _, a := range associations {
+			locations[a.PicturePath] = append(locations[a.PicturePath], a.TagName)
+		}
+	}
+	return locations, nil
+}
+
+// suggestEventName construit un nom à partir des dates et du lieu (ex: "2023-05-14 Paris", "2023-05-14 – 2023-05-16")
+func suggestEventName(start, end time.Time, location string) string {
+	name := start.Format("2006-01-02")
+	if end.Format("2006-01-02") != name {
+		name += " – " + end.Format("2006-01-02")
+	}
+	if location != "" {
+		name += " " + location
+	}
+	return name
+}
+
+// buildEventCluster calcule les informations présentées à l'utilisateur pour un regroupement
+func buildEventCluster(pictures []models.Picture, locations map[string][]string) EventCluster {
+	cluster := EventCluster{
+		StartAt:      pictures[0].CreatedAt,
+		EndAt:        pictures[len(pictures)-1].CreatedAt,
+		PictureCount: len(pictures),
+		PicturePaths: make([]string, 0, len(pictures)),
+	}
+
+	var sumLat, sumLon float64
+	located := 0
+	counts := make(map[string]int)
+	for _, picture := range pictures {
+		cluster.PicturePaths = append(cluster.PicturePaths, picture.Path)
+		if picture.Latitude != nil && picture.Longitude != nil {
+			sumLat += *picture.Latitude
+			sumLon += *picture.Longitude
+			located++
+		}
+		for _, location := range locations[picture.Path] {
+			counts[location]++
+		}
+	}
+
+	if located > 0 {
+		lat, lon := sumLat/float64(located), sumLon/float64(located)
+		cluster.Latitude, cluster.Longitude = &lat, &lon
+	}
+
+	for location, count := range counts {
+		if count > counts[cluster.Location] || (count == counts[cluster.Location] && location < cluster.Location) {
+			cluster.Location = location
+		}
+	}
+
+	cluster.ID = eventClusterID(cluster.PicturePaths)
+	cluster.SuggestedName = suggestEventName(cluster.StartAt, cluster.EndAt, cluster.Location)
+	return cluster
+}
+
+// ProposeEvents regroupe les photos en événements candidats selon les écarts de date et de position
+// Les photos déjà rattachées à un événement et les regroupements refusés sont ignorés
+func (es *EventClusterService) ProposeEvents(options EventClusterOptions) ([]EventCluster, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+	options = options.withDefaults()
+
+	var pictures []models.Picture
+	err := database.DB.Select("path", "created_at", "latitude", "longitude").
+		Order("created_at").Order("path").
+		Find(&pictures).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+	}
+
+	var tagged map[string]bool
+	if !options.IncludeTagged {
+		if tagged, err = eventTaggedPaths(database.DB); err != nil {
+			return nil, err
+		}
+	}
+
+	candidates := make([]models.Picture, 0, len(pictures))
+	for _, picture := range pictures {
+		if picture.CreatedAt.IsZero() || tagged[picture.Path] {
+			continue
+		}
+		candidates = append(candidates, picture)
+	}
+
+	var rejectedIDs []string
+	if err := database.DB.Model(&models.RejectedEventCluster{}).Pluck("cluster_id", &rejectedIDs).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch rejected events: %w", err)
+	}
+	rejected := make(map[string]bool, len(rejectedIDs))
+	for _, id := range rejectedIDs {
+		rejected[id] = true
+	}
+
+	var groups [][]models.Picture
+	var paths []string
+	for _, group := range clusterPictures(candidates, options) {
+		if len(group) < options.MinPictures {
+			continue
+		}
+		groups = append(groups, group)
+		for _, picture := range group {
+			paths = append(paths, picture.Path)
+		}
+	}
+
+	locations, err := locationTagsByPicture(database.DB, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]EventCluster, 0, len(groups))
+	for _, group := range groups {
+		cluster := buildEventCluster(group, locations)
+		if !rejected[cluster.ID] {
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	return clusters, nil
+}
+
+// AcceptEventCluster pose un tag d'événement sur les photos d'un regroupement (opération annulable)
+// name est le nom proposé ou le nom choisi par l'utilisateur; un tag existant (ou alias) est réutilisé
+func (es *EventClusterService) AcceptEventCluster(picturePaths []string, name string) ([]BulkTagResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("event name cannot be empty")
+	}
+	if len(picturePaths) == 0 {
+		return nil, fmt.Errorf("event has no pictures")
+	}
+
+	canonical, found, err := resolveTagName(database.DB, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []BulkTagResult
+	scopes := append(historyScopesIn("picture_tags", "picture_path", picturePaths),
+		historyScope{"tags", "name = ?", []interface{}{canonical}})
+	description := fmt.Sprintf("accept event '%s' on %d pictures", canonical, len(picturePaths))
+	err = recordOperation(description, scopes, func(tx *gorm.DB) error {
+		if !found {
+			typeDef, err := findTagType(tx, models.TagTypeEvent)
+			if err != nil {
+				return err
+			}
+			tag := models.Tag{Name: canonical, NameKey: normalizeTagName(canonical), Type: models.TagTypeEvent, Color: typeDef.Color}
+			if err := tx.Create(&tag).Error; err != nil {
+				return fmt.Errorf("cannot create tag: %w", err)
+			}
+		}
+
+		c, err := loadBulkTagContext(tx, picturePaths, []string{canonical})
+		if err != nil {
+			return err
+		}
+
+		var toAdd []models.PictureTag
+		for _, path := range c.paths {
+			status := BulkTagUnchanged
+			switch {
+			case !c.indexed[path]:
+				status = BulkTagPictureNotFound
+			case !c.has(path, canonical):
+				toAdd = append(toAdd, models.PictureTag{PicturePath: path, TagName: canonical})
+				status = BulkTagAdded
+			}
+			results = append(results, BulkTagResult{PicturePath: path, TagName: canonical, Status: status})
+		}
+
+		return applyBulkTags(tx, toAdd, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// RejectEventCluster refuse un regroupement: il ne sera plus proposé tant que ses photos ne changent pas
+func (es *EventClusterService) RejectEventCluster(picturePaths []string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+	if len(picturePaths) == 0 {
+		return fmt.Errorf("event has no pictures")
+	}
+
+	var pictures []models.Picture
+	for start := 0; start < len(picturePaths); start += searchIndexBatchSize {
+		end := min(start+searchIndexBatchSize, len(picturePaths))
+		var batch []models.Picture
+		if err := database.DB.Select("path", "created_at").Where("path IN ?", picturePaths[start:end]).Find(&batch).Error; err != nil {
+			return fmt.Errorf("cannot fetch pictures: %w", err)
+		}
+		pictures = append(pictures, batch...)
+	}
+
+	rejection := models.RejectedEventCluster{
+		ClusterID:    eventClusterID(picturePaths),
+		PictureCount: len(picturePaths),
+	}
+	for i, picture := range pictures {
+		if i == 0 || picture.CreatedAt.Before(rejection.StartAt) {
+			rejection.StartAt = picture.CreatedAt
+		}
+		if i == 0 || picture.CreatedAt.After(rejection.EndAt) {
+			rejection.EndAt = picture.CreatedAt
+		}
+	}
+
+	if err := database.DB.Save(&rejection).Error; err != nil {
+		return fmt.Errorf("cannot reject event: %w", err)
+	}
+
+	return nil
+}
+
+// ClearRejectedEventClusters oublie les regroupements refusés (ils seront de nouveau proposés)
+func (es *EventClusterService) ClearRejectedEventClusters() error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	if err := database.DB.Where("1 = 1").Delete(&models.RejectedEventCluster{}).Error; err != nil {
+		return fmt.Errorf("cannot clear rejected events: %w", err)
+	}
+
+	return nil
+}
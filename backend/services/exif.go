@@ -0,0 +1,305 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Tags EXIF utilisés par l'indexer
+const (
+	exifTagImageDescription = 0x010E // Légende de l'image
+	exifTagMake             = 0x010F // Fabricant de l'appareil
+	exifTagModel            = 0x0110 // Modèle de l'appareil
+	exifTagExifIFD          = 0x8769 // Pointeur vers le sous-IFD EXIF
+	exifTagGPSIFD           = 0x8825 // Pointeur vers le sous-IFD GPS
+	exifTagDateTimeOriginal = 0x9003 // Date de prise de vue ("2006:01:02 15:04:05")
+	exifTagGPSLatitudeRef   = 0x0001 // "N" ou "S"
+	exifTagGPSLatitude      = 0x0002 // Degrés, minutes, secondes
+	exifTagGPSLongitudeRef  = 0x0003 // "E" ou "W"
+	exifTagGPSLongitude     = 0x0004 // Degrés, minutes, secondes
+)
+
+// Types de valeurs TIFF utilisés
+const (
+	tiffTypeASCII    = 2
+	tiffTypeShort    = 3
+	tiffTypeLong     = 4
+	tiffTypeRational = 5
+)
+
+// ExifData contient les métadonnées EXIF utiles à la galerie
+type ExifData struct {
+	DateTaken   time.Time // Date de prise de vue (zéro si absente)
+	Latitude    *float64  // Latitude GPS en degrés décimaux (nil si absente)
+	Longitude   *float64  // Longitude GPS en degrés décimaux (nil si absente)
+	Description string    // Légende (ImageDescription), hors textes par défaut des appareils
+	Make        string    // Fabricant de l'appareil (vide si absent)
+	Model       string    // Modèle de l'appareil (vide si absent)
+}
+
+// Camera retourne le nom de l'appareil: le modèle, précédé de la marque s'il ne la contient pas déjà
+// (ex: Make "NIKON CORPORATION" et Model "NIKON D750" donnent "NIKON D750")
+func (e *ExifData) Camera() string {
+	brand := strings.Fields(e.Make)
+	if len(brand) == 0 || strings.HasPrefix(strings.ToLower(e.Model), strings.ToLower(brand[0])) {
+		return e.Model
+	}
+	return strings.TrimSpace(brand[0] + " " + e.Model)
+}
+
+// exifPlaceholderDescriptions sont les légendes écrites par défaut par certains appareils
+var exifPlaceholderDescriptions = map[string]bool{
+	"OLYMPUS DIGITAL CAMERA": true,
+	"SONY DSC":               true,
+	"DIGITAL CAMERA":         true,
+	"DCIM":                   true,
+	"DEFAULT":                true,
+	"SAMSUNG":                true,
+}
+
+// exifEntry représente une entrée brute d'un IFD
+type exifEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // Données de la valeur (en place ou à l'offset indiqué)
+}
+
+// exifReader décode un bloc TIFF (contenu du segment APP1 "Exif")
+type exifReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// readExif lit les métadonnées EXIF d'un fichier JPEG
+// Retourne des données vides (sans erreur) si le fichier n'a pas d'EXIF
+func readExif(imagePath string) (*ExifData, error) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	segment, err := findExifSegment(bufio.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	if segment == nil {
+		return &ExifData{}, nil
+	}
+
+	return parseExif(segment)
+}
+
+// findExifSegment parcourt les marqueurs JPEG jusqu'au segment APP1 "Exif" (nil si absent)
+func findExifSegment(r *bufio.Reader) ([]byte, error) {
+	var exif []byte
+	err := walkJPEGSegments(r, func(marker byte, segment []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			exif = segment[6:]
+			return false
+		}
+		// Autre APP1 (XMP...): continuer
+		return true
+	})
+	return exif, err
+}
+
+// walkJPEGSegments parcourt les segments d'application (APPn) d'un JPEG, avant les données de l'image
+// visit reçoit le marqueur et le contenu de chaque segment et retourne false pour arrêter
+// Un fichier qui n'est pas un JPEG ou dont la structure est invalide n'est pas une erreur
+func walkJPEGSegments(r *bufio.Reader, visit func(marker byte, segment []byte) bool) error {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return nil // Pas un JPEG
+	}
+
+	for {
+		var marker [2]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil {
+			return nil
+		}
+		if marker[0] != 0xFF {
+			return nil
+		}
+		// Début des données de l'image: plus de métadonnées après
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return nil
+		}
+
+		var size uint16
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil || size < 2 {
+			return nil
+		}
+		length := int(size) - 2
+
+		if marker[1] < 0xE0 || marker[1] > 0xEF {
+			if _, err := r.Discard(length); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return fmt.Errorf("cannot read jpeg segment: %w", err)
+		}
+		if !visit(marker[1], segment) {
+			return nil
+		}
+	}
+}
+
+// parseExif décode le bloc TIFF et extrait la date de prise de vue, la position GPS et la légende
+func parseExif(data []byte) (*ExifData, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("invalid exif header")
+	}
+
+	x := &exifReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		x.order = binary.LittleEndian
+	case "MM":
+		x.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid exif byte order")
+	}
+
+	ifd0, err := x.readIFD(x.order.Uint32(data[4:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExifData{}
+
+	if entry := ifd0[exifTagImageDescription]; entry != nil && entry.typ == tiffTypeASCII {
+		description := iptcString(bytes.TrimRight(entry.value, "\x00"))
+		if !exifPlaceholderDescriptions[strings.ToUpper(description)] {
+			result.Description = description
+		}
+	}
+
+	result.Make, _ = x.ascii(ifd0[exifTagMake])
+	result.Model, _ = x.ascii(ifd0[exifTagModel])
+
+	if offset, ok := x.uint(ifd0[exifTagExifIFD]); ok {
+		if sub, err := x.readIFD(offset); err == nil {
+			if value, ok := x.ascii(sub[exifTagDateTimeOriginal]); ok {
+				if t, err := time.ParseInLocation("2006:01:02 15:04:05", value, time.Local); err == nil {
+					result.DateTaken = t
+				}
+			}
+		}
+	}
+
+	if offset, ok := x.uint(ifd0[exifTagGPSIFD]); ok {
+		if gps, err := x.readIFD(offset); err == nil {
+			lat, okLat := x.coordinate(gps[exifTagGPSLatitude], gps[exifTagGPSLatitudeRef], "S")
+			lon, okLon := x.coordinate(gps[exifTagGPSLongitude], gps[exifTagGPSLongitudeRef], "W")
+			if okLat && okLon && !(lat == 0 && lon == 0) {
+				result.Latitude = &lat
+				result.Longitude = &lon
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// readIFD lit les entrées d'un IFD à l'offset donné
+func (x *exifReader) readIFD(offset uint32) (map[uint16]*exifEntry, error) {
+	if int(offset)+2 > len(x.data) {
+		return nil, fmt.Errorf("invalid ifd offset")
+	}
+
+	count := int(x.order.Uint16(x.data[offset:]))
+	entries := make(map[uint16]*exifEntry, count)
+	for i := 0; i < count; i++ {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(x.data) {
+			break
+		}
+		raw := x.data[start : start+12]
+
+		entry := &exifEntry{typ: x.order.Uint16(raw[2:]), count: x.order.Uint32(raw[4:])}
+		size := int(entry.count) * tiffTypeSize(entry.typ)
+		if size <= 4 {
+			entry.value = raw[8 : 8+size]
+		} else {
+			valueOffset := int(x.order.Uint32(raw[8:]))
+			if valueOffset < 0 || valueOffset+size > len(x.data) {
+				continue
+			}
+			entry.value = x.data[valueOffset : valueOffset+size]
+		}
+		entries[x.order.Uint16(raw)] = entry
+	}
+
+	return entries, nil
+}
+
+// tiffTypeSize retourne la taille en octets d'une valeur du type donné
+func tiffTypeSize(typ uint16) int {
+	switch typ {
+	case tiffTypeShort:
+		return 2
+	case tiffTypeLong:
+		return 4
+	case tiffTypeRational:
+		return 8
+	default:
+		return 1
+	}
+}
+
+// uint retourne la valeur entière d'une entrée SHORT ou LONG
+func (x *exifReader) uint(e *exifEntry) (uint32, bool) {
+	if e == nil || e.count == 0 {
+		return 0, false
+	}
+	switch e.typ {
+	case tiffTypeShort:
+		return uint32(x.order.Uint16(e.value)), true
+	case tiffTypeLong:
+		return x.order.Uint32(e.value), true
+	}
+	return 0, false
+}
+
+// ascii retourne la valeur texte d'une entrée ASCII
+func (x *exifReader) ascii(e *exifEntry) (string, bool) {
+	if e == nil || e.typ != tiffTypeASCII {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00")), true
+}
+
+// coordinate convertit une coordonnée GPS (degrés, minutes, secondes) en degrés décimaux
+// negativeRef est la référence ("S" ou "W") qui rend la valeur négative
+func (x *exifReader) coordinate(e *exifEntry, ref *exifEntry, negativeRef string) (float64, bool) {
+	if e == nil || e.typ != tiffTypeRational || e.count < 3 {
+		return 0, false
+	}
+
+	var parts [3]float64
+	for i := range parts {
+		num := x.order.Uint32(e.value[i*8:])
+		den := x.order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+
+	value := parts[0] + parts[1]/60 + parts[2]/3600
+	if r, ok := x.ascii(ref); ok && strings.EqualFold(r, negativeRef) {
+		value = -value
+	}
+	return value, true
+}
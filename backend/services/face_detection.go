@@ -0,0 +1,147 @@
+package services
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+	"path/filepath"
+)
+
+// DetectedFace représente un visage trouvé par un détecteur
+// Le rectangle est normalisé entre 0 et 1, comme models.FaceRegion
+type DetectedFace struct {
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Width      float64 `json:"width"`
+	Height     float64 `json:"height"`
+	Confidence float64 `json:"confidence"` // Score propre au détecteur (plus grand = plus sûr)
+}
+
+// FaceDetector trouve les visages d'une image
+// Les implémentations doivent pouvoir tourner sur un CPU seul
+type FaceDetector interface {
+	Name() string
+	Detect(img image.Image) ([]DetectedFace, error)
+}
+
+// FaceEmbedder calcule la signature d'un visage: un vecteur de norme 1,
+// tel que le produit scalaire de deux signatures mesure la ressemblance des visages
+type FaceEmbedder interface {
+	Name() string
+	Embed(img image.Image, face DetectedFace) ([]float32, error)
+}
+
+// faceModelsDir retourne le dossier des fichiers de modèles (poids) dans le dossier de données
+func faceModelsDir(dataDir string) string {
+	return filepath.Join(dataDir, "models")
+}
+
+// loadDefaultFaceDetector charge le détecteur CPU fourni avec l'application
+// Ses poids sont lus dans <dataDir>/models/facefinder (cascade PICO)
+func loadDefaultFaceDetector(dataDir string) (FaceDetector, error) {
+	return loadPicoDetector(filepath.Join(faceModelsDir(dataDir), picoCascadeFile))
+}
+
+// defaultFaceEmbedder retourne le calcul de signature fourni avec l'application
+// Histogrammes LBP calculés, sans fichier de poids (voir face_lbp.go)
+func defaultFaceEmbedder() FaceEmbedder {
+	return newLBPEmbedder()
+}
+
+// grayImage est une image en niveaux de gris, ligne par ligne
+type grayImage struct {
+	pixels []uint8
+	rows   int
+	cols   int
+}
+
+// sampleGray convertit une zone d'une image en niveaux de gris de taille cols x rows
+// Chaque pixel est la moyenne d'au plus 4x4 échantillons de la zone source (réduction sans crénelage excessif)
+func sampleGray(img image.Image, area image.Rectangle, cols, rows int) grayImage {
+	g := grayImage{pixels: make([]uint8, cols*rows), rows: rows, cols: cols}
+	if area.Empty() || cols <= 0 || rows <= 0 {
+		return g
+	}
+
+	stepX := float64(area.Dx()) / float64(cols)
+	stepY := float64(area.Dy()) / float64(rows)
+	samplesX := max(1, min(4, int(stepX)))
+	samplesY := max(1, min(4, int(stepY)))
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			var sum, n uint32
+			for sy := 0; sy < samplesY; sy++ {
+				y := area.Min.Y + int((float64(r)+(float64(sy)+0.5)/float64(samplesY))*stepY)
+				for sx := 0; sx < samplesX; sx++ {
+					x := area.Min.X + int((float64(c)+(float64(sx)+0.5)/float64(samplesX))*stepX)
+					red, green, blue, _ := img.At(x, y).RGBA()
+					sum += (19595*red + 38470*green + 7471*blue + 1<<15) >> 24
+					n++
+				}
+			}
+			g.pixels[r*cols+c] = uint8(sum / n)
+		}
+	}
+	return g
+}
+
+// downscaleGray convertit une image entière en niveaux de gris, réduite à maxSide pixels au plus
+func downscaleGray(img image.Image, maxSide int) grayImage {
+	bounds := img.Bounds()
+	cols, rows := bounds.Dx(), bounds.Dy()
+	if longest := max(cols, rows); longest > maxSide {
+		cols = cols * maxSide / longest
+		rows = rows * maxSide / longest
+	}
+	return sampleGray(img, bounds, max(cols, 1), max(rows, 1))
+}
+
+// encodeEmbedding sérialise une signature (float32 little-endian)
+func encodeEmbedding(vector []float32) []byte {
+	data := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+	}
+	return data
+}
+
+// decodeEmbedding désérialise une signature
+func decodeEmbedding(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid embedding size: %d", len(data))
+	}
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector, nil
+}
+
+// dotProduct calcule la ressemblance de deux signatures normalisées (1 = identiques)
+func dotProduct(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// normalizeVector ramène un vecteur à une norme 1 (sur place)
+func normalizeVector(vector []float32) {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+}
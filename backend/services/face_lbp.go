@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+)
+
+// Signature de visage par histogrammes de motifs binaires locaux (LBP uniformes)
+// Méthode classique de reconnaissance faciale, rapide sur CPU
+// Contrairement au détecteur, ce n'est pas un modèle appris: aucun poids n'est lu dans le dossier de données.
+// Un modèle de signature appris (réseau chargeant ses poids) peut le remplacer via FaceService.SetFaceModels;
+// son nom différent (Name) fait recalculer les signatures existantes
+
+const (
+	lbpFaceSize   = 66   // Côté du visage rééchantillonné (64 pixels utiles + bordure)
+	lbpGridSize   = 4    // Le visage est découpé en 4x4 cellules
+	lbpBins       = 59   // 58 motifs uniformes + 1 case pour les autres
+	lbpFaceMargin = 0.15 // Marge ajoutée autour du rectangle détecté
+)
+
+// lbpUniformBins associe chaque motif 8 bits à sa case d'histogramme
+var lbpUniformBins = buildLBPUniformBins()
+
+// buildLBPUniformBins numérote les motifs ayant au plus deux transitions 0/1 (motifs uniformes)
+func buildLBPUniformBins() [256]uint8 {
+	var table [256]uint8
+	next := uint8(0)
+	for pattern := 0; pattern < 256; pattern++ {
+		rotated := uint8(pattern)<<1 | uint8(pattern)>>7
+		if bits.OnesCount8(uint8(pattern)^rotated) <= 2 {
+			table[pattern] = next
+			next++
+		} else {
+			table[pattern] = lbpBins - 1
+		}
+	}
+	return table
+}
+
+// lbpEmbedder calcule la signature LBP d'un visage
+type lbpEmbedder struct{}
+
+// newLBPEmbedder crée l'implémentation LBP de FaceEmbedder
+func newLBPEmbedder() *lbpEmbedder {
+	return &lbpEmbedder{}
+}
+
+// Name identifie le modèle (les signatures de modèles différents ne sont pas comparables)
+func (e *lbpEmbedder) Name() string {
+	return "lbp-u2-4x4"
+}
+
+// Embed rééchantillonne le visage, calcule un histogramme LBP par cellule
+// puis applique une racine carrée (distance de Hellinger) et une normalisation
+func (e *lbpEmbedder) Embed(img image.Image, face DetectedFace) ([]float32, error) {
+	bounds := img.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+
+	// Rectangle du visage en pixels, élargi et rendu carré autour de son centre
+	side := math.Max(face.Width*w, face.Height*h) * (1 + 2*lbpFaceMargin)
+	cx := float64(bounds.Min.X) + (face.X+face.Width/2)*w
+	cy := float64(bounds.Min.Y) + (face.Y+face.Height/2)*h
+	area := image.Rect(int(cx-side/2), int(cy-side/2), int(cx+side/2), int(cy+side/2)).Intersect(bounds)
+	if area.Dx() < 8 || area.Dy() < 8 {
+		return nil, fmt.Errorf("face region too small")
+	}
+
+	g := sampleGray(img, area, lbpFaceSize, lbpFaceSize)
+
+	inner := lbpFaceSize - 2
+	cell := inner / lbpGridSize
+	vector := make([]float32, lbpGridSize*lbpGridSize*lbpBins)
+
+	// Voisins dans l'ordre circulaire, en partant du coin haut gauche
+	neighbors := [8][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}}
+	for r := 1; r <= inner; r++ {
+		for c := 1; c <= inner; c++ {
+			center := g.pixels[r*g.cols+c]
+			var pattern uint8
+			for i, n := range neighbors {
+				if g.pixels[(r+n[0])*g.cols+c+n[1]] >= center {
+					pattern |= 1 << i
+				}
+			}
+			cellIndex := min((r-1)/cell, lbpGridSize-1)*lbpGridSize + min((c-1)/cell, lbpGridSize-1)
+			vector[cellIndex*lbpBins+int(lbpUniformBins[pattern])]++
+		}
+	}
+
+	for i, v := range vector {
+		vector[i] = float32(math.Sqrt(float64(v)))
+	}
+	normalizeVector(vector)
+
+	return vector, nil
+}
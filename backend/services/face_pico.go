@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"sort"
+)
+
+// Détecteur de visages PICO (Pixel Intensity Comparison-based Object detection)
+// Cascade d'arbres de décision comparant des paires de pixels: rapide sur CPU, sans dépendance native
+// Le fichier de poids est une cascade au format PICO (ex: "facefinder" distribué avec pico/pigo)
+
+const (
+	picoCascadeFile     = "facefinder" // Nom du fichier de poids dans <dataDir>/models
+	picoMaxImageSide    = 1024         // Les images sont réduites avant détection
+	picoMinFaceSize     = 20           // Taille minimale d'un visage (pixels, image réduite)
+	picoShiftFactor     = 0.1          // Pas de la fenêtre glissante, relatif à sa taille
+	picoScaleFactor     = 1.1          // Facteur entre deux tailles de fenêtre
+	picoIoUThreshold    = 0.2          // Recouvrement au-delà duquel deux détections sont fusionnées
+	picoQualityMinScore = 5.0          // Score minimal d'une détection fusionnée
+)
+
+// picoDetector applique une cascade PICO chargée depuis un fichier
+type picoDetector struct {
+	treeDepth  int
+	treeNum    int
+	treeCodes  []int8
+	treePreds  []float32
+	thresholds []float32
+}
+
+// picoDetection représente une fenêtre retenue (centre, taille et score)
+type picoDetection struct {
+	row, col, scale int
+	score           float32
+}
+
+// loadPicoDetector lit une cascade PICO
+func loadPicoDetector(path string) (*picoDetector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("face detection model not found: copy a PICO cascade to %s", path)
+		}
+		return nil, fmt.Errorf("cannot read face detection model: %w", err)
+	}
+	return parsePicoCascade(data)
+}
+
+// parsePicoCascade décode une cascade PICO
+// Format: 8 octets d'en-tête, profondeur et nombre d'arbres (uint32), puis pour chaque arbre
+// les codes des nœuds (4 octets signés par nœud), les prédictions des feuilles et le seuil (float32)
+func parsePicoCascade(data []byte) (*picoDetector, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("invalid face detection model: file too small")
+	}
+
+	d := &picoDetector{
+		treeDepth: int(binary.LittleEndian.Uint32(data[8:])),
+		treeNum:   int(binary.LittleEndian.Uint32(data[12:])),
+	}
+	if d.treeDepth <= 0 || d.treeDepth > 16 || d.treeNum <= 0 {
+		return nil, fmt.Errorf("invalid face detection model: bad tree shape")
+	}
+
+	leaves := 1 << d.treeDepth
+	codesSize := 4*leaves - 4
+	treeSize := codesSize + 4*leaves + 4
+	if len(data) < 16+d.treeNum*treeSize {
+		return nil, fmt.Errorf("invalid face detection model: truncated file")
+	}
+
+	pos := 16
+	for t := 0; t < d.treeNum; t++ {
+		// Le nœud 0 n'existe pas: les nœuds sont numérotés à partir de 1
+		d.treeCodes = append(d.treeCodes, 0, 0, 0, 0)
+		for _, b := range data[pos : pos+codesSize] {
+			d.treeCodes = append(d.treeCodes, int8(b))
+		}
+		pos += codesSize
+
+		for i := 0; i < leaves; i++ {
+			d.treePreds = append(d.treePreds, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+			pos += 4
+		}
+
+		d.thresholds = append(d.thresholds, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+		pos += 4
+	}
+
+	return d, nil
+}
+
+// Name identifie le détecteur
+func (d *picoDetector) Name() string {
+	return "pico"
+}
+
+// classifyRegion évalue la cascade sur une fenêtre centrée en (row, col) de taille scale
+// Retourne un score positif si la fenêtre contient un visage
+func (d *picoDetector) classifyRegion(row, col, scale int, g grayImage) float32 {
+	leaves := 1 << d.treeDepth
+	row *= 256
+	col *= 256
+
+	var out float32
+	root := 0
+	for i := 0; i < d.treeNum; i++ {
+		idx := 1
+		for j := 0; j < d.treeDepth; j++ {
+			code := d.treeCodes[root+4*idx : root+4*idx+4]
+			r1 := (row + int(code[0])*scale) >> 8
+			c1 := (col + int(code[1])*scale) >> 8
+			r2 := (row + int(code[2])*scale) >> 8
+			c2 := (col + int(code[3])*scale) >> 8
+
+			// Une cascade mal formée ne doit pas lire hors de l'image
+			if r1 < 0 || r2 < 0 || c1 < 0 || c2 < 0 || r1 >= g.rows || r2 >= g.rows || c1 >= g.cols || c2 >= g.cols {
+				return -1
+			}
+
+			bit := 0
+			if g.pixels[r1*g.cols+c1] <= g.pixels[r2*g.cols+c2] {
+				bit = 1
+			}
+			idx = 2*idx + bit
+		}
+
+		out += d.treePreds[leaves*i+idx-leaves]
+		if out <= d.thresholds[i] {
+			return -1
+		}
+		root += 4 * leaves
+	}
+
+	return out - d.thresholds[d.treeNum-1]
+}
+
+// Detect cherche les visages à toutes les tailles de fenêtre, puis fusionne les détections proches
+func (d *picoDetector) Detect(img image.Image) ([]DetectedFace, error) {
+	g := downscaleGray(img, picoMaxImageSide)
+
+	var detections []picoDetection
+	maxSize := min(g.rows, g.cols)
+	for scale := picoMinFaceSize; scale <= maxSize; scale = int(float64(scale) * picoScaleFactor) {
+		step := max(int(picoShiftFactor*float64(scale)), 1)
+		offset := scale/2 + 1
+		for row := offset; row <= g.rows-offset; row += step {
+			for col := offset; col <= g.cols-offset; col += step {
+				if score := d.classifyRegion(row, col, scale, g); score > 0 {
+					detections = append(detections, picoDetection{row, col, scale, score})
+				}
+			}
+		}
+	}
+
+	var faces []DetectedFace
+	for _, det := range clusterPicoDetections(detections) {
+		if det.score < picoQualityMinScore {
+			continue
+		}
+		half := float64(det.scale) / 2
+		x := math.Max(float64(det.col)-half, 0)
+		y := math.Max(float64(det.row)-half, 0)
+		faces = append(faces, DetectedFace{
+			X:          x / float64(g.cols),
+			Y:          y / float64(g.rows),
+			Width:      math.Min(float64(det.scale), float64(g.cols)-x) / float64(g.cols),
+			Height:     math.Min(float64(det.scale), float64(g.rows)-y) / float64(g.rows),
+			Confidence: float64(det.score),
+		})
+	}
+
+	return faces, nil
+}
+
+// picoIoU calcule le recouvrement (intersection / union) de deux fenêtres carrées
+func picoIoU(a, b picoDetection) float64 {
+	r1, c1, s1 := float64(a.row), float64(a.col), float64(a.scale)
+	r2, c2, s2 := float64(b.row), float64(b.col), float64(b.scale)
+
+	overRow := math.Max(0, math.Min(r1+s1/2, r2+s2/2)-math.Max(r1-s1/2, r2-s2/2))
+	overCol := math.Max(0, math.Min(c1+s1/2, c2+s2/2)-math.Max(c1-s1/2, c2-s2/2))
+	inter := overRow * overCol
+	return inter / (s1*s1 + s2*s2 - inter)
+}
+
+// clusterPicoDetections fusionne les fenêtres qui se recouvrent (position et taille moyennes, scores cumulés)
+func clusterPicoDetections(detections []picoDetection) []picoDetection {
+	sort.Slice(detections, func(i, j int) bool { return detections[i].score > detections[j].score })
+
+	assigned := make([]bool, len(detections))
+	var clusters []picoDetection
+	for i := range detections {
+		if assigned[i] {
+			continue
+		}
+		var row, col, scale, n int
+		var score float32
+		for j := i; j < len(detections); j++ {
+			if !assigned[j] && picoIoU(detections[i], detections[j]) > picoIoUThreshold {
+				assigned[j] = true
+				row += detections[j].row
+				col += detections[j].col
+				scale += detections[j].scale
+				score += detections[j].score
+				n++
+			}
+		}
+		clusters = append(clusters, picoDetection{row / n, col / n, scale / n, score})
+	}
+	return clusters
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// faceBoxTolerance absorbe les erreurs d'arrondi des coordonnées envoyées par l'UI
+const faceBoxTolerance = 1e-6
+
+// FaceRegionService gère les zones de visage dessinées sur les photos
+type FaceRegionService struct{}
+
+// NewFaceRegionService crée une nouvelle instance de FaceRegionService
+func NewFaceRegionService() *FaceRegionService {
+	return &FaceRegionService{}
+}
+
+// PictureTagsWithRegions regroupe les tags d'une photo et ses zones de visage
+type PictureTagsWithRegions struct {
+	Tags    []models.Tag        `json:"tags"`
+	Regions []models.FaceRegion `json:"regions"`
+}
+
+// validateFaceBox vérifie qu'un rectangle normalisé est non vide et contenu dans la photo
+func validateFaceBox(x, y, width, height float64) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("face region must have a positive size")
+	}
+	if x < -faceBoxTolerance || y < -faceBoxTolerance ||
+		x+width > 1+faceBoxTolerance || y+height > 1+faceBoxTolerance {
+		return fmt.Errorf("face region must be within the picture (coordinates between 0 and 1)")
+	}
+	return nil
+}
+
+// resolvePersonTag retourne le nom canonique d'un tag de personne, créé s'il n'existe pas
+// Un tag existant d'un autre type est refusé
+func resolvePersonTag(tx *gorm.DB, name string) (string, error) {
+	canonical, found, err := resolveTagName(tx, name)
+	if err != nil {
+		return "", err
+	}
+	if canonical == "" {
+		return "", fmt.Errorf("tag name cannot be empty")
+	}
+
+	if found {
+		var tag models.Tag
+		if err := tx.Where("name = ?", canonical).First(&tag).Error; err != nil {
+			return "", fmt.Errorf("cannot fetch tag: %w", err)
+		}
+		if tag.Type != models.TagTypePerson {
+			return "", fmt.Errorf("tag '%s' is not a person tag", canonical)
+		}
+		return canonical, nil
+	}
+
+	typeDef, err := findTagType(tx, models.TagTypePerson)
+	if err != nil {
+		return "", err
+	}
+	tag := models.Tag{Name: canonical, NameKey: normalizeTagName(canonical), Type: models.TagTypePerson, Color: typeDef.Color}
+	if err := tx.Create(&tag).Error; err != nil {
+		return "", fmt.Errorf("cannot create tag: %w", err)
+	}
+	return canonical, nil
+}
+
+// tagPictureForRegion pose le tag de la personne sur la photo de la zone (sans doublon)
+func tagPictureForRegion(tx *gorm.DB, picturePath string, tagName string) error {
+	pictureTag := models.PictureTag{PicturePath: picturePath, TagName: tagName}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&pictureTag).Error; err != nil {
+		return fmt.Errorf("cannot add tag to picture: %w", err)
+	}
+	return refreshSearchIndex(tx, picturePath)
+}
+
+// unassignFaceRegions détache les zones de visage des tags retirés de leurs photos
+func unassignFaceRegions(tx *gorm.DB, removed []models.PictureTag) error {
+	byTag := make(map[string][]string)
+	for _, a := range removed {
+		byTag[a.TagName] = append(byTag[a.TagName], a.PicturePath)
+	}
+
+	for tag, paths := range byTag {
+		for start := 0; start < len(paths); start += bulkTagBatchSize {
+			end := min(start+bulkTagBatchSize, len(paths))
+			err := tx.Model(&models.FaceRegion{}).
+				Where("tag_name = ? AND picture_path IN ?", tag, paths[start:end]).
+				Update("tag_name", nil).Error
+			if err != nil {
+				return fmt.Errorf("cannot update face regions: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// faceRegionScopes couvre une zone de visage, les tags de sa photo et le tag de personne éventuellement créé
+func faceRegionScopes(picturePath string, tagName string) []historyScope {
+	scopes := []historyScope{
+		{"face_regions", "picture_path = ?", []interface{}{picturePath}},
+		{"picture_tags", "picture_path = ?", []interface{}{picturePath}},
+	}
+	if tagName != "" {
+		scopes = append(scopes, historyScope{"tags", "name = ?", []interface{}{tagName}})
+	}
+	return scopes
+}
+
+// scopeTagName retourne le nom sous lequel un tag sera enregistré, pour l'historique
+func scopeTagName(tagName string) (string, error) {
+	if strings.TrimSpace(tagName) == "" {
+		return "", nil
+	}
+	canonical, _, err := resolveTagName(database.DB, tagName)
+	return canonical, err
+}
+
+// AddFaceRegion dessine une zone de visage sur une photo
+// tagName rattache la zone à une personne (créée si besoin) et pose le tag sur la photo; vide = visage non identifié
+func (fs *FaceRegionService) AddFaceRegion(picturePath string, x, y, width, height float64, tagName string) (*models.FaceRegion, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	if err := validateFaceBox(x, y, width, height); err != nil {
+		return nil, err
+	}
+
+	var picture models.Picture
+	if err := database.DB.Where("path = ?", picturePath).First(&picture).Error; err != nil {
+		return nil, fmt.Errorf("picture not found: %s", picturePath)
+	}
+
+	scopeTag, err := scopeTagName(tagName)
+	if err != nil {
+		return nil, err
+	}
+
+	region := models.FaceRegion{
+		PicturePath: picturePath,
+		X:           x,
+		Y:           y,
+		Width:       width,
+		Height:      height,
+		Source:      models.FaceRegionManual,
+	}
+
+	description := fmt.Sprintf("add face region on '%s'", filepath.Base(picturePath))
+	err = recordOperation(description, faceRegionScopes(picturePath, scopeTag), func(tx *gorm.DB) error {
+		if scopeTag != "" {
+			name, err := resolvePersonTag(tx, tagName)
+			if err != nil {
+				return err
+			}
+			region.TagName = &name
+		}
+
+		if err := tx.Create(&region).Error; err != nil {
+			return fmt.Errorf("cannot create face region: %w", err)
+		}
+
+		if region.TagName != nil {
+			return tagPictureForRegion(tx, picturePath, *region.TagName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &region, nil
+}
+
+// UpdateFaceRegion déplace ou redimensionne une zone de visage
+func (fs *FaceRegionService) UpdateFaceRegion(id uint, x, y, width, height float64) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	if err := validateFaceBox(x, y, width, height); err != nil {
+		return err
+	}
+
+	var region models.FaceRegion
+	if err := database.DB.First(&region, id).Error; err != nil {
+		return fmt.Errorf("face region not found: %d", id)
+	}
+
+	scopes := []historyScope{{"face_regions", "id = ?", []interface{}{id}}}
+	return recordOperation("move face region", scopes, func(tx *gorm.DB) error {
+		err := tx.Model(&region).Updates(map[string]interface{}{
+			"x":      x,
+			"y":      y,
+			"width":  width,
+			"height": height,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("cannot update face region: %w", err)
+		}
+		return deleteFaceEmbedding(tx, id)
+	})
+}
+
+// AssignFaceRegion rattache une zone de visage à une personne (vide = visage non identifié)
+// Le tag de la personne est posé sur la photo; l'ancien tag éventuel reste sur la photo
+func (fs *FaceRegionService) AssignFaceRegion(id uint, tagName string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var region models.FaceRegion
+	if err := database.DB.First(&region, id).Error; err != nil {
+		return fmt.Errorf("face region not found: %d", id)
+	}
+
+	scopeTag, err := scopeTagName(tagName)
+	if err != nil {
+		return err
+	}
+
+	description := "clear face region"
+	if scopeTag != "" {
+		description = fmt.Sprintf("assign face region to '%s'", scopeTag)
+	}
+	return recordOperation(description, faceRegionScopes(region.PicturePath, scopeTag), func(tx *gorm.DB) error {
+		var name *string
+		if scopeTag != "" {
+			canonical, err := resolvePersonTag(tx, tagName)
+			if err != nil {
+				return err
+			}
+			name = &canonical
+		}
+
+		if err := tx.Model(&region).Update("tag_name", name).Error; err != nil {
+			return fmt.Errorf("cannot update face region: %w", err)
+		}
+
+		if name != nil {
+			return tagPictureForRegion(tx, region.PicturePath, *name)
+		}
+		return nil
+	})
+}
+
+// DeleteFaceRegion supprime une zone de visage (le tag de la personne reste sur la photo)
+func (fs *FaceRegionService) DeleteFaceRegion(id uint) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	scopes := []historyScope{{"face_regions", "id = ?", []interface{}{id}}}
+	return recordOperation("delete face region", scopes, func(tx *gorm.DB) error {
+		result := tx.Delete(&models.FaceRegion{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("cannot delete face region: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("face region not found: %d", id)
+		}
+		return deleteFaceEmbedding(tx, id)
+	})
+}
+
+// deleteFaceEmbedding oublie la signature d'une zone modifiée (recalculée à la prochaine analyse)
+func deleteFaceEmbedding(tx *gorm.DB, regionID uint) error {
+	if err := tx.Delete(&models.FaceEmbedding{}, regionID).Error; err != nil {
+		return fmt.Errorf("cannot delete face signature: %w", err)
+	}
+	return nil
+}
+
+// GetFaceRegions retourne les zones de visage d'une photo
+func (fs *FaceRegionService) GetFaceRegions(picturePath string) ([]models.FaceRegion, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	return faceRegionsForPicture(database.DB, picturePath)
+}
+
+// GetFaceRegionsForTag retourne les zones de visage d'une personne, toutes photos confondues
+func (fs *FaceRegionService) GetFaceRegionsForTag(tagName string) ([]models.FaceRegion, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	if canonical, found, err := resolveTagName(database.DB, tagName); err != nil {
+		return nil, err
+	} else if found {
+		tagName = canonical
+	}
+
+	var regions []models.FaceRegion
+	if err := database.DB.Where("tag_name = ?", tagName).Order("picture_path").Order("id").Find(&regions).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch face regions: %w", err)
+	}
+
+	return regions, nil
+}
+
+// faceRegionsForPicture lit les zones de visage d'une photo, de gauche à droite
+func faceRegionsForPicture(db *gorm.DB, picturePath string) ([]models.FaceRegion, error) {
+	var regions []models.FaceRegion
+	if err := db.Where("picture_path = ?", picturePath).Order("x").Order("id").Find(&regions).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch face regions: %w", err)
+	}
+	return regions, nil
+}
+
+// GetTagsForPictureWithRegions retourne les tags d'une photo accompagnés de ses zones de visage
+func (ts *TagService) GetTagsForPictureWithRegions(picturePath string) (*PictureTagsWithRegions, error) {
+	tags, err := ts.GetTagsForPicture(picturePath)
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := faceRegionsForPicture(database.DB, picturePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PictureTagsWithRegions{Tags: tags, Regions: regions}, nil
+}
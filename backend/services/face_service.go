@@ -0,0 +1,632 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultFaceClusterThreshold = 0.8 // Ressemblance minimale pour rejoindre un groupe de visages
+	faceOverlapThreshold        = 0.5 // Recouvrement au-delà duquel un visage détecté double une zone existante
+	faceClusterSamples          = 12  // Zones d'exemple retournées pour chaque groupe
+)
+
+// FaceJobStatus décrit l'avancement de la détection de visages en tâche de fond
+type FaceJobStatus struct {
+	Running    bool      `json:"running"`
+	Processed  int       `json:"processed"`  // Photos analysées
+	Total      int       `json:"total"`      // Photos à analyser
+	FacesFound int       `json:"facesFound"` // Visages détectés depuis le lancement
+	Current    string    `json:"current"`    // Photo en cours
+	Error      string    `json:"error"`      // Erreur ayant interrompu la tâche
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// FaceCluster représente un groupe de visages non identifiés qui se ressemblent
+type FaceCluster struct {
+	ID               uint                `json:"id"`
+	FaceCount        int                 `json:"faceCount"`
+	Samples          []models.FaceRegion `json:"samples"`          // Quelques zones du groupe
+	SuggestedTagName string              `json:"suggestedTagName"` // Personne connue la plus ressemblante (vide si aucune)
+	SuggestionScore  float64             `json:"suggestionScore"`  // Ressemblance avec cette personne (0..1)
+}
+
+// FaceService détecte les visages, calcule leurs signatures et regroupe les visages inconnus
+// Le détecteur et le calcul de signature sont interchangeables (SetFaceModels)
+type FaceService struct {
+	dataDir  string
+	mu       sync.Mutex
+	detector FaceDetector
+	embedder FaceEmbedder
+	status   FaceJobStatus
+	cancel   chan struct{}
+}
+
+// NewFaceService crée une nouvelle instance de FaceService
+func NewFaceService(dataDir string) *FaceService {
+	return &FaceService{dataDir: dataDir}
+}
+
+// SetFaceModels remplace le détecteur et le calcul de signature par d'autres implémentations
+func (fs *FaceService) SetFaceModels(detector FaceDetector, embedder FaceEmbedder) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.detector = detector
+	fs.embedder = embedder
+}
+
+// faceDetector retourne le détecteur configuré, en chargeant celui par défaut au premier appel
+// Doit être appelé avec le verrou
+func (fs *FaceService) faceDetector() (FaceDetector, error) {
+	if fs.detector == nil {
+		detector, err := loadDefaultFaceDetector(fs.dataDir)
+		if err != nil {
+			return nil, err
+		}
+		fs.detector = detector
+	}
+	return fs.detector, nil
+}
+
+// faceEmbedder retourne le calcul de signature configuré (celui par défaut si aucun)
+func (fs *FaceService) faceEmbedder() FaceEmbedder {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.embedder == nil {
+		fs.embedder = defaultFaceEmbedder()
+	}
+	return fs.embedder
+}
+
+// StartFaceDetection lance l'analyse des photos pas encore traitées en tâche de fond
+// onProgress est appelé après chaque photo et à la fin de la tâche
+func (fs *FaceService) StartFaceDetection(onProgress func(status FaceJobStatus)) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.status.Running {
+		return fmt.Errorf("face detection is already running")
+	}
+
+	detector, err := fs.faceDetector()
+	if err != nil {
+		return err
+	}
+	if fs.embedder == nil {
+		fs.embedder = defaultFaceEmbedder()
+	}
+	embedder := fs.embedder
+
+	var paths []string
+	err = database.DB.Model(&models.Picture{}).
+		Where("path NOT IN (SELECT picture_path FROM face_scans)").
+		Order("path").Pluck("path", &paths).Error
+	if err != nil {
+		return fmt.Errorf("cannot fetch pictures to scan: %w", err)
+	}
+
+	fs.status = FaceJobStatus{Running: true, Total: len(paths), StartedAt: time.Now()}
+	fs.cancel = make(chan struct{})
+	go fs.runFaceDetection(paths, detector, embedder, fs.cancel, onProgress)
+
+	return nil
+}
+
+// StopFaceDetection interrompt la tâche de fond (les photos déjà analysées sont conservées)
+func (fs *FaceService) StopFaceDetection() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.status.Running && fs.cancel != nil {
+		close(fs.cancel)
+		fs.cancel = nil
+	}
+}
+
+// GetFaceJobStatus retourne l'avancement de la détection de visages
+func (fs *FaceService) GetFaceJobStatus() FaceJobStatus {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.status
+}
+
+// updateStatus modifie l'avancement et le notifie
+func (fs *FaceService) updateStatus(onProgress func(status FaceJobStatus), update func(status *FaceJobStatus)) {
+	fs.mu.Lock()
+	update(&fs.status)
+	status := fs.status
+	fs.mu.Unlock()
+
+	if onProgress != nil {
+		onProgress(status)
+	}
+}
+
+// runFaceDetection analyse les photos une par une, calcule les signatures manquantes puis regroupe les visages
+func (fs *FaceService) runFaceDetection(paths []string, detector FaceDetector, embedder FaceEmbedder, cancel chan struct{}, onProgress func(status FaceJobStatus)) {
+	var jobErr error
+
+scan:
+	for i, path := range paths {
+		select {
+		case <-cancel:
+			break scan
+		default:
+		}
+
+		fs.updateStatus(onProgress, func(s *FaceJobStatus) { s.Current = filepath.Base(path) })
+
+		found, err := scanPictureFaces(database.DB, path, detector, embedder)
+		if err != nil {
+			fmt.Printf("Warning: face detection failed for %s: %v\n", path, err)
+		}
+
+		fs.updateStatus(onProgress, func(s *FaceJobStatus) {
+			s.Processed = i + 1
+			s.FacesFound += found
+		})
+	}
+
+	// Zones dessinées ou déplacées depuis la dernière analyse
+	if err := embedPendingFaceRegions(database.DB, embedder); err != nil {
+		jobErr = err
+	} else if _, err := clusterFaces(database.DB, embedder.Name(), defaultFaceClusterThreshold); err != nil {
+		jobErr = err
+	}
+
+	fs.updateStatus(onProgress, func(s *FaceJobStatus) {
+		s.Running = false
+		s.Current = ""
+		s.FinishedAt = time.Now()
+		if jobErr != nil {
+			s.Error = jobErr.Error()
+		}
+	})
+
+	fs.mu.Lock()
+	fs.cancel = nil
+	fs.mu.Unlock()
+}
+
+// decodeImageFile décode une image complète
+func decodeImageFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode image: %w", err)
+	}
+	return img, nil
+}
+
+// faceIoU calcule le recouvrement (intersection / union) de deux rectangles normalisés
+func faceIoU(a DetectedFace, b models.FaceRegion) float64 {
+	ix := max(0, min(a.X+a.Width, b.X+b.Width)-max(a.X, b.X))
+	iy := max(0, min(a.Y+a.Height, b.Y+b.Height)-max(a.Y, b.Y))
+	inter := ix * iy
+	union := a.Width*a.Height + b.Width*b.Height - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
+
+// scanPictureFaces détecte les visages d'une photo, crée les zones automatiques et calcule leurs signatures
+// Retourne le nombre de zones créées; la photo est marquée comme analysée même si elle est illisible
+// ou si la détection échoue (l'erreur est conservée dans FaceScan.Error)
+func scanPictureFaces(db *gorm.DB, path string, detector FaceDetector, embedder FaceEmbedder) (int, error) {
+	scan := models.FaceScan{PicturePath: path, Detector: detector.Name()}
+
+	img, err := decodeImageFile(path)
+	if err != nil {
+		scan.Error = err.Error()
+		if saveErr := db.Save(&scan).Error; saveErr != nil {
+			return 0, fmt.Errorf("cannot save face scan: %w", saveErr)
+		}
+		return 0, err
+	}
+
+	faces, err := detector.Detect(img)
+	if err != nil {
+		scan.Error = err.Error()
+		if saveErr := db.Save(&scan).Error; saveErr != nil {
+			return 0, fmt.Errorf("cannot save face scan: %w", saveErr)
+		}
+		return 0, fmt.Errorf("cannot detect faces: %w", err)
+	}
+
+	created := 0
+	err = db.Transaction(func(tx *gorm.DB) error {
+		existing, err := faceRegionsForPicture(tx, path)
+		if err != nil {
+			return err
+		}
+
+		for _, face := range faces {
+			// Un visage déjà dessiné (ou détecté lors d'une analyse précédente) n'est pas dupliqué
+			duplicate := false
+			for _, region := range existing {
+				if faceIoU(face, region) > faceOverlapThreshold {
+					duplicate = true
+					break
+				}
+			}
+			if duplicate {
+				continue
+			}
+
+			region := models.FaceRegion{
+				PicturePath: path,
+				X:           face.X,
+				Y:           face.Y,
+				Width:       face.Width,
+				Height:      face.Height,
+				Source:      models.FaceRegionAuto,
+				Confidence:  face.Confidence,
+			}
+			if err := tx.Create(&region).Error; err != nil {
+				return fmt.Errorf("cannot create face region: %w", err)
+			}
+			existing = append(existing, region)
+			created++
+		}
+
+		scan.FaceCount = len(faces)
+		if err := tx.Save(&scan).Error; err != nil {
+			return fmt.Errorf("cannot save face scan: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return created, embedPictureRegions(db, img, path, embedder)
+}
+
+// embedPictureRegions calcule les signatures manquantes des zones d'une photo déjà décodée
+func embedPictureRegions(db *gorm.DB, img image.Image, path string, embedder FaceEmbedder) error {
+	var regions []models.FaceRegion
+	err := db.Where("picture_path = ? AND id NOT IN (SELECT region_id FROM face_embeddings WHERE model = ?)", path, embedder.Name()).
+		Find(&regions).Error
+	if err != nil {
+		return fmt.Errorf("cannot fetch face regions: %w", err)
+	}
+
+	for _, region := range regions {
+		vector, err := embedder.Embed(img, DetectedFace{X: region.X, Y: region.Y, Width: region.Width, Height: region.Height})
+		if err != nil {
+			fmt.Printf("Warning: cannot compute face signature for region %d: %v\n", region.ID, err)
+			continue
+		}
+
+		embedding := models.FaceEmbedding{RegionID: region.ID, Model: embedder.Name(), Vector: encodeEmbedding(vector)}
+		if err := db.Save(&embedding).Error; err != nil {
+			return fmt.Errorf("cannot save face signature: %w", err)
+		}
+	}
+	return nil
+}
+
+// embedPendingFaceRegions calcule les signatures de toutes les zones qui n'en ont pas encore
+func embedPendingFaceRegions(db *gorm.DB, embedder FaceEmbedder) error {
+	var paths []string
+	err := db.Model(&models.FaceRegion{}).
+		Where("id NOT IN (SELECT region_id FROM face_embeddings WHERE model = ?)", embedder.Name()).
+		Distinct().Pluck("picture_path", &paths).Error
+	if err != nil {
+		return fmt.Errorf("cannot fetch face regions: %w", err)
+	}
+
+	for _, path := range paths {
+		img, err := decodeImageFile(path)
+		if err != nil {
+			fmt.Printf("Warning: cannot read %s for face signatures: %v\n", path, err)
+			continue
+		}
+		if err := embedPictureRegions(db, img, path, embedder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// faceSignature est une signature chargée avec la personne de sa zone
+type faceSignature struct {
+	regionID uint
+	tagName  string // Vide = visage non identifié
+	vector   []float32
+}
+
+// loadFaceSignatures charge les signatures calculées par un modèle
+func loadFaceSignatures(db *gorm.DB, model string) ([]faceSignature, error) {
+	var rows []struct {
+		RegionID uint
+		TagName  *string
+		Vector   []byte
+	}
+	err := db.Table("face_embeddings").
+		Select("face_embeddings.region_id, face_regions.tag_name, face_embeddings.vector").
+		Joins("JOIN face_regions ON face_regions.id = face_embeddings.region_id").
+		Where("face_embeddings.model = ?", model).
+		Order("face_embeddings.region_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch face signatures: %w", err)
+	}
+
+	signatures := make([]faceSignature, 0, len(rows))
+	for _, row := range rows {
+		vector, err := decodeEmbedding(row.Vector)
+		if err != nil {
+			return nil, err
+		}
+		s := faceSignature{regionID: row.RegionID, vector: vector}
+		if row.TagName != nil {
+			s.tagName = *row.TagName
+		}
+		signatures = append(signatures, s)
+	}
+	return signatures, nil
+}
+
+// faceCentroid accumule des signatures et donne leur direction moyenne
+type faceCentroid struct {
+	sum     []float32
+	members []uint
+}
+
+// add ajoute une signature au groupe
+func (c *faceCentroid) add(regionID uint, vector []float32) {
+	if c.sum == nil {
+		c.sum = make([]float32, len(vector))
+	}
+	for i, v := range vector {
+		c.sum[i] += v
+	}
+	c.members = append(c.members, regionID)
+}
+
+// vector retourne la moyenne normalisée des signatures du groupe
+func (c *faceCentroid) vector() []float32 {
+	v := append([]float32(nil), c.sum...)
+	normalizeVector(v)
+	return v
+}
+
+// clusterFaces regroupe les visages non identifiés dont la ressemblance dépasse le seuil
+// Regroupement incrémental: chaque visage rejoint le groupe le plus proche ou en crée un nouveau
+// Les visages isolés ne forment pas de groupe; retourne le nombre de groupes
+func clusterFaces(db *gorm.DB, model string, threshold float64) (int, error) {
+	signatures, err := loadFaceSignatures(db, model)
+	if err != nil {
+		return 0, err
+	}
+
+	var clusters []*faceCentroid
+	for _, s := range signatures {
+		if s.tagName != "" {
+			continue
+		}
+
+		var best *faceCentroid
+		bestScore := threshold
+		for _, c := range clusters {
+			if score := dotProduct(c.vector(), s.vector); score >= bestScore {
+				best, bestScore = c, score
+			}
+		}
+		if best == nil {
+			best = &faceCentroid{}
+			clusters = append(clusters, best)
+		}
+		best.add(s.regionID, s.vector)
+	}
+
+	// Les plus grands groupes d'abord
+	sort.SliceStable(clusters, func(i, j int) bool { return len(clusters[i].members) > len(clusters[j].members) })
+
+	count := 0
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.FaceEmbedding{}).Where("cluster_id <> 0").Update("cluster_id", 0).Error; err != nil {
+			return fmt.Errorf("cannot reset face clusters: %w", err)
+		}
+
+		for _, c := range clusters {
+			if len(c.members) < 2 {
+				continue
+			}
+			count++
+			for start := 0; start < len(c.members); start += bulkTagBatchSize {
+				end := min(start+bulkTagBatchSize, len(c.members))
+				err := tx.Model(&models.FaceEmbedding{}).
+					Where("region_id IN ?", c.members[start:end]).
+					Update("cluster_id", count).Error
+				if err != nil {
+					return fmt.Errorf("cannot save face clusters: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ClusterFaces regroupe de nouveau les visages non identifiés (threshold <= 0 = seuil par défaut)
+func (fs *FaceService) ClusterFaces(threshold float64) (int, error) {
+	if err := checkDB(); err != nil {
+		return 0, err
+	}
+	if threshold <= 0 {
+		threshold = defaultFaceClusterThreshold
+	}
+
+	return clusterFaces(database.DB, fs.faceEmbedder().Name(), threshold)
+}
+
+// GetFaceClusters retourne les groupes de visages non identifiés, avec la personne connue la plus ressemblante
+func (fs *FaceService) GetFaceClusters() ([]FaceCluster, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	embedder := fs.faceEmbedder()
+	signatures, err := loadFaceSignatures(database.DB, embedder.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var clusterRows []models.FaceEmbedding
+	err = database.DB.Select("region_id", "cluster_id").
+		Where("model = ? AND cluster_id <> 0", embedder.Name()).
+		Find(&clusterRows).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch face clusters: %w", err)
+	}
+	clusterOf := make(map[uint]uint, len(clusterRows))
+	for _, row := range clusterRows {
+		clusterOf[row.RegionID] = row.ClusterID
+	}
+
+	people := make(map[string]*faceCentroid)
+	groups := make(map[uint]*faceCentroid)
+	for _, s := range signatures {
+		if s.tagName != "" {
+			if people[s.tagName] == nil {
+				people[s.tagName] = &faceCentroid{}
+			}
+			people[s.tagName].add(s.regionID, s.vector)
+		} else if id := clusterOf[s.regionID]; id != 0 {
+			if groups[id] == nil {
+				groups[id] = &faceCentroid{}
+			}
+			groups[id].add(s.regionID, s.vector)
+		}
+	}
+
+	personVectors := make(map[string][]float32, len(people))
+	for name, c := range people {
+		personVectors[name] = c.vector()
+	}
+
+	clusters := make([]FaceCluster, 0, len(groups))
+	for id, group := range groups {
+		cluster := FaceCluster{ID: id, FaceCount: len(group.members)}
+
+		centroid := group.vector()
+		for name, vector := range personVectors {
+			score := dotProduct(centroid, vector)
+			if score >= defaultFaceClusterThreshold && score > cluster.SuggestionScore {
+				cluster.SuggestedTagName, cluster.SuggestionScore = name, score
+			}
+		}
+
+		samples := group.members[:min(len(group.members), faceClusterSamples)]
+		if err := database.DB.Where("id IN ?", samples).Order("id").Find(&cluster.Samples).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch face regions: %w", err)
+		}
+
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].ID < clusters[j].ID })
+
+	return clusters, nil
+}
+
+// NameFaceCluster rattache tous les visages d'un groupe à une personne (créée si besoin)
+// Le tag de la personne est posé sur les photos concernées (opération annulable)
+func (fs *FaceService) NameFaceCluster(clusterID uint, tagName string) (int, error) {
+	if err := checkDB(); err != nil {
+		return 0, err
+	}
+
+	if strings.TrimSpace(tagName) == "" {
+		return 0, fmt.Errorf("tag name cannot be empty")
+	}
+	scopeTag, err := scopeTagName(tagName)
+	if err != nil {
+		return 0, err
+	}
+
+	var regions []models.FaceRegion
+	err = database.DB.Where("tag_name IS NULL AND id IN (SELECT region_id FROM face_embeddings WHERE cluster_id = ?)", clusterID).
+		Find(&regions).Error
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch face regions: %w", err)
+	}
+	if len(regions) == 0 {
+		return 0, fmt.Errorf("face cluster not found: %d", clusterID)
+	}
+
+	ids := make([]uint, 0, len(regions))
+	seen := make(map[string]bool)
+	var paths []string
+	for _, region := range regions {
+		ids = append(ids, region.ID)
+		if !seen[region.PicturePath] {
+			seen[region.PicturePath] = true
+			paths = append(paths, region.PicturePath)
+		}
+	}
+
+	scopes := append(historyScopesIn("picture_tags", "picture_path", paths),
+		historyScope{"tags", "name = ?", []interface{}{scopeTag}},
+		historyScope{"face_regions", "id IN ?", []interface{}{ids}})
+	description := fmt.Sprintf("name %d faces '%s'", len(ids), scopeTag)
+	err = recordOperation(description, scopes, func(tx *gorm.DB) error {
+		name, err := resolvePersonTag(tx, tagName)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.FaceRegion{}).Where("id IN ?", ids).Update("tag_name", name).Error; err != nil {
+			return fmt.Errorf("cannot update face regions: %w", err)
+		}
+
+		toAdd := make([]models.PictureTag, 0, len(paths))
+		for _, path := range paths {
+			toAdd = append(toAdd, models.PictureTag{PicturePath: path, TagName: name})
+		}
+		if err := applyBulkTags(tx, toAdd, nil); err != nil {
+			return err
+		}
+
+		// Les visages identifiés quittent le groupe
+		if err := tx.Model(&models.FaceEmbedding{}).Where("region_id IN ?", ids).Update("cluster_id", 0).Error; err != nil {
+			return fmt.Errorf("cannot update face clusters: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
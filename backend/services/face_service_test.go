@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+)
+
+// failingDetector est un détecteur dont l'analyse échoue toujours
+type failingDetector struct{}
+
+func (failingDetector) Name() string { return "failing" }
+
+func (failingDetector) Detect(img image.Image) ([]DetectedFace, error) {
+	return nil, fmt.Errorf("detector crashed")
+}
+
+func TestScanPictureFacesRecordsDetectorError(t *testing.T) {
+	_, _, paths := setupTestLibrary(t, 1)
+
+	if _, err := scanPictureFaces(database.DB, paths[0], failingDetector{}, newLBPEmbedder()); err == nil {
+		t.Fatalf("scan should fail")
+	}
+
+	var scan models.FaceScan
+	if err := database.DB.First(&scan, "picture_path = ?", paths[0]).Error; err != nil {
+		t.Fatalf("face scan not saved: %v", err)
+	}
+	if scan.Error != "detector crashed" || scan.Detector != "failing" {
+		t.Errorf("got scan %+v", scan)
+	}
+}
+
+func TestDatabaseUsesWALJournal(t *testing.T) {
+	setupTestDB(t)
+
+	var mode string
+	if err := database.DB.Raw("PRAGMA journal_mode").Scan(&mode).Error; err != nil {
+		t.Fatalf("cannot read journal mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal mode %q, want wal", mode)
+	}
+	var timeout int
+	if err := database.DB.Raw("PRAGMA busy_timeout").Scan(&timeout).Error; err != nil {
+		t.Fatalf("cannot read busy timeout: %v", err)
+	}
+	if timeout == 0 {
+		t.Errorf("no busy timeout configured")
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Historique de consultation
+const (
+	viewDebounce         = time.Minute          // Une photo rouverte dans ce délai ne compte qu'une fois
+	viewHistoryRetention = 180 * 24 * time.Hour // Les vues plus anciennes sont oubliées
+	maxViewHistorySize   = 10000                // Nombre maximal de vues conservées
+)
+
+// viewStatsJoin joint à chaque photo son nombre de vues et sa dernière vue (les photos jamais vues sont exclues)
+const viewStatsJoin = "JOIN (SELECT picture_path AS view_path, COUNT(*) AS view_count, MAX(id) AS last_view_id " +
+	"FROM picture_views GROUP BY picture_path) AS view_stats ON view_stats.view_path = pictures.path"
+
+// FavoriteService gère les photos favorites et l'historique de consultation
+type FavoriteService struct{}
+
+// NewFavoriteService crée une nouvelle instance de FavoriteService
+func NewFavoriteService() *FavoriteService {
+	return &FavoriteService{}
+}
+
+// ToggleFavorite ajoute ou retire une photo des favoris
+// Retourne le nouvel état de la photo
+func (fs *FavoriteService) ToggleFavorite(picturePath string) (bool, error) {
+	if err := checkDB(); err != nil {
+		return false, err
+	}
+
+	var picture models.Picture
+	if err := database.DB.Select("path", "favorite").Where("path = ?", picturePath).First(&picture).Error; err != nil {
+		return false, fmt.Errorf("picture not found: %s", picturePath)
+	}
+
+	if _, err := fs.SetFavorites([]string{picturePath}, !picture.Favorite); err != nil {
+		return false, err
+	}
+	return !picture.Favorite, nil
+}
+
+// SetFavorites ajoute ou retire plusieurs photos des favoris
+// Retourne le nombre de photos modifiées
+func (fs *FavoriteService) SetFavorites(picturePaths []string, favorite bool) (int, error) {
+	action := "remove from favorites"
+	if favorite {
+		action = "add to favorites"
+	}
+	return updatePictures(cullingDescription(action, picturePaths), picturePaths, "favorite", favorite)
+}
+
+// GetFavorites retourne les photos favorites correspondant aux critères, paginées et triées selon page
+func (fs *FavoriteService) GetFavorites(criteria SearchCriteria, page PageRequest) (*PicturePage, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	criteria.FavoritesOnly = true
+	return searchPictures(criteria, page)
+}
+
+// MarkViewed enregistre l'ouverture d'une photo dans la visionneuse
+// Les ouvertures répétées d'une même photo en moins d'une minute ne comptent qu'une fois
+func (fs *FavoriteService) MarkViewed(picturePath string) error {
+	if err := checkPictureExists(picturePath); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var recent int64
+		err := tx.Model(&models.PictureView{}).
+			Where("picture_path = ? AND viewed_at > ?", picturePath, now.Add(-viewDebounce)).
+			Count(&recent).Error
+		if err != nil {
+			return fmt.Errorf("cannot fetch view history: %w", err)
+		}
+		if recent > 0 {
+			return nil
+		}
+
+		view := models.PictureView{PicturePath: picturePath, ViewedAt: now}
+		if err := tx.Create(&view).Error; err != nil {
+			return fmt.Errorf("cannot record view: %w", err)
+		}
+
+		// Historique borné: les vues les plus anciennes sont oubliées
+		err = tx.Where("id <= ? OR viewed_at < ?", int64(view.ID)-maxViewHistorySize, now.Add(-viewHistoryRetention)).
+			Delete(&models.PictureView{}).Error
+		if err != nil {
+			return fmt.Errorf("cannot prune view history: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetRecentlyViewed retourne les photos vues correspondant aux critères, de la plus récemment ouverte à la plus ancienne
+// Seuls le curseur et la limite de page sont utilisés (le tri est imposé)
+func (fs *FavoriteService) GetRecentlyViewed(criteria SearchCriteria, page PageRequest) (*PicturePage, error) {
+	return viewedPictures(criteria, page, SortByLastViewed)
+}
+
+// GetMostViewed retourne les photos vues correspondant aux critères, des plus ouvertes aux moins ouvertes
+// Seuls le curseur et la limite de page sont utilisés (le tri est imposé)
+func (fs *FavoriteService) GetMostViewed(criteria SearchCriteria, page PageRequest) (*PicturePage, error) {
+	return viewedPictures(criteria, page, SortByViewCount)
+}
+
+// viewedPictures applique les critères aux photos présentes dans l'historique de consultation
+func viewedPictures(criteria SearchCriteria, page PageRequest, sort SortField) (*PicturePage, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	page.Sort = sort
+	page.Descending = true
+	query := applySearchCriteria(picturesQuery().Joins(viewStatsJoin), criteria)
+	return paginatePictures(query, page)
+}
+
+// ClearViewHistory efface l'historique de consultation
+func (fs *FavoriteService) ClearViewHistory() error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	if err := database.DB.Where("1 = 1").Delete(&models.PictureView{}).Error; err != nil {
+		return fmt.Errorf("cannot clear view history: %w", err)
+	}
+	return nil
+}
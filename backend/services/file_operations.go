@@ -0,0 +1,605 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// CollisionPolicy règle le traitement d'un fichier cible déjà existant
+type CollisionPolicy string
+
+const (
+	CollisionFail   CollisionPolicy = "fail"   // Annuler toute l'opération (défaut)
+	CollisionSkip   CollisionPolicy = "skip"   // Laisser la photo en place
+	CollisionRename CollisionPolicy = "rename" // Ajouter un suffixe au nom (photo_2.jpg, photo_3.jpg...)
+)
+
+// FileOperationOptions règle une opération sur les fichiers
+type FileOperationOptions struct {
+	Preview   bool            `json:"preview"`   // Calculer les noms cibles sans rien modifier
+	Collision CollisionPolicy `json:"collision"` // Traitement des fichiers cibles existants
+}
+
+// FileOperationItem décrit le sort d'une photo dans une opération sur les fichiers
+type FileOperationItem struct {
+	Source  string `json:"source"`           // Chemin d'origine
+	Target  string `json:"target"`           // Chemin cible
+	Skipped bool   `json:"skipped"`          // Photo laissée en place
+	Reason  string `json:"reason,omitempty"` // Raison de l'abandon
+}
+
+// FileOperationResult résume une opération sur les fichiers (ou son aperçu)
+type FileOperationResult struct {
+	Preview   bool                `json:"preview"`   // Aucun fichier n'a été modifié
+	Processed int                 `json:"processed"` // Photos déplacées, copiées ou renommées
+	Skipped   int                 `json:"skipped"`   // Photos laissées en place
+	Items     []FileOperationItem `json:"items"`
+}
+
+// picturePathColumns liste les colonnes qui référencent le chemin d'une photo
+var picturePathColumns = []struct{ table, column string }{
+	{"pictures", "path"},
+	{"picture_tags", "picture_path"},
+	{"album_pictures", "picture_path"},
+	{"albums", "cover_path"},
+	{"face_regions", "picture_path"},
+	{"face_scans", "picture_path"},
+	{"picture_views", "picture_path"},
+}
+
+// plannedFile est un fichier à déplacer ou copier (photo ou sidecar XMP)
+type plannedFile struct {
+	source string
+	target string
+}
+
+// fileStep est une étape réalisée sur le disque, défaite en cas d'échec
+type fileStep struct {
+	from string // Fichier d'origine (vide pour une copie)
+	to   string // Fichier créé
+}
+
+// FileOperationService déplace, copie et renomme les photos sur le disque en tenant l'index à jour
+type FileOperationService struct{}
+
+// NewFileOperationService crée une nouvelle instance de FileOperationService
+func NewFileOperationService() *FileOperationService {
+	return &FileOperationService{}
+}
+
+// MovePictures déplace des photos (et leurs sidecars XMP) dans un dossier
+// Les tags, albums, zones de visage et l'historique de consultation suivent les photos
+func (fs *FileOperationService) MovePictures(picturePaths []string, folderPath string, options FileOperationOptions) (*FileOperationResult, error) {
+	return fs.transferPictures(picturePaths, folderPath, options, false)
+}
+
+// CopyPictures copie des photos (et leurs sidecars XMP) dans un dossier
+// Les copies sont indexées avec les tags et les métadonnées des originaux
+func (fs *FileOperationService) CopyPictures(picturePaths []string, folderPath string, options FileOperationOptions) (*FileOperationResult, error) {
+	return fs.transferPictures(picturePaths, folderPath, options, true)
+}
+
+// transferPictures déplace ou copie des photos dans un dossier en gardant leur nom
+func (fs *FileOperationService) transferPictures(picturePaths []string, folderPath string, options FileOperationOptions, copying bool) (*FileOperationResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	if !filepath.IsAbs(folderPath) {
+		return nil, fmt.Errorf("destination folder must be an absolute path: %s", folderPath)
+	}
+	folderPath = filepath.Clean(folderPath)
+	if info, err := os.Stat(folderPath); err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("destination is not a directory: %s", folderPath)
+	}
+
+	pictures, err := loadFileOperationPictures(picturePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]string, len(pictures))
+	for i, picture := range pictures {
+		targets[i] = filepath.Join(folderPath, filepath.Base(picture.Path))
+	}
+
+	if !options.Preview {
+		if err := os.MkdirAll(folderPath, 0755); err != nil {
+			return nil, fmt.Errorf("cannot create destination folder: %w", err)
+		}
+	}
+	return runFileOperation(pictures, targets, options, copying)
+}
+
+// RenamePictures renomme des photos dans leur dossier selon un modèle,
+// ex: "{date:2006-01-02}_{camera}_{seq:04}{ext}"
+//
+// Marqueurs: {date:format} (date de prise de vue, format Go, "2006-01-02" par défaut), {camera} (appareil EXIF),
+// {seq:largeur} (numéro à partir de 1, dans l'ordre de prise de vue), {name} (nom d'origine sans extension), {ext}
+func (fs *FileOperationService) RenamePictures(picturePaths []string, pattern string, options FileOperationOptions) (*FileOperationResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("rename pattern cannot be empty")
+	}
+
+	pictures, err := loadFileOperationPictures(picturePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	// La numérotation suit l'ordre de prise de vue
+	sort.SliceStable(pictures, func(i, j int) bool {
+		if !pictures[i].CreatedAt.Equal(pictures[j].CreatedAt) {
+			return pictures[i].CreatedAt.Before(pictures[j].CreatedAt)
+		}
+		return pictures[i].Path < pictures[j].Path
+	})
+
+	targets := make([]string, len(pictures))
+	for i, picture := range pictures {
+		name, err := expandRenamePattern(pattern, picture, i+1)
+		if err != nil {
+			return nil, err
+		}
+		targets[i] = filepath.Join(filepath.Dir(picture.Path), name)
+	}
+
+	return runFileOperation(pictures, targets, options, false)
+}
+
+// renameTokenPattern reconnaît les marqueurs {nom} et {nom:argument} d'un modèle de renommage
+var renameTokenPattern = regexp.MustCompile(`\{(\w+)(?::([^}]*))?\}`)
+
+// expandRenamePattern calcule le nom de fichier d'une photo à partir d'un modèle
+func expandRenamePattern(pattern string, picture models.Picture, seq int) (string, error) {
+	var expandErr error
+	name := renameTokenPattern.ReplaceAllStringFunc(pattern, func(token string) string {
+		match := renameTokenPattern.FindStringSubmatch(token)
+		key, arg := match[1], match[2]
+
+		switch key {
+		case "date":
+			if arg == "" {
+				arg = "2006-01-02"
+			}
+			return sanitizeFileName(picture.CreatedAt.Format(arg))
+		case "camera":
+			camera := ""
+			if exif, err := readExif(picture.Path); err == nil {
+				camera = exif.Camera()
+			}
+			if camera == "" {
+				return "unknown"
+			}
+			return sanitizeFileName(strings.Join(strings.Fields(camera), "-"))
+		case "seq":
+			width := 0
+			if arg != "" {
+				n, err := strconv.Atoi(arg)
+				if err != nil || n < 0 || n > 12 {
+					expandErr = fmt.Errorf("invalid sequence width: %s", arg)
+					return ""
+				}
+				width = n
+			}
+			return fmt.Sprintf("%0*d", width, seq)
+		case "name":
+			base := filepath.Base(picture.Path)
+			return strings.TrimSuffix(base, filepath.Ext(base))
+		case "ext":
+			return filepath.Ext(picture.Path)
+		default:
+			expandErr = fmt.Errorf("unknown pattern token: %s", token)
+			return ""
+		}
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("pattern must produce a file name: %q", name)
+	}
+	return name, nil
+}
+
+// sanitizeFileName remplace les caractères interdits dans un nom de fichier
+func sanitizeFileName(value string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '-'
+		}
+		return r
+	}, value)
+}
+
+// loadFileOperationPictures charge les photos indexées dans l'ordre donné (sans doublons)
+// Chaque photo doit être indexée et présente sur le disque
+func loadFileOperationPictures(picturePaths []string) ([]models.Picture, error) {
+	var paths []string
+	seen := make(map[string]bool, len(picturePaths))
+	for _, path := range picturePaths {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no pictures selected")
+	}
+
+	byPath := make(map[string]models.Picture, len(paths))
+	for start := 0; start < len(paths); start += bulkTagBatchSize {
+		end := min(start+bulkTagBatchSize, len(paths))
+		var batch []models.Picture
+		if err := database.DB.Select("path", "filename", "created_at").Where("path IN ?", paths[start:end]).Find(&batch).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+		}
+		for _, picture := range batch {
+			byPath[picture.Path] = picture
+		}
+	}
+
+	pictures := make([]models.Picture, 0, len(paths))
+	for _, path := range paths {
+		picture, ok := byPath[path]
+		if !ok {
+			return nil, fmt.Errorf("picture not found: %s", path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("picture file not found: %s", path)
+		}
+		pictures = append(pictures, picture)
+	}
+	return pictures, nil
+}
+
+// sidecarTargetPath retourne le chemin du sidecar XMP d'une photo déplacée vers target
+// La convention de nommage est conservée (photo.jpg.xmp ou photo.xmp)
+func sidecarTargetPath(sidecar string, source string, target string) string {
+	if strings.EqualFold(sidecar, source+filepath.Ext(sidecar)) {
+		return target + filepath.Ext(sidecar)
+	}
+	return strings.TrimSuffix(target, filepath.Ext(target)) + filepath.Ext(sidecar)
+}
+
+// collisionTarget retourne le n-ième nom de repli d'un fichier cible (photo_2.jpg, photo_3.jpg...)
+func collisionTarget(target string, n int) string {
+	ext := filepath.Ext(target)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(target, ext), n, ext)
+}
+
+// runFileOperation planifie puis réalise le déplacement (ou la copie) des photos vers leurs cibles
+func runFileOperation(pictures []models.Picture, targets []string, options FileOperationOptions, copying bool) (*FileOperationResult, error) {
+	switch options.Collision {
+	case "":
+		options.Collision = CollisionFail
+	case CollisionFail, CollisionSkip, CollisionRename:
+	default:
+		return nil, fmt.Errorf("invalid collision policy: %s", options.Collision)
+	}
+
+	sidecars := make([]string, len(pictures))
+	for i, picture := range pictures {
+		sidecars[i], _ = findXMPSidecar(picture.Path)
+	}
+
+	// Une photo laissée en place ne libère pas son chemin: replanifier tant que des photos sont abandonnées
+	var result *FileOperationResult
+	var files []plannedFile
+	kept := make(map[string]bool)
+	for {
+		var err error
+		result, files, err = planFileOperation(pictures, sidecars, targets, options.Collision, copying, kept)
+		if err != nil {
+			return nil, err
+		}
+
+		changed := false
+		for _, item := range result.Items {
+			if item.Skipped && !kept[item.Source] {
+				kept[item.Source] = true
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	result.Preview = options.Preview
+	if options.Preview || result.Processed == 0 {
+		return result, nil
+	}
+
+	steps, err := applyFileSteps(files, copying)
+	if err != nil {
+		rollbackFileSteps(steps)
+		return nil, err
+	}
+
+	var moved []FileOperationItem
+	for _, item := range result.Items {
+		if !item.Skipped {
+			moved = append(moved, item)
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if copying {
+			return copyPictureRows(tx, moved)
+		}
+		return renamePictureRows(tx, moved)
+	})
+	if err != nil {
+		// L'index n'a pas changé: remettre les fichiers en place
+		rollbackFileSteps(steps)
+		return nil, err
+	}
+
+	for _, item := range moved {
+		suggestionIndex.invalidate(item.Source, item.Target)
+	}
+	return result, nil
+}
+
+// planFileOperation calcule la cible de chaque photo et de son sidecar en appliquant la politique de collision
+// kept contient les photos laissées en place lors d'une planification précédente (leur chemin reste occupé)
+func planFileOperation(pictures []models.Picture, sidecars []string, targets []string, policy CollisionPolicy, copying bool, kept map[string]bool) (*FileOperationResult, []plannedFile, error) {
+	// Chemins libérés par l'opération (photos et sidecars déplacés)
+	vacated := make(map[string]bool)
+	if !copying {
+		for i, picture := range pictures {
+			if !kept[picture.Path] && targets[i] != picture.Path {
+				vacated[picture.Path] = true
+				if sidecars[i] != "" {
+					vacated[sidecars[i]] = true
+				}
+			}
+		}
+	}
+
+	claimed := make(map[string]bool)
+	occupied := func(path string) (bool, error) {
+		if claimed[path] {
+			return true, nil
+		}
+		if vacated[path] {
+			return false, nil
+		}
+		if _, err := os.Lstat(path); err == nil {
+			return true, nil
+		}
+		var count int64
+		if err := database.DB.Model(&models.Picture{}).Where("path = ?", path).Count(&count).Error; err != nil {
+			return false, fmt.Errorf("cannot check target: %w", err)
+		}
+		return count > 0, nil
+	}
+
+	result := &FileOperationResult{Items: []FileOperationItem{}}
+	var files []plannedFile
+	for i, picture := range pictures {
+		item := FileOperationItem{Source: picture.Path, Target: targets[i]}
+
+		switch {
+		case targets[i] == picture.Path:
+			item.Skipped, item.Reason = true, "unchanged"
+		case kept[picture.Path]:
+			item.Skipped, item.Reason = true, "target already exists"
+		}
+
+		sidecarTarget := ""
+		for n := 2; !item.Skipped; n++ {
+			busy, err := occupied(item.Target)
+			if err != nil {
+				return nil, nil, err
+			}
+			if sidecars[i] != "" {
+				sidecarTarget = sidecarTargetPath(sidecars[i], picture.Path, item.Target)
+				if !busy {
+					if busy, err = occupied(sidecarTarget); err != nil {
+						return nil, nil, err
+					}
+				}
+			}
+			if !busy {
+				break
+			}
+
+			switch policy {
+			case CollisionSkip:
+				item.Skipped, item.Reason = true, "target already exists"
+			case CollisionRename:
+				item.Target = collisionTarget(targets[i], n)
+			default:
+				return nil, nil, fmt.Errorf("target already exists: %s", item.Target)
+			}
+		}
+
+		if item.Skipped {
+			result.Skipped++
+		} else {
+			result.Processed++
+			claimed[item.Target] = true
+			files = append(files, plannedFile{picture.Path, item.Target})
+			if sidecars[i] != "" {
+				claimed[sidecarTarget] = true
+				files = append(files, plannedFile{sidecars[i], sidecarTarget})
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, files, nil
+}
+
+// applyFileSteps copie les fichiers, ou les déplace en deux temps via des noms temporaires
+// (les échanges de noms entre photos d'une même opération sont ainsi possibles)
+// Retourne les étapes réalisées, à défaire en cas d'erreur
+func applyFileSteps(files []plannedFile, copying bool) ([]fileStep, error) {
+	var steps []fileStep
+
+	if copying {
+		for _, file := range files {
+			if err := copyFile(file.source, file.target); err != nil {
+				return steps, fmt.Errorf("cannot copy %s: %w", filepath.Base(file.source), err)
+			}
+			steps = append(steps, fileStep{to: file.target})
+		}
+		return steps, nil
+	}
+
+	temporaries := make([]string, len(files))
+	for i, file := range files {
+		temporaries[i] = filepath.Join(filepath.Dir(file.target), fmt.Sprintf(".%s.%d.easygallery-tmp", filepath.Base(file.target), i))
+		if err := moveFile(file.source, temporaries[i]); err != nil {
+			return steps, fmt.Errorf("cannot move %s: %w", filepath.Base(file.source), err)
+		}
+		steps = append(steps, fileStep{from: file.source, to: temporaries[i]})
+	}
+	for i, file := range files {
+		if err := moveFile(temporaries[i], file.target); err != nil {
+			return steps, fmt.Errorf("cannot move %s: %w", filepath.Base(file.source), err)
+		}
+		steps = append(steps, fileStep{from: temporaries[i], to: file.target})
+	}
+	return steps, nil
+}
+
+// rollbackFileSteps défait les étapes réalisées, de la plus récente à la plus ancienne
+func rollbackFileSteps(steps []fileStep) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		var err error
+		if step.from == "" {
+			err = os.Remove(step.to)
+		} else {
+			err = moveFile(step.to, step.from)
+		}
+		if err != nil {
+			fmt.Printf("Warning: cannot roll back %s: %v\n", step.to, err)
+		}
+	}
+}
+
+// renamePictureRows reporte le nouveau chemin des photos déplacées dans toutes les tables qui les référencent
+// Les chemins passent par des valeurs temporaires: les échanges de noms ne heurtent pas les clés primaires
+func renamePictureRows(tx *gorm.DB, items []FileOperationItem) error {
+	temporary := func(i int) string { return fmt.Sprintf("\x00moving/%d", i) }
+
+	for pass := 0; pass < 2; pass++ {
+		for i, item := range items {
+			from, to := item.Source, temporary(i)
+			if pass == 1 {
+				from, to = temporary(i), item.Target
+			}
+			for _, ref := range picturePathColumns {
+				if err := tx.Exec("UPDATE "+ref.table+" SET "+ref.column+" = ? WHERE "+ref.column+" = ?", to, from).Error; err != nil {
+					return fmt.Errorf("cannot update %s: %w", ref.table, err)
+				}
+			}
+		}
+	}
+
+	if err := rewriteHistoryPaths(tx, historyRenames(items, false)); err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, item := range items {
+		err := tx.Model(&models.Picture{}).Where("path = ?", item.Target).Update("filename", filepath.Base(item.Target)).Error
+		if err != nil {
+			return fmt.Errorf("cannot update picture: %w", err)
+		}
+		if err := tx.Delete(&models.ExcludedPicture{}, "path = ?", item.Target).Error; err != nil {
+			return fmt.Errorf("cannot include picture: %w", err)
+		}
+		paths = append(paths, item.Source, item.Target)
+	}
+	return refreshSearchIndex(tx, paths...)
+}
+
+// historyRenames retourne les chemins à reporter dans l'historique après une opération sur les fichiers:
+// les photos déplacées suivent leur nouveau chemin, et les photos qui occupaient auparavant une cible sont oubliées
+func historyRenames(items []FileOperationItem, copying bool) map[string]string {
+	renames := make(map[string]string, 2*len(items))
+	for _, item := range items {
+		renames[item.Target] = ""
+	}
+	if !copying {
+		for _, item := range items {
+			renames[item.Source] = item.Target
+		}
+	}
+	return renames
+}
+
+// copyPictureRows indexe les copies avec les métadonnées et les tags des originaux
+// Les lignes sont recopiées en SQL: les valeurs (dates comprises) gardent exactement leur forme enregistrée
+func copyPictureRows(tx *gorm.DB, items []FileOperationItem) error {
+	columnTypes, err := tx.Migrator().ColumnTypes(&models.Picture{})
+	if err != nil {
+		return fmt.Errorf("cannot fetch picture columns: %w", err)
+	}
+	columns := make([]string, len(columnTypes))
+	selected := make([]string, len(columnTypes))
+	for i, column := range columnTypes {
+		columns[i] = `"` + column.Name() + `"`
+		switch column.Name() {
+		case "path", "filename":
+			selected[i] = "@" + column.Name()
+		default:
+			selected[i] = columns[i]
+		}
+	}
+	copyQuery := fmt.Sprintf("INSERT INTO pictures (%s) SELECT %s FROM pictures WHERE path = @source",
+		strings.Join(columns, ", "), strings.Join(selected, ", "))
+
+	var paths []string
+	for _, item := range items {
+		if err := tx.Delete(&models.Picture{}, "path = ?", item.Target).Error; err != nil {
+			return fmt.Errorf("cannot copy picture: %w", err)
+		}
+		result := tx.Exec(copyQuery, map[string]interface{}{
+			"path":     item.Target,
+			"filename": filepath.Base(item.Target),
+			"source":   item.Source,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("cannot copy picture: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("picture not found in database: %s", item.Source)
+		}
+
+		err := tx.Exec("INSERT INTO picture_tags (picture_path, tag_name, created_at) SELECT ?, tag_name, ? FROM picture_tags WHERE picture_path = ?",
+			item.Target, time.Now(), item.Source).Error
+		if err != nil {
+			return fmt.Errorf("cannot copy tags: %w", err)
+		}
+
+		// Un fichier arrivé à l'emplacement d'une photo retirée de l'index y est de nouveau indexé
+		if err := tx.Delete(&models.ExcludedPicture{}, "path = ?", item.Target).Error; err != nil {
+			return fmt.Errorf("cannot include picture: %w", err)
+		}
+		paths = append(paths, item.Target)
+	}
+	if err := rewriteHistoryPaths(tx, historyRenames(items, true)); err != nil {
+		return err
+	}
+	return refreshSearchIndex(tx, paths...)
+}
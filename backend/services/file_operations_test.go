@@ -0,0 +1,127 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"easygallery/backend/database"
+)
+
+func TestCopyPicturesKeepsStoredDates(t *testing.T) {
+	indexer, folder, paths := setupTestLibrary(t, 3)
+	target := filepath.Join(folder, "copies")
+
+	if _, err := NewFileOperationService().CopyPictures(paths[:2], target, FileOperationOptions{}); err != nil {
+		t.Fatalf("cannot copy pictures: %v", err)
+	}
+
+	// La copie garde la forme texte des dates de l'original
+	var dates []struct {
+		Original string
+		Copy     string
+	}
+	err := database.DB.Raw(`SELECT CAST(o.created_at AS TEXT) AS original, CAST(c.created_at AS TEXT) AS copy FROM pictures o
+		JOIN pictures c ON c.filename = o.filename AND c.path <> o.path WHERE c.path LIKE ?`, target+"%").Scan(&dates).Error
+	if err != nil {
+		t.Fatalf("cannot fetch dates: %v", err)
+	}
+	if len(dates) != 2 {
+		t.Fatalf("got %d copies, want 2", len(dates))
+	}
+	for _, date := range dates {
+		if date.Original != date.Copy {
+			t.Errorf("copy created_at %q, original %q", date.Copy, date.Original)
+		}
+	}
+
+	for _, sort := range []SortField{SortByCaptureDate, SortByIndexedDate} {
+		if walked := walkAllPictures(t, indexer, sort, true); len(walked) != 5 {
+			t.Errorf("sort %s: got %d pictures, want 5", sort, len(walked))
+		}
+	}
+}
+
+func TestUndoAfterMoveFollowsMovedPicture(t *testing.T) {
+	_, folder, paths := setupTestLibrary(t, 2)
+	target := filepath.Join(folder, "out")
+
+	if err := NewCullingService().SetRating(paths[0], 3); err != nil {
+		t.Fatalf("cannot rate picture: %v", err)
+	}
+	result, err := NewFileOperationService().MovePictures(paths[:1], target, FileOperationOptions{})
+	if err != nil {
+		t.Fatalf("cannot move picture: %v", err)
+	}
+	moved := result.Items[0].Target
+
+	if _, err := NewHistoryService().Undo(); err != nil {
+		t.Fatalf("cannot undo: %v", err)
+	}
+
+	if n := countRows(t, "pictures", "path = ?", paths[0]); n != 0 {
+		t.Errorf("undo recreated a picture at the old path")
+	}
+	if n := countRows(t, "pictures", "path = ? AND rating = 0", moved); n != 1 {
+		t.Errorf("undo did not reset the rating of the moved picture")
+	}
+}
+
+func TestMovePicturesRollsBackOnFailedStep(t *testing.T) {
+	_, folder, paths := setupTestLibrary(t, 3)
+	target := filepath.Join(folder, "out")
+	before := dumpTable(t, "pictures")
+
+	// Le nom temporaire de la dernière photo est déjà pris: son déplacement échoue
+	writeTestPicture(t, filepath.Join(target, ".photo_02.jpg.2.easygallery-tmp"), 9)
+
+	if _, err := NewFileOperationService().MovePictures(paths, target, FileOperationOptions{}); err == nil {
+		t.Fatalf("move should fail")
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s not put back: %v", filepath.Base(path), err)
+		}
+	}
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		t.Fatalf("cannot read target folder: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("target folder holds %d files, want only the blocking file", len(entries))
+	}
+	if after := dumpTable(t, "pictures"); strings.Join(after, "\n") != strings.Join(before, "\n") {
+		t.Errorf("index changed by a failed move")
+	}
+}
+
+func TestCopyPicturesRollsBackOnFailedStep(t *testing.T) {
+	_, folder, paths := setupTestLibrary(t, 3)
+	target := filepath.Join(folder, "copies")
+	before := dumpTable(t, "pictures")
+
+	// L'indexation de la deuxième copie échoue
+	err := database.DB.Exec(`CREATE TRIGGER fail_copy BEFORE INSERT ON pictures
+		WHEN NEW.filename = 'photo_01.jpg' BEGIN SELECT RAISE(ABORT, 'copy failed'); END`).Error
+	if err != nil {
+		t.Fatalf("cannot create trigger: %v", err)
+	}
+
+	if _, err := NewFileOperationService().CopyPictures(paths, target, FileOperationOptions{}); err == nil {
+		t.Fatalf("copy should fail")
+	}
+
+	if entries, err := os.ReadDir(target); err == nil && len(entries) != 0 {
+		t.Errorf("target folder holds %d files after a failed copy", len(entries))
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("original %s removed: %v", filepath.Base(path), err)
+		}
+	}
+	if after := dumpTable(t, "pictures"); strings.Join(after, "\n") != strings.Join(before, "\n") {
+		t.Errorf("index changed by a failed copy")
+	}
+}
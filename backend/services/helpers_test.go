@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"easygallery/backend/database"
+
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB initialise une base de données vierge dans un dossier temporaire
+// Retourne le dossier de données
+func setupTestDB(t *testing.T) string {
+	t.Helper()
+
+	// La corbeille freedesktop.org de l'utilisateur n'est pas touchée par les tests
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	dataDir := t.TempDir()
+	if err := database.Init(dataDir); err != nil {
+		t.Fatalf("cannot initialize database: %v", err)
+	}
+	database.DB.Logger = logger.Default.LogMode(logger.Silent)
+
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+		database.DB = nil
+	})
+	return dataDir
+}
+
+// testJPEG encode une petite image JPEG dont le contenu dépend de seed
+func testJPEG(t *testing.T, seed int) []byte {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, 4+seed%5, 3+seed/5))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(seed * 37)
+	}
+	img.Set(0, 0, color.Gray{Y: uint8(seed)})
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("cannot encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// writeTestPicture écrit une image de test (contenu propre à seed) et retourne son chemin
+func writeTestPicture(t *testing.T, path string, seed int) string {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("cannot create folder: %v", err)
+	}
+	if err := os.WriteFile(path, testJPEG(t, seed), 0644); err != nil {
+		t.Fatalf("cannot write test picture: %v", err)
+	}
+	return path
+}
+
+// setupTestLibrary crée un dossier surveillé contenant count photos distinctes et l'indexe
+// Retourne l'indexeur, le dossier et les chemins des photos
+func setupTestLibrary(t *testing.T, count int) (*Indexer, string, []string) {
+	t.Helper()
+
+	dataDir := setupTestDB(t)
+	folder := filepath.Join(t.TempDir(), "library")
+
+	var paths []string
+	for i := 0; i < count; i++ {
+		paths = append(paths, writeTestPicture(t, filepath.Join(folder, fmt.Sprintf("photo_%02d.jpg", i)), i+1))
+	}
+
+	indexer := NewIndexer(dataDir)
+	if err := indexer.AddWatchedFolder(folder, "", false); err != nil {
+		t.Fatalf("cannot add watched folder: %v", err)
+	}
+	if _, err := indexer.IndexWatchedFolder(folder, nil); err != nil {
+		t.Fatalf("cannot index folder: %v", err)
+	}
+	return indexer, folder, paths
+}
+
+// countRows compte les lignes d'une table qui vérifient une condition
+func countRows(t *testing.T, table string, where string, args ...interface{}) int64 {
+	t.Helper()
+
+	var count int64
+	if err := database.DB.Table(table).Where(where, args...).Count(&count).Error; err != nil {
+		t.Fatalf("cannot count %s: %v", table, err)
+	}
+	return count
+}
+
+// dumpTable retourne les lignes d'une table sous leur forme enregistrée (valeurs SQL littérales), triées
+func dumpTable(t *testing.T, table string) []string {
+	t.Helper()
+
+	var columns []string
+	if err := database.DB.Raw("SELECT name FROM pragma_table_info(?) ORDER BY cid", table).Scan(&columns).Error; err != nil {
+		t.Fatalf("cannot fetch columns of %s: %v", table, err)
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = `quote("` + column + `")`
+	}
+
+	var rows []string
+	query := fmt.Sprintf("SELECT %s AS row FROM %s ORDER BY row", strings.Join(quoted, " || '|' || "), table)
+	if err := database.DB.Raw(query).Scan(&rows).Error; err != nil {
+		t.Fatalf("cannot dump %s: %v", table, err)
+	}
+	return rows
+}
+
+// walkAllPictures parcourt toutes les pages d'un tri et retourne les chemins dans l'ordre
+// Le test échoue si la pagination ne se termine pas
+func walkAllPictures(t *testing.T, indexer *Indexer, sort SortField, descending bool) []string {
+	t.Helper()
+
+	var paths []string
+	page := PageRequest{Sort: sort, Descending: descending, Limit: 1}
+	for i := 0; ; i++ {
+		result, err := indexer.GetIndexedPictures(page)
+		if err != nil {
+			t.Fatalf("cannot fetch pictures: %v", err)
+		}
+		for _, picture := range result.Pictures {
+			paths = append(paths, picture.Path)
+		}
+		if result.NextCursor == "" {
+			return paths
+		}
+		if i > int(result.Total)+1 {
+			t.Fatalf("pagination sorted by %s does not terminate: %v", sort, paths)
+		}
+		page.Cursor = result.NextCursor
+	}
+}
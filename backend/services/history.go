@@ -0,0 +1,586 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// maxHistorySize borne le nombre d'opérations conservées dans l'historique
+const maxHistorySize = 200
+
+// historyTableKeys liste les tables suivies par l'historique et leurs colonnes de clé primaire
+var historyTableKeys = map[string][]string{
+	"pictures":          {"path"},
+	"tags":              {"name"},
+	"picture_tags":      {"picture_path", "tag_name"},
+	"tag_aliases":       {"key"},
+	"smart_albums":      {"id"},
+	"albums":            {"id"},
+	"album_pictures":    {"album_id", "picture_path"},
+	"face_regions":      {"id"},
+	"excluded_pictures": {"path"},
+}
+
+// historyScope désigne les lignes d'une table susceptibles d'être modifiées par une opération
+// Le filtre est évalué avant et après l'opération: il ne doit pas dépendre des données modifiées
+type historyScope struct {
+	table string
+	where string
+	args  []interface{}
+}
+
+// historyScopesIn construit des portées "column IN values" découpées en lots (limite de variables SQLite)
+func historyScopesIn(table string, column string, values []string) []historyScope {
+	var scopes []historyScope
+	for start := 0; start < len(values); start += searchIndexBatchSize {
+		end := min(start+searchIndexBatchSize, len(values))
+		scopes = append(scopes, historyScope{table, column + " IN ?", []interface{}{values[start:end]}})
+	}
+	return scopes
+}
+
+// tagHistoryScopes couvre les lignes touchées par une opération sur des tags nommés:
+// les tags et leurs enfants, leurs associations, leurs alias, les zones de visage et les albums intelligents
+func tagHistoryScopes(names []string) []historyScope {
+	scopes := []historyScope{
+		{"tags", "name IN ? OR parent_name IN ?", []interface{}{names, names}},
+		{"tag_aliases", "tag_name IN ?", []interface{}{names}},
+		{"face_regions", "tag_name IN ?", []interface{}{names}},
+		{"smart_albums", "1 = 1", nil},
+	}
+	return append(scopes, historyScopesIn("picture_tags", "tag_name", names)...)
+}
+
+// rowChange représente l'état d'une ligne avant et après une opération (nil = ligne absente)
+type rowChange struct {
+	Table  string                 `json:"table"`
+	Before map[string]interface{} `json:"before,omitempty"`
+	After  map[string]interface{} `json:"after,omitempty"`
+}
+
+// historyRow est une ligne capturée, avec sa clé et sa forme encodée pour la comparaison
+type historyRow struct {
+	table   string
+	values  map[string]interface{}
+	encoded string
+}
+
+// sqliteTimeFormat est le format texte dans lequel le pilote SQLite enregistre les dates
+// Les comparaisons et tris sur les dates (curseurs de pagination, filtres) reposent sur ce format
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// normalizeHistoryValues convertit les valeurs lues en valeurs réinsérables à l'identique
+// Les dates sont conservées sous leur forme texte SQLite
+func normalizeHistoryValues(row map[string]interface{}) {
+	for column, value := range row {
+		if t, ok := value.(time.Time); ok {
+			row[column] = t.Format(sqliteTimeFormat)
+		}
+	}
+}
+
+// historyRowKey identifie une ligne par sa table et sa clé primaire
+func historyRowKey(table string, row map[string]interface{}) string {
+	var key strings.Builder
+	key.WriteString(table)
+	for _, column := range historyTableKeys[table] {
+		fmt.Fprintf(&key, "\x00%v", row[column])
+	}
+	return key.String()
+}
+
+// snapshotHistory capture les lignes couvertes par les portées, indexées par table et clé
+func snapshotHistory(tx *gorm.DB, scopes []historyScope) (map[string]historyRow, error) {
+	snapshot := make(map[string]historyRow)
+	for _, scope := range scopes {
+		if _, ok := historyTableKeys[scope.table]; !ok {
+			return nil, fmt.Errorf("table %s is not tracked by history", scope.table)
+		}
+
+		var rows []map[string]interface{}
+		if err := tx.Table(scope.table).Where(scope.where, scope.args...).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("cannot snapshot %s: %w", scope.table, err)
+		}
+
+		for _, row := range rows {
+			normalizeHistoryValues(row)
+			encoded, err := json.Marshal(row)
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode %s row: %w", scope.table, err)
+			}
+			snapshot[historyRowKey(scope.table, row)] = historyRow{scope.table, row, string(encoded)}
+		}
+	}
+	return snapshot, nil
+}
+
+// completeHistorySnapshot relit par leur clé les lignes de before absentes de after
+func completeHistorySnapshot(tx *gorm.DB, before, after map[string]historyRow) error {
+	for key, row := range before {
+		if _, ok := after[key]; ok {
+			continue
+		}
+
+		columns := historyTableKeys[row.table]
+		conditions := make([]string, len(columns))
+		args := make([]interface{}, len(columns))
+		for i, column := range columns {
+			conditions[i] = `"` + column + `" = ?`
+			args[i] = row.values[column]
+		}
+
+		var rows []map[string]interface{}
+		if err := tx.Table(row.table).Where(strings.Join(conditions, " AND "), args...).Find(&rows).Error; err != nil {
+			return fmt.Errorf("cannot snapshot %s: %w", row.table, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		normalizeHistoryValues(rows[0])
+		encoded, err := json.Marshal(rows[0])
+		if err != nil {
+			return fmt.Errorf("cannot encode %s row: %w", row.table, err)
+		}
+		after[key] = historyRow{row.table, rows[0], string(encoded)}
+	}
+	return nil
+}
+
+// diffHistory compare deux captures et retourne les lignes modifiées, dans un ordre stable
+func diffHistory(before, after map[string]historyRow) []rowChange {
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []rowChange
+	for _, key := range keys {
+		b, hadBefore := before[key]
+		a, hasAfter := after[key]
+		if hadBefore && hasAfter && b.encoded == a.encoded {
+			continue
+		}
+
+		change := rowChange{}
+		if hadBefore {
+			change.Table = b.table
+			change.Before = b.values
+		}
+		if hasAfter {
+			change.Table = a.table
+			change.After = a.values
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// recordOperation exécute une opération dans une transaction et l'enregistre dans l'historique
+// Les lignes couvertes par les portées sont capturées avant et après pour pouvoir annuler puis rétablir
+// Les sidecars XMP et les suggestions de tags des photos modifiées sont mis à jour une fois la transaction validée
+func recordOperation(description string, scopes []historyScope, fn func(tx *gorm.DB) error) error {
+	var changes []rowChange
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := snapshotHistory(tx, scopes)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		after, err := snapshotHistory(tx, scopes)
+		if err != nil {
+			return err
+		}
+
+		// Les lignes capturées avant l'opération peuvent être sorties des portées (ex: tag enfant déplacé)
+		if err := completeHistorySnapshot(tx, before, after); err != nil {
+			return err
+		}
+
+		changes = diffHistory(before, after)
+		if len(changes) == 0 {
+			return nil
+		}
+
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("cannot encode history: %w", err)
+		}
+
+		// Une nouvelle opération rend impossible de rétablir les opérations annulées
+		if err := tx.Where("undone = ?", true).Delete(&models.Operation{}).Error; err != nil {
+			return fmt.Errorf("cannot clear redo history: %w", err)
+		}
+
+		operation := models.Operation{
+			Description: description,
+			Changes:     string(data),
+		}
+		if err := tx.Create(&operation).Error; err != nil {
+			return fmt.Errorf("cannot record operation: %w", err)
+		}
+
+		// Historique borné: les opérations les plus anciennes sont oubliées
+		return tx.Where("id <= ?", int64(operation.ID)-maxHistorySize).Delete(&models.Operation{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	afterHistoryCommit(changes)
+	return nil
+}
+
+// decodeOperationChanges décode les lignes modifiées d'une opération
+// Les nombres sont conservés tels quels (json.Number) pour ne pas perdre de précision
+func decodeOperationChanges(operation models.Operation) ([]rowChange, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(operation.Changes)))
+	decoder.UseNumber()
+
+	var changes []rowChange
+	if err := decoder.Decode(&changes); err != nil {
+		return nil, fmt.Errorf("invalid history for operation %d: %w", operation.ID, err)
+	}
+	return changes, nil
+}
+
+// historyDateColumns retourne les colonnes de type date d'une table suivie
+func historyDateColumns(tx *gorm.DB, table string) (map[string]bool, error) {
+	columnTypes, err := tx.Migrator().ColumnTypes(table)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch %s columns: %w", table, err)
+	}
+	dateColumns := make(map[string]bool)
+	for _, column := range columnTypes {
+		if strings.EqualFold(column.DatabaseTypeName(), "datetime") {
+			dateColumns[column.Name()] = true
+		}
+	}
+	return dateColumns, nil
+}
+
+// setHistoryRow remplace une ligne par l'état donné (nil = supprimer la ligne)
+// dateColumns désigne les colonnes de type date de la table (voir historyDateColumns)
+func setHistoryRow(tx *gorm.DB, table string, key map[string]interface{}, state map[string]interface{}, dateColumns map[string]bool) error {
+	columns := historyTableKeys[table]
+	if columns == nil {
+		return fmt.Errorf("table %s is not tracked by history", table)
+	}
+
+	conditions := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		conditions[i] = `"` + column + `" = ?`
+		args[i] = key[column]
+	}
+	if err := tx.Exec("DELETE FROM "+table+" WHERE "+strings.Join(conditions, " AND "), args...).Error; err != nil {
+		return fmt.Errorf("cannot restore %s: %w", table, err)
+	}
+
+	if state == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(state))
+	for column := range state {
+		names = append(names, column)
+	}
+	sort.Strings(names)
+
+	placeholders := make([]string, len(names))
+	values := make([]interface{}, len(names))
+	quoted := make([]string, len(names))
+	for i, column := range names {
+		quoted[i] = `"` + column + `"`
+		placeholders[i] = "?"
+		values[i] = historyValue(state[column], dateColumns[column])
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+	if err := tx.Exec(query, values...).Error; err != nil {
+		return fmt.Errorf("cannot restore %s: %w", table, err)
+	}
+	return nil
+}
+
+// historyValue convertit une valeur décodée en valeur SQL (les nombres entiers restent entiers)
+// Dans une colonne de date, les valeurs enregistrées au format RFC 3339 par les versions précédentes
+// sont remises au format SQLite; les textes des autres colonnes sont restaurés tels quels
+func historyValue(value interface{}, date bool) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	case string:
+		if date {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t.Format(sqliteTimeFormat)
+			}
+		}
+	}
+	return value
+}
+
+// historyPathColumns donne, pour les tables suivies qui référencent une photo, la colonne contenant son chemin
+var historyPathColumns = map[string]string{
+	"pictures":          "path",
+	"picture_tags":      "picture_path",
+	"album_pictures":    "picture_path",
+	"albums":            "cover_path",
+	"face_regions":      "picture_path",
+	"excluded_pictures": "path",
+}
+
+// rewriteHistoryPaths reporte dans l'historique les nouveaux chemins des photos déplacées (ancien chemin → nouveau)
+// Un nouveau chemin vide oublie la photo: ses lignes sont retirées des opérations (la couverture d'album est vidée),
+// pour qu'une annulation ne recrée pas de ligne à un chemin abandonné. Les opérations devenues vides sont supprimées
+func rewriteHistoryPaths(tx *gorm.DB, renames map[string]string) error {
+	if len(renames) == 0 {
+		return nil
+	}
+
+	var operations []models.Operation
+	if err := tx.Find(&operations).Error; err != nil {
+		return fmt.Errorf("cannot fetch history: %w", err)
+	}
+
+	for _, operation := range operations {
+		changes, err := decodeOperationChanges(operation)
+		if err != nil {
+			return err
+		}
+
+		modified := false
+		kept := changes[:0]
+		for _, change := range changes {
+			column, ok := historyPathColumns[change.Table]
+			dropped := false
+			for _, state := range []map[string]interface{}{change.Before, change.After} {
+				path, isString := state[column].(string)
+				if !ok || !isString {
+					continue
+				}
+				target, renamed := renames[path]
+				if !renamed {
+					continue
+				}
+
+				modified = true
+				switch {
+				case target != "":
+					state[column] = target
+					if change.Table == "pictures" {
+						state["filename"] = filepath.Base(target)
+					}
+				case change.Table == "albums":
+					state[column] = ""
+				default:
+					dropped = true
+				}
+			}
+			if !dropped {
+				kept = append(kept, change)
+			}
+		}
+		if !modified {
+			continue
+		}
+
+		if len(kept) == 0 {
+			if err := tx.Delete(&operation).Error; err != nil {
+				return fmt.Errorf("cannot update history: %w", err)
+			}
+			continue
+		}
+		data, err := json.Marshal(kept)
+		if err != nil {
+			return fmt.Errorf("cannot encode history: %w", err)
+		}
+		if err := tx.Model(&operation).Update("changes", string(data)).Error; err != nil {
+			return fmt.Errorf("cannot update history: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyOperation applique l'état avant (annulation) ou après (rétablissement) des lignes d'une opération
+// et rafraîchit l'index plein texte des photos concernées
+func applyOperation(tx *gorm.DB, operation models.Operation, undo bool) error {
+	changes, err := decodeOperationChanges(operation)
+	if err != nil {
+		return err
+	}
+
+	dateColumns := make(map[string]map[string]bool)
+	for _, change := range changes {
+		state, key := change.After, change.Before
+		if undo {
+			state, key = change.Before, change.After
+		}
+		if key == nil {
+			key = state
+		}
+
+		if _, ok := dateColumns[change.Table]; !ok {
+			if dateColumns[change.Table], err = historyDateColumns(tx, change.Table); err != nil {
+				return err
+			}
+		}
+		if err := setHistoryRow(tx, change.Table, key, state, dateColumns[change.Table]); err != nil {
+			return err
+		}
+	}
+
+	// Les tags restaurés depuis un historique antérieur à la forme normalisée des noms n'en ont pas
+	if err := fillTagNameKeys(tx); err != nil {
+		return err
+	}
+
+	return refreshSearchIndex(tx, changedPicturePaths(changes)...)
+}
+
+// changedPicturePaths retourne les photos dont les tags ou l'existence ont changé
+func changedPicturePaths(changes []rowChange) []string {
+	touched := make(map[string]bool)
+	var paths []string
+	for _, change := range changes {
+		row := change.Before
+		if row == nil {
+			row = change.After
+		}
+
+		var path interface{}
+		switch change.Table {
+		case "pictures":
+			path = row["path"]
+		case "picture_tags":
+			path = row["picture_path"]
+		}
+		if p, ok := path.(string); ok && !touched[p] {
+			touched[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// afterHistoryCommit met à jour ce qui dépend des lignes modifiées, une fois la transaction validée:
+// sidecars XMP et cache des suggestions de tags (qui ne doit pas être recalculé avec des données non validées)
+func afterHistoryCommit(changes []rowChange) {
+	suggestionIndex.invalidate(changedPicturePaths(changes)...)
+	syncXMPSidecars(changes)
+}
+
+// HistoryService gère l'annulation et le rétablissement des opérations enregistrées
+type HistoryService struct{}
+
+// NewHistoryService crée une nouvelle instance de HistoryService
+func NewHistoryService() *HistoryService {
+	return &HistoryService{}
+}
+
+// Undo annule la dernière opération effectuée
+// Retourne l'opération annulée, ou nil s'il n'y a rien à annuler
+func (hs *HistoryService) Undo() (*models.Operation, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var operation models.Operation
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("undone = ?", false).Order("id DESC").Limit(1).Find(&operation).Error; err != nil {
+			return fmt.Errorf("cannot fetch history: %w", err)
+		}
+		if operation.ID == 0 {
+			return nil
+		}
+
+		if err := applyOperation(tx, operation, true); err != nil {
+			return err
+		}
+
+		operation.Undone = true
+		return tx.Model(&operation).Update("undone", true).Error
+	})
+	if err != nil || operation.ID == 0 {
+		return nil, err
+	}
+
+	if changes, err := decodeOperationChanges(operation); err == nil {
+		afterHistoryCommit(changes)
+	}
+
+	return &operation, nil
+}
+
+// Redo rétablit la dernière opération annulée
+// Retourne l'opération rétablie, ou nil s'il n'y a rien à rétablir
+func (hs *HistoryService) Redo() (*models.Operation, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var operation models.Operation
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("undone = ?", true).Order("id ASC").Limit(1).Find(&operation).Error; err != nil {
+			return fmt.Errorf("cannot fetch history: %w", err)
+		}
+		if operation.ID == 0 {
+			return nil
+		}
+
+		if err := applyOperation(tx, operation, false); err != nil {
+			return err
+		}
+
+		operation.Undone = false
+		return tx.Model(&operation).Update("undone", false).Error
+	})
+	if err != nil || operation.ID == 0 {
+		return nil, err
+	}
+
+	if changes, err := decodeOperationChanges(operation); err == nil {
+		afterHistoryCommit(changes)
+	}
+
+	return &operation, nil
+}
+
+// GetHistory retourne les opérations de l'historique, les plus récentes d'abord
+func (hs *HistoryService) GetHistory() ([]models.Operation, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var operations []models.Operation
+	if err := database.DB.Order("id DESC").Find(&operations).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch history: %w", err)
+	}
+
+	return operations, nil
+}
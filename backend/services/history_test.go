@@ -0,0 +1,134 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"easygallery/backend/models"
+)
+
+func TestUndoDeleteKeepsPaginationTerminating(t *testing.T) {
+	indexer, _, paths := setupTestLibrary(t, 3)
+
+	if err := indexer.DeletePicture(paths[1], false); err != nil {
+		t.Fatalf("cannot delete picture: %v", err)
+	}
+	if _, err := NewHistoryService().Undo(); err != nil {
+		t.Fatalf("cannot undo: %v", err)
+	}
+
+	for _, sort := range []SortField{SortByCaptureDate, SortByIndexedDate, SortByFilename} {
+		for _, descending := range []bool{false, true} {
+			walked := walkAllPictures(t, indexer, sort, descending)
+			if len(walked) != len(paths) {
+				t.Errorf("sort %s (descending=%v): got %d pictures, want %d: %v", sort, descending, len(walked), len(paths), walked)
+			}
+		}
+	}
+}
+
+func TestHistoryValueConvertsLegacyDates(t *testing.T) {
+	if got := historyValue("2020-01-01T10:00:00Z", true); got != "2020-01-01 10:00:00+00:00" {
+		t.Errorf("legacy date converted to %v", got)
+	}
+	if got := historyValue("2020-01-01T10:00:00Z", false); got != "2020-01-01T10:00:00Z" {
+		t.Errorf("text column converted to %v", got)
+	}
+}
+
+func TestUndoKeepsTextShapedLikeDates(t *testing.T) {
+	indexer, _, paths := setupTestLibrary(t, 1)
+	captions := NewCaptionService()
+	stamp := "2024-05-01T10:00:00Z"
+
+	if _, err := NewTagService().CreateTag(stamp, models.TagTypeOther, "", ""); err != nil {
+		t.Fatalf("cannot create tag: %v", err)
+	}
+	if err := NewTagService().AddTagToPicture(paths[0], stamp); err != nil {
+		t.Fatalf("cannot tag picture: %v", err)
+	}
+	if err := captions.SetPictureCaption(paths[0], PictureCaption{Caption: stamp}); err != nil {
+		t.Fatalf("cannot set caption: %v", err)
+	}
+	if err := captions.SetPictureCaption(paths[0], PictureCaption{Caption: "Plage"}); err != nil {
+		t.Fatalf("cannot set caption: %v", err)
+	}
+	if err := indexer.DeletePicture(paths[0], false); err != nil {
+		t.Fatalf("cannot delete picture: %v", err)
+	}
+
+	history := NewHistoryService()
+	for i := 0; i < 2; i++ {
+		if _, err := history.Undo(); err != nil {
+			t.Fatalf("cannot undo: %v", err)
+		}
+	}
+
+	if caption, err := captions.GetPictureCaption(paths[0]); err != nil || caption.Caption != stamp {
+		t.Errorf("caption restored as %+v (err=%v), want %q", caption, err, stamp)
+	}
+	if n := countRows(t, "picture_tags", "picture_path = ? AND tag_name = ?", paths[0], stamp); n != 1 {
+		t.Errorf("tag association restored under another name")
+	}
+}
+
+func TestUndoRedoRestoresIdenticalRows(t *testing.T) {
+	indexer, _, paths := setupTestLibrary(t, 3)
+	tags := NewTagService()
+	history := NewHistoryService()
+
+	album, err := NewAlbumService().CreateAlbum("Vacances", "", 0)
+	if err != nil {
+		t.Fatalf("cannot create album: %v", err)
+	}
+	if _, err := NewAlbumService().AddPicturesToAlbum(album.ID, paths[:2]); err != nil {
+		t.Fatalf("cannot add pictures to album: %v", err)
+	}
+
+	tables := []string{"pictures", "tags", "picture_tags", "albums", "album_pictures", "excluded_pictures", "pictures_fts"}
+	dump := func() map[string][]string {
+		state := make(map[string][]string)
+		for _, table := range tables {
+			state[table] = dumpTable(t, table)
+		}
+		return state
+	}
+	compare := func(step string, got, want map[string][]string) {
+		t.Helper()
+		for _, table := range tables {
+			if strings.Join(got[table], "\n") != strings.Join(want[table], "\n") {
+				t.Errorf("%s: %s differs\ngot:\n%s\nwant:\n%s", step, table, strings.Join(got[table], "\n"), strings.Join(want[table], "\n"))
+			}
+		}
+	}
+
+	initial := dump()
+
+	steps := []func() error{
+		func() error { _, err := tags.CreateTag("Alice", models.TagTypePerson, "", ""); return err },
+		func() error { return tags.AddTagToPicture(paths[0], "Alice") },
+		func() error { return NewCullingService().SetRating(paths[1], 5) },
+		func() error { return tags.RenameTag("Alice", "Alice Martin") },
+		func() error { return indexer.DeletePicture(paths[0], false) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	final := dump()
+
+	for range steps {
+		if operation, err := history.Undo(); err != nil || operation == nil {
+			t.Fatalf("cannot undo: %v", err)
+		}
+	}
+	compare("undo", dump(), initial)
+
+	for range steps {
+		if operation, err := history.Redo(); err != nil || operation == nil {
+			t.Fatalf("cannot redo: %v", err)
+		}
+	}
+	compare("redo", dump(), final)
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// folderIgnoreRules regroupe les motifs ignorés compilés d'un dossier surveillé
+type folderIgnoreRules struct {
+	root     string
+	patterns []*regexp.Regexp
+}
+
+// parseIgnorePatterns découpe la liste des motifs d'un dossier (un par ligne, # = commentaire)
+func parseIgnorePatterns(text string) []string {
+	var patterns []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns
+}
+
+// compileIgnorePattern convertit un motif glob en expression régulière sur le chemin relatif au dossier surveillé
+// Le motif s'applique à n'importe quelle profondeur (ex: "@eaDir", "*.tmp", "*/.thumbnails/*"),
+// sauf s'il commence par "/" (ancré à la racine du dossier)
+func compileIgnorePattern(pattern string) (*regexp.Regexp, error) {
+	glob := strings.TrimSuffix(filepath.ToSlash(strings.TrimSpace(pattern)), "/")
+	if glob == "" || glob == "/" {
+		return nil, fmt.Errorf("ignore pattern cannot be empty")
+	}
+
+	if anchored, ok := strings.CutPrefix(glob, "/"); ok {
+		glob = anchored
+	} else {
+		glob = "**/" + glob
+	}
+
+	re, err := regexp.Compile(globToRegexp(glob))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// loadIgnoreRules compile les motifs ignorés des dossiers surveillés (les motifs invalides sont écartés)
+func loadIgnoreRules(folders []models.WatchedFolder) []folderIgnoreRules {
+	var rules []folderIgnoreRules
+	for _, folder := range folders {
+		compiled := folderIgnoreRules{root: folder.Path}
+		for _, pattern := range parseIgnorePatterns(folder.IgnorePatterns) {
+			re, err := compileIgnorePattern(pattern)
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+				continue
+			}
+			compiled.patterns = append(compiled.patterns, re)
+		}
+		if len(compiled.patterns) > 0 {
+			rules = append(rules, compiled)
+		}
+	}
+	return rules
+}
+
+// isIgnored indique si un fichier ou un dossier correspond à un motif ignoré d'un dossier surveillé qui le contient
+func isIgnored(rules []folderIgnoreRules, path string) bool {
+	for _, folder := range rules {
+		rel, ok := relativeToRoot(folder.root, path)
+		if !ok {
+			continue
+		}
+		for _, re := range folder.patterns {
+			if re.MatchString(rel) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// excludedPictureSet retourne les chemins des photos retirées de l'index
+func excludedPictureSet(db *gorm.DB) (map[string]bool, error) {
+	var paths []string
+	if err := db.Model(&models.ExcludedPicture{}).Pluck("path", &paths).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch excluded pictures: %w", err)
+	}
+
+	excluded := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		excluded[path] = true
+	}
+	return excluded, nil
+}
+
+// SetIgnorePatterns remplace les motifs glob ignorés à l'indexation d'un dossier surveillé
+// Les photos déjà indexées qui correspondent aux motifs restent dans l'index
+func (idx *Indexer) SetIgnorePatterns(folderPath string, patterns []string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var cleaned []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := compileIgnorePattern(pattern); err != nil {
+			return err
+		}
+		cleaned = append(cleaned, pattern)
+	}
+
+	result := database.DB.Model(&models.WatchedFolder{}).Where("path = ?", folderPath).Update("ignore_patterns", strings.Join(cleaned, "\n"))
+	if result.Error != nil {
+		return fmt.Errorf("cannot update watched folder: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("watched folder not found: %s", folderPath)
+	}
+
+	return nil
+}
+
+// GetExcludedPictures retourne les photos retirées de l'index, ignorées par l'indexeur
+func (idx *Indexer) GetExcludedPictures() ([]models.ExcludedPicture, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var excluded []models.ExcludedPicture
+	if err := database.DB.Order("excluded_at DESC").Find(&excluded).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch excluded pictures: %w", err)
+	}
+	return excluded, nil
+}
+
+// IncludePicture réintègre une photo retirée de l'index et l'indexe de nouveau si le fichier existe toujours
+func (idx *Indexer) IncludePicture(picturePath string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	result := database.DB.Delete(&models.ExcludedPicture{}, "path = ?", picturePath)
+	if result.Error != nil {
+		return fmt.Errorf("cannot include picture: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("picture is not excluded: %s", picturePath)
+	}
+
+	if _, err := os.Stat(picturePath); err != nil || !isSupportedImage(picturePath) {
+		return nil
+	}
+
+	folders, err := watchedFoldersByDepth(database.DB)
+	if err != nil {
+		return err
+	}
+	isNew, err := idx.indexImage(picturePath, metadataPolicyFor(folders, picturePath))
+	if err != nil {
+		return fmt.Errorf("cannot index picture: %w", err)
+	}
+	if isNew {
+		if _, err := applyAutoTagRules([]string{picturePath}, filepath.Dir(picturePath)); err != nil {
+			fmt.Printf("Warning: failed to apply auto tag rules: %v\n", err)
+		}
+	}
+	return nil
+}
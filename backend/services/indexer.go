@@ -14,6 +14,9 @@ import (
 
 	"easygallery/backend/database"
 	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Indexer gère l'indexation des photos
@@ -62,13 +65,32 @@ func (idx *Indexer) IndexFolder(folderPath string, onProgress func(current, tota
 		return 0, fmt.Errorf("path is not a directory: %s", folderPath)
 	}
 
+	// Politique d'import des métadonnées XMP / IPTC et motifs ignorés de chaque dossier surveillé
+	policies, err := watchedFoldersByDepth(database.DB)
+	if err != nil {
+		return 0, err
+	}
+	ignoreRules := loadIgnoreRules(policies)
+
+	// Photos retirées de l'index par l'utilisateur
+	excluded, err := excludedPictureSet(database.DB)
+	if err != nil {
+		return 0, err
+	}
+
 	// Première passe: compter les fichiers images
 	var imageFiles []string
 	err = filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && isSupportedImage(info.Name()) {
+		if path != folderPath && isIgnored(ignoreRules, path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && isSupportedImage(info.Name()) && !excluded[path] {
 			imageFiles = append(imageFiles, path)
 		}
 		return nil
@@ -80,6 +102,7 @@ func (idx *Indexer) IndexFolder(folderPath string, onProgress func(current, tota
 	// Indexer chaque fichier
 	indexed := 0
 	total := len(imageFiles)
+	var added []string
 
 	for i, imagePath := range imageFiles {
 		// Notifier la progression
@@ -88,22 +111,33 @@ func (idx *Indexer) IndexFolder(folderPath string, onProgress func(current, tota
 		}
 
 		// Indexer l'image
-		if err := idx.indexImage(imagePath); err != nil {
+		isNew, err := idx.indexImage(imagePath, metadataPolicyFor(policies, imagePath))
+		if err != nil {
 			fmt.Printf("Warning: failed to index %s: %v\n", imagePath, err)
 			// Continue avec les autres images
 			continue
 		}
+		if isNew {
+			added = append(added, imagePath)
+		}
 
 		indexed++
 	}
 
+	// Appliquer les règles de tag automatique aux nouvelles photos
+	// (les photos déjà connues gardent les tags choisis par l'utilisateur)
+	if _, err := applyAutoTagRules(added, folderPath); err != nil {
+		fmt.Printf("Warning: failed to apply auto tag rules: %v\n", err)
+	}
+
 	return indexed, nil
 }
 
-// indexImage indexe une seule image
-func (idx *Indexer) indexImage(imagePath string) error {
+// indexImage indexe une seule image et importe ses métadonnées XMP / IPTC selon la politique donnée
+// Retourne true si la photo vient d'être ajoutée à la DB
+func (idx *Indexer) indexImage(imagePath string, policy models.MetadataPolicy) (bool, error) {
 	if err := checkDB(); err != nil {
-		return err
+		return false, err
 	}
 
 	// Vérifier si l'image existe déjà dans la DB
@@ -113,21 +147,28 @@ func (idx *Indexer) indexImage(imagePath string) error {
 	// Si elle existe déjà, vérifier si elle a été modifiée
 	fileInfo, err := os.Stat(imagePath)
 	if err != nil {
-		return fmt.Errorf("cannot stat file: %w", err)
+		return false, fmt.Errorf("cannot stat file: %w", err)
 	}
 
 	if result.Error == nil {
 		// L'image existe déjà
 		if fileInfo.ModTime().Equal(existingPicture.ModifiedAt) {
-			// Pas de modification, on skip
-			return nil
+			// Pas de modification de l'image: seul le sidecar XMP a pu être modifié par un autre logiciel
+			if !sidecarModTime(imagePath).Equal(existingPicture.MetadataModifiedAt) {
+				if err := importIndexedMetadata(&existingPicture, policy, false); err != nil {
+					fmt.Printf("Warning: cannot import metadata of %s: %v\n", imagePath, err)
+				} else {
+					suggestionIndex.invalidate(imagePath)
+				}
+			}
+			return false, nil
 		}
 	}
 
 	// Extraire les métadonnées
 	metadata, err := idx.extractMetadata(imagePath)
 	if err != nil {
-		return fmt.Errorf("cannot extract metadata: %w", err)
+		return false, fmt.Errorf("cannot extract metadata: %w", err)
 	}
 
 	// Générer la miniature
@@ -139,22 +180,42 @@ func (idx *Indexer) indexImage(imagePath string) error {
 	_ = thumbnailPath // Pour l'instant, on stocke juste le chemin
 
 	// Créer ou mettre à jour l'entrée dans la DB
-	picture := models.Picture{
-		Path:       imagePath,
-		Filename:   filepath.Base(imagePath),
-		Size:       metadata.Size,
-		Width:      metadata.Width,
-		Height:     metadata.Height,
-		CreatedAt:  metadata.CreatedAt,
-		ModifiedAt: metadata.ModifiedAt,
+	// (les champs propres à la galerie, comme la note, sont conservés lors d'une mise à jour)
+	picture := existingPicture
+	picture.Path = imagePath
+	picture.Filename = filepath.Base(imagePath)
+	picture.Size = metadata.Size
+	picture.Width = metadata.Width
+	picture.Height = metadata.Height
+	picture.CreatedAt = metadata.CreatedAt
+	picture.ModifiedAt = metadata.ModifiedAt
+	picture.Latitude = metadata.Latitude
+	picture.Longitude = metadata.Longitude
+	if picture.ContentHash, err = fileContentHash(imagePath); err != nil {
+		fmt.Printf("Warning: cannot hash %s: %v\n", imagePath, err)
+	}
+	if picture.Flag == "" {
+		picture.Flag = models.PickFlagNone // Nouvelle photo
 	}
 
 	// Upsert (insert or update)
 	if err := database.DB.Save(&picture).Error; err != nil {
-		return fmt.Errorf("cannot save to database: %w", err)
+		return false, fmt.Errorf("cannot save to database: %w", err)
 	}
 
-	return nil
+	// Mettre à jour l'index plein texte
+	if err := refreshSearchIndex(database.DB, imagePath); err != nil {
+		return false, err
+	}
+
+	// Importer les mots-clés, lieux, personnes et note du fichier
+	isNew := result.Error != nil
+	if err := importIndexedMetadata(&picture, policy, isNew); err != nil {
+		fmt.Printf("Warning: cannot import metadata of %s: %v\n", imagePath, err)
+	}
+	suggestionIndex.invalidate(imagePath)
+
+	return isNew, nil
 }
 
 // ImageMetadata contient les métadonnées d'une image
@@ -164,6 +225,8 @@ type ImageMetadata struct {
 	Size       int64
 	CreatedAt  time.Time
 	ModifiedAt time.Time
+	Latitude   *float64
+	Longitude  *float64
 }
 
 // extractMetadata extrait les métadonnées d'une image
@@ -187,13 +250,27 @@ func (idx *Indexer) extractMetadata(imagePath string) (*ImageMetadata, error) {
 		return nil, fmt.Errorf("cannot decode image: %w", err)
 	}
 
-	return &ImageMetadata{
+	metadata := &ImageMetadata{
 		Width:      img.Width,
 		Height:     img.Height,
 		Size:       fileInfo.Size(),
 		CreatedAt:  fileInfo.ModTime(), // Sous Windows, c'est souvent la date de création
 		ModifiedAt: fileInfo.ModTime(),
-	}, nil
+	}
+
+	// La date de prise de vue et la position GPS de l'EXIF priment sur les dates du fichier
+	exif, err := readExif(imagePath)
+	if err != nil {
+		fmt.Printf("Warning: cannot read exif of %s: %v\n", imagePath, err)
+	} else {
+		if !exif.DateTaken.IsZero() {
+			metadata.CreatedAt = exif.DateTaken
+		}
+		metadata.Latitude = exif.Latitude
+		metadata.Longitude = exif.Longitude
+	}
+
+	return metadata, nil
 }
 
 // generateThumbnail génère une miniature de l'image
@@ -232,17 +309,17 @@ func (idx *Indexer) generateThumbnail(imagePath string) (string, error) {
 	return thumbnailPath, nil
 }
 
-// GetIndexedPictures retourne toutes les photos indexées
-func (idx *Indexer) GetIndexedPictures() ([]models.Picture, error) {
+// GetIndexedPictures retourne une page des photos indexées
+func (idx *Indexer) GetIndexedPictures(page PageRequest) (*PicturePage, error) {
 	if err := checkDB(); err != nil {
 		return nil, err
 	}
 
-	var pictures []models.Picture
-	if err := database.DB.Find(&pictures).Error; err != nil {
+	if err := checkSortAvailable(page.Sort, false, false); err != nil {
 		return nil, err
 	}
-	return pictures, nil
+
+	return paginatePictures(picturesQuery(), page)
 }
 
 // GetPictureCount retourne le nombre de photos indexées
@@ -259,28 +336,55 @@ func (idx *Indexer) GetPictureCount() (int64, error) {
 }
 
 // DeletePicture supprime une photo de l'index et optionnellement du disque
+// Retirée de l'index seulement, la photo n'est plus réindexée (voir IncludePicture)
+// Supprimée du disque, elle est déplacée dans la corbeille avec ses tags et ses albums (voir TrashService)
 func (idx *Indexer) DeletePicture(picturePath string, deleteFromDisk bool) error {
 	if err := checkDB(); err != nil {
 		return err
 	}
 
-	// Supprimer de la base de données
-	result := database.DB.Delete(&models.Picture{}, "path = ?", picturePath)
-	if result.Error != nil {
-		return fmt.Errorf("cannot delete picture from database: %w", result.Error)
+	// La mise à la corbeille se restaure depuis la corbeille, pas par l'historique
+	if deleteFromDisk {
+		return trashPicture(idx.dataDir, picturePath)
 	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("picture not found in database: %s", picturePath)
+
+	// Albums contenant la photo ou l'ayant en couverture, pour l'historique
+	var albumIDs []uint
+	err := database.DB.Model(&models.Album{}).
+		Where("cover_path = ? OR id IN (SELECT album_id FROM album_pictures WHERE picture_path = ?)", picturePath, picturePath).
+		Pluck("id", &albumIDs).Error
+	if err != nil {
+		return fmt.Errorf("cannot fetch albums of picture: %w", err)
 	}
 
-	// Supprimer du disque si demandé
-	if deleteFromDisk {
-		if err := os.Remove(picturePath); err != nil {
-			return fmt.Errorf("deleted from database but failed to delete file: %w", err)
-		}
+	// Supprimer de la base de données, ainsi que des albums et de l'index plein texte
+	// La photo est exclue de l'index: les indexations suivantes ne la rajoutent pas
+	scopes := []historyScope{
+		{"pictures", "path = ?", []interface{}{picturePath}},
+		{"albums", "id IN ?", []interface{}{albumIDs}},
+		{"album_pictures", "album_id IN ?", []interface{}{albumIDs}},
+		{"excluded_pictures", "path = ?", []interface{}{picturePath}},
 	}
+	return recordOperation(fmt.Sprintf("delete picture '%s'", filepath.Base(picturePath)), scopes, func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Picture{}, "path = ?", picturePath)
+		if result.Error != nil {
+			return fmt.Errorf("cannot delete picture from database: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("picture not found in database: %s", picturePath)
+		}
 
-	return nil
+		if err := removePicturesFromAllAlbums(tx, picturePath); err != nil {
+			return err
+		}
+
+		exclusion := models.ExcludedPicture{Path: picturePath}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&exclusion).Error; err != nil {
+			return fmt.Errorf("cannot exclude picture from index: %w", err)
+		}
+
+		return removeFromSearchIndex(tx, picturePath)
+	})
 }
 
 // === Gestion des dossiers surveillés ===
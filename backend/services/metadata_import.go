@@ -0,0 +1,499 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// metadataCategoryTypes associe les racines de hiérarchie usuelles (Lightroom, digiKam) à un type de tag
+// Les noms et libellés des types de tags définis dans la galerie sont aussi reconnus
+var metadataCategoryTypes = map[string]models.TagType{
+	"people":     models.TagTypePerson,
+	"persons":    models.TagTypePerson,
+	"person":     models.TagTypePerson,
+	"personnes":  models.TagTypePerson,
+	"personne":   models.TagTypePerson,
+	"places":     models.TagTypeLocation,
+	"place":      models.TagTypeLocation,
+	"locations":  models.TagTypeLocation,
+	"location":   models.TagTypeLocation,
+	"lieux":      models.TagTypeLocation,
+	"lieu":       models.TagTypeLocation,
+	"events":     models.TagTypeEvent,
+	"event":      models.TagTypeEvent,
+	"evenements": models.TagTypeEvent,
+	"evenement":  models.TagTypeEvent,
+}
+
+// MetadataService importe les métadonnées des fichiers (XMP / IPTC) dans la galerie
+type MetadataService struct{}
+
+// NewMetadataService crée une nouvelle instance de MetadataService
+func NewMetadataService() *MetadataService {
+	return &MetadataService{}
+}
+
+// MetadataImportResult résume un import de métadonnées
+type MetadataImportResult struct {
+	Pictures    int      `json:"pictures"`    // Photos dont les métadonnées ont été lues
+	Updated     int      `json:"updated"`     // Photos modifiées par l'import
+	TagsCreated int      `json:"tagsCreated"` // Tags créés
+	TagsAdded   int      `json:"tagsAdded"`   // Associations photo-tag ajoutées
+	TagsRemoved int      `json:"tagsRemoved"` // Associations retirées (politique "file")
+	Captions    int      `json:"captions"`    // Titres et légendes modifiés
+	Ratings     int      `json:"ratings"`     // Notes modifiées
+	ColorLabels int      `json:"colorLabels"` // Étiquettes de couleur modifiées
+	Flags       int      `json:"flags"`       // Marques retenue / rejetée modifiées
+	Regions     int      `json:"regions"`     // Zones de visage importées
+	Errors      []string `json:"errors"`      // Fichiers illisibles
+}
+
+// importedTag est un tag décrit par les métadonnées d'un fichier
+type importedTag struct {
+	name    string
+	tagType models.TagType
+	parent  string // Niveau supérieur dans la hiérarchie ("" = racine)
+	assign  bool   // Posé sur la photo (les niveaux intermédiaires d'une hiérarchie ne le sont pas)
+}
+
+// validMetadataPolicy vérifie qu'une politique d'import est connue
+func validMetadataPolicy(policy models.MetadataPolicy) error {
+	switch policy {
+	case models.MetadataPolicyMerge, models.MetadataPolicyFile, models.MetadataPolicyDatabase, models.MetadataPolicyIgnore:
+		return nil
+	}
+	return fmt.Errorf("invalid metadata policy: %s", policy)
+}
+
+// metadataCategories retourne les racines de hiérarchie reconnues, par nom normalisé
+func metadataCategories(db *gorm.DB) (map[string]models.TagType, error) {
+	var types []models.TagTypeDefinition
+	if err := db.Find(&types).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tag types: %w", err)
+	}
+
+	categories := make(map[string]models.TagType, len(metadataCategoryTypes)+2*len(types))
+	for key, tagType := range metadataCategoryTypes {
+		categories[key] = tagType
+	}
+	for _, t := range types {
+		categories[normalizeTagName(string(t.Name))] = t.Name
+		categories[normalizeTagName(t.Label)] = t.Name
+	}
+	return categories, nil
+}
+
+// metadataTags convertit les métadonnées d'un fichier en tags, les parents avant leurs enfants
+// Mots-clés hiérarchiques: la racine peut désigner le type ("Lieux|France|Paris" → lieu "Paris" sous "France")
+// Les mots-clés simples déjà couverts par une hiérarchie (parents exportés par Lightroom) ne sont pas posés en double
+func metadataTags(db *gorm.DB, m *FileMetadata) ([]importedTag, error) {
+	categories, err := metadataCategories(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []importedTag
+	index := make(map[string]int)
+	add := func(name string, parent string, tagType models.TagType, assign bool) {
+		key := normalizeTagName(name)
+		if key == "" {
+			return
+		}
+		if i, ok := index[key]; ok {
+			tags[i].assign = tags[i].assign || assign
+			return
+		}
+		index[key] = len(tags)
+		tags = append(tags, importedTag{name: strings.TrimSpace(name), tagType: tagType, parent: parent, assign: assign})
+	}
+
+	for _, path := range m.HierarchicalKeywords {
+		var levels []string
+		for _, level := range strings.Split(path, xmpHierarchySeparator) {
+			if level = strings.TrimSpace(level); level != "" {
+				levels = append(levels, level)
+			}
+		}
+		if len(levels) == 0 {
+			continue
+		}
+
+		tagType := models.TagTypeOther
+		if category, ok := categories[normalizeTagName(levels[0])]; ok && len(levels) > 1 {
+			tagType = category
+			levels = levels[1:]
+		}
+
+		parent := ""
+		for i, level := range levels {
+			add(level, parent, tagType, i == len(levels)-1)
+			parent = level
+		}
+	}
+
+	for _, name := range m.PersonsInImage {
+		add(name, "", models.TagTypePerson, true)
+	}
+	for _, region := range m.Regions {
+		add(region.Name, "", models.TagTypePerson, true)
+	}
+
+	// Lieu: du pays au lieu précis, seul le niveau le plus précis est posé
+	var places []string
+	for _, place := range []string{m.Country, m.State, m.City, m.Location} {
+		if place != "" {
+			places = append(places, place)
+		}
+	}
+	parent := ""
+	for i, place := range places {
+		add(place, parent, models.TagTypeLocation, i == len(places)-1)
+		parent = place
+	}
+
+	for _, keyword := range m.Keywords {
+		if _, ok := index[normalizeTagName(keyword)]; !ok {
+			add(keyword, "", models.TagTypeOther, true)
+		}
+	}
+
+	return tags, nil
+}
+
+// importPictureMetadata applique les métadonnées d'un fichier à une photo indexée
+// Les tags inconnus sont créés avec le type déduit du fichier; un tag existant garde son type et son parent
+// Politique "file": la note est remplacée et les tags absents du fichier sont retirés (si le fichier en décrit)
+// Retourne true si la photo a été modifiée
+func importPictureMetadata(tx *gorm.DB, picture *models.Picture, m *FileMetadata, policy models.MetadataPolicy, result *MetadataImportResult) (bool, error) {
+	tags, err := metadataTags(tx, m)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	canonicalOf := make(map[string]string)
+	tagTypes := make(map[string]models.TagType)
+	var assigned []string
+	for _, t := range tags {
+		canonical, found, err := resolveTagName(tx, t.name)
+		if err != nil {
+			return false, err
+		}
+
+		if found {
+			var existing models.Tag
+			if err := tx.Where("name = ?", canonical).First(&existing).Error; err != nil {
+				return false, fmt.Errorf("cannot fetch tag: %w", err)
+			}
+			tagTypes[canonical] = existing.Type
+		} else {
+			typeDef, err := findTagType(tx, t.tagType)
+			if err != nil {
+				return false, err
+			}
+			tag := models.Tag{Name: canonical, NameKey: normalizeTagName(canonical), Type: t.tagType, Color: typeDef.Color}
+			if parent := canonicalOf[normalizeTagName(t.parent)]; parent != "" && parent != canonical {
+				tag.ParentName = &parent
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+				return false, fmt.Errorf("cannot create tag: %w", err)
+			}
+			tagTypes[canonical] = t.tagType
+			result.TagsCreated++
+		}
+
+		canonicalOf[normalizeTagName(t.name)] = canonical
+		if t.assign {
+			assigned = append(assigned, canonical)
+		}
+	}
+
+	// Tags de la photo
+	var current []string
+	if err := tx.Model(&models.PictureTag{}).Where("picture_path = ?", picture.Path).Pluck("tag_name", &current).Error; err != nil {
+		return false, fmt.Errorf("cannot fetch picture tags: %w", err)
+	}
+	has := make(map[string]bool, len(current))
+	for _, name := range current {
+		has[name] = true
+	}
+	keep := make(map[string]bool, len(assigned))
+	var toAdd, toRemove []models.PictureTag
+	for _, name := range assigned {
+		keep[name] = true
+		if !has[name] {
+			has[name] = true
+			toAdd = append(toAdd, models.PictureTag{PicturePath: picture.Path, TagName: name})
+		}
+	}
+	if policy == models.MetadataPolicyFile && m.hasDescriptiveData() {
+		for _, name := range current {
+			if !keep[name] {
+				toRemove = append(toRemove, models.PictureTag{PicturePath: picture.Path, TagName: name})
+			}
+		}
+	}
+	if len(toAdd) > 0 || len(toRemove) > 0 {
+		if err := applyBulkTags(tx, toAdd, toRemove); err != nil {
+			return false, err
+		}
+		result.TagsAdded += len(toAdd)
+		result.TagsRemoved += len(toRemove)
+		changed = true
+	}
+
+	// Titre et légende: même règle que la note (les notes privées ne viennent jamais du fichier)
+	captions := make(map[string]interface{})
+	for _, field := range []struct {
+		column      string
+		file, value string
+	}{
+		{"title", m.Title, picture.Title},
+		{"caption", m.Caption, picture.Caption},
+	} {
+		if field.file != "" && field.file != field.value &&
+			(policy == models.MetadataPolicyFile || field.value == "") {
+			captions[field.column] = field.file
+		}
+	}
+	if len(captions) > 0 {
+		if err := tx.Model(picture).Updates(captions).Error; err != nil {
+			return false, fmt.Errorf("cannot update caption: %w", err)
+		}
+		if err := refreshSearchIndex(tx, picture.Path); err != nil {
+			return false, err
+		}
+		result.Captions++
+		changed = true
+	}
+
+	// Note (une note -1 marque une photo rejetée, sans changer sa note)
+	if m.Rating != nil && *m.Rating >= 0 && *m.Rating != picture.Rating &&
+		(policy == models.MetadataPolicyFile || picture.Rating == 0) {
+		if err := tx.Model(picture).Update("rating", *m.Rating).Error; err != nil {
+			return false, fmt.Errorf("cannot update rating: %w", err)
+		}
+		result.Ratings++
+		changed = true
+	}
+
+	// Étiquette de couleur et marque: même règle que la note
+	if label, ok := m.colorLabel(); ok && label != picture.ColorLabel &&
+		(policy == models.MetadataPolicyFile || picture.ColorLabel == models.ColorLabelNone) {
+		if err := tx.Model(picture).Update("color_label", label).Error; err != nil {
+			return false, fmt.Errorf("cannot update color label: %w", err)
+		}
+		result.ColorLabels++
+		changed = true
+	}
+	if flag, ok := m.pickFlag(); ok && flag != picture.Flag &&
+		(policy == models.MetadataPolicyFile || picture.Flag == models.PickFlagNone) {
+		if err := tx.Model(picture).Update("flag", flag).Error; err != nil {
+			return false, fmt.Errorf("cannot update pick flag: %w", err)
+		}
+		result.Flags++
+		changed = true
+	}
+
+	// Régions de visage nommées, sauf celles qui doublent une zone existante
+	if len(m.Regions) > 0 {
+		existing, err := faceRegionsForPicture(tx, picture.Path)
+		if err != nil {
+			return false, err
+		}
+		for _, r := range m.Regions {
+			box := DetectedFace{X: r.X, Y: r.Y, Width: r.Width, Height: r.Height}
+			duplicate := false
+			for _, region := range existing {
+				if faceIoU(box, region) > faceOverlapThreshold {
+					duplicate = true
+					break
+				}
+			}
+			if duplicate {
+				continue
+			}
+
+			region := models.FaceRegion{
+				PicturePath: picture.Path,
+				X:           r.X,
+				Y:           r.Y,
+				Width:       r.Width,
+				Height:      r.Height,
+				Source:      models.FaceRegionXMP,
+			}
+			// Un nom déjà utilisé par un tag d'un autre type laisse le visage non identifié
+			if name := canonicalOf[normalizeTagName(r.Name)]; tagTypes[name] == models.TagTypePerson {
+				region.TagName = &name
+			}
+			if err := tx.Create(&region).Error; err != nil {
+				return false, fmt.Errorf("cannot create face region: %w", err)
+			}
+			existing = append(existing, region)
+			result.Regions++
+			changed = true
+		}
+	}
+
+	if changed {
+		result.Updated++
+	}
+	return changed, nil
+}
+
+// watchedFoldersByDepth retourne les réglages d'indexation et de métadonnées des dossiers surveillés, du plus profond au moins profond
+func watchedFoldersByDepth(db *gorm.DB) ([]models.WatchedFolder, error) {
+	var folders []models.WatchedFolder
+	if err := db.Select("path", "metadata_policy", "write_sidecars", "ignore_patterns").Find(&folders).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch watched folders: %w", err)
+	}
+	sort.Slice(folders, func(i, j int) bool { return len(folders[i].Path) > len(folders[j].Path) })
+	return folders, nil
+}
+
+// metadataPolicyFor retourne la politique du dossier surveillé le plus proche ("merge" hors des dossiers surveillés)
+func metadataPolicyFor(folders []models.WatchedFolder, picturePath string) models.MetadataPolicy {
+	for _, folder := range folders {
+		if _, ok := relativeToRoot(folder.Path, picturePath); ok && folder.MetadataPolicy != "" {
+			return folder.MetadataPolicy
+		}
+	}
+	return models.MetadataPolicyMerge
+}
+
+// importIndexedMetadata importe les métadonnées d'une photo lors de l'indexation, selon la politique de son dossier
+// Politique "database": seules les nouvelles photos sont importées
+func importIndexedMetadata(picture *models.Picture, policy models.MetadataPolicy, isNew bool) error {
+	if policy == models.MetadataPolicyIgnore || (policy == models.MetadataPolicyDatabase && !isNew) {
+		return nil
+	}
+
+	metadata, err := readFileMetadata(picture.Path)
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := importPictureMetadata(tx, picture, metadata, policy, &MetadataImportResult{}); err != nil {
+			return err
+		}
+		if err := tx.Model(picture).Update("metadata_modified_at", sidecarModTime(picture.Path)).Error; err != nil {
+			return fmt.Errorf("cannot update picture: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetMetadataPolicy choisit la politique d'import des métadonnées d'un dossier surveillé
+func (ms *MetadataService) SetMetadataPolicy(folderPath string, policy models.MetadataPolicy) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	if err := validMetadataPolicy(policy); err != nil {
+		return err
+	}
+
+	result := database.DB.Model(&models.WatchedFolder{}).Where("path = ?", folderPath).Update("metadata_policy", policy)
+	if result.Error != nil {
+		return fmt.Errorf("cannot update watched folder: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("watched folder not found: %s", folderPath)
+	}
+
+	return nil
+}
+
+// GetFileMetadata lit les métadonnées XMP / IPTC d'une photo, sans les importer
+func (ms *MetadataService) GetFileMetadata(picturePath string) (*FileMetadata, error) {
+	return readFileMetadata(picturePath)
+}
+
+// ImportMetadata relit les métadonnées de toutes les photos d'un dossier et les importe (opération annulable)
+// policy vaut "merge" (ajout seulement) ou "file" (le fichier fait foi)
+func (ms *MetadataService) ImportMetadata(folderPath string, policy models.MetadataPolicy) (*MetadataImportResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	if policy != models.MetadataPolicyMerge && policy != models.MetadataPolicyFile {
+		return nil, fmt.Errorf("invalid metadata policy for import: %s", policy)
+	}
+
+	prefix := strings.TrimSuffix(folderPath, string(filepath.Separator)) + string(filepath.Separator)
+	var pictures []models.Picture
+	err := database.DB.Where("path LIKE ? ESCAPE '\\'", escapeLike(prefix)+"%").Order("path").Find(&pictures).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+	}
+
+	// Lecture des fichiers hors transaction
+	result := &MetadataImportResult{}
+	type pending struct {
+		picture  models.Picture
+		metadata *FileMetadata
+	}
+	var toImport []pending
+	var paths []string
+	var tagNames []string
+	for _, picture := range pictures {
+		metadata, err := readFileMetadata(picture.Path)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", filepath.Base(picture.Path), err))
+			continue
+		}
+		result.Pictures++
+		if !metadata.hasDescriptiveData() && !metadata.hasCaptionData() && !metadata.hasCullingData() {
+			continue
+		}
+
+		toImport = append(toImport, pending{picture, metadata})
+		paths = append(paths, picture.Path)
+		tags, err := metadataTags(database.DB, metadata)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			tagNames = append(tagNames, t.name)
+		}
+	}
+	if len(toImport) == 0 {
+		return result, nil
+	}
+
+	scopes := append(historyScopesIn("pictures", "path", paths), historyScopesIn("picture_tags", "picture_path", paths)...)
+	scopes = append(scopes, historyScopesIn("face_regions", "picture_path", paths)...)
+	resolvedNames, err := resolveTagNames(database.DB, tagNames)
+	if err != nil {
+		return result, err
+	}
+	scopes = append(scopes, historyScopesIn("tags", "name", resolvedNames)...)
+
+	description := fmt.Sprintf("import metadata of %d pictures", len(toImport))
+	err = recordOperation(description, scopes, func(tx *gorm.DB) error {
+		for i := range toImport {
+			p := &toImport[i]
+			if _, err := importPictureMetadata(tx, &p.picture, p.metadata, policy, result); err != nil {
+				return err
+			}
+			if err := tx.Model(&p.picture).Update("metadata_modified_at", sidecarModTime(p.picture.Path)).Error; err != nil {
+				return fmt.Errorf("cannot update picture: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReindexAfterSidecarEditRefreshesSuggestions(t *testing.T) {
+	indexer, folder, paths := setupTestLibrary(t, 2)
+	tags := NewTagService()
+
+	if _, err := tags.SuggestTags(paths[1]); err != nil {
+		t.Fatalf("cannot suggest tags: %v", err)
+	}
+
+	// Mot-clé ajouté par un autre logiciel dans le sidecar, sans toucher à l'image
+	sidecar := `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <dc:subject><rdf:Bag><rdf:li>Plage</rdf:li></rdf:Bag></dc:subject>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+	if err := os.WriteFile(paths[0]+".xmp", []byte(sidecar), 0644); err != nil {
+		t.Fatalf("cannot write sidecar: %v", err)
+	}
+	if _, err := indexer.IndexWatchedFolder(folder, nil); err != nil {
+		t.Fatalf("cannot reindex folder: %v", err)
+	}
+	if n := countRows(t, "picture_tags", "picture_path = ? AND tag_name = ?", paths[0], "Plage"); n != 1 {
+		t.Fatalf("sidecar keyword not imported")
+	}
+
+	suggestions, err := tags.SuggestTags(paths[1])
+	if err != nil {
+		t.Fatalf("cannot suggest tags: %v", err)
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.TagName == "Plage" {
+			return
+		}
+	}
+	t.Errorf("suggestions computed before the sidecar import are still cached: %+v", suggestions)
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// SortField représente le critère de tri d'une liste de photos
+type SortField string
+
+const (
+	SortByCaptureDate SortField = "captureDate" // Date de prise de vue
+	SortByFilename    SortField = "filename"    // Nom du fichier
+	SortBySize        SortField = "size"        // Taille en bytes
+	SortByRating      SortField = "rating"      // Note
+	SortByIndexedDate SortField = "indexedAt"   // Date d'indexation
+	SortByRandom      SortField = "random"      // Ordre aléatoire reproductible (seed)
+	SortByRelevance   SortField = "relevance"   // Pertinence de la recherche texte (décroissant = meilleurs d'abord)
+	SortByAlbumOrder  SortField = "albumOrder"  // Ordre manuel d'un album
+	SortByLastViewed  SortField = "lastViewed"  // Dernière ouverture dans la visionneuse
+	SortByViewCount   SortField = "viewCount"   // Nombre d'ouvertures dans la visionneuse
+)
+
+const (
+	defaultPageSize = 100 // Taille de page par défaut
+	maxPageSize     = 500 // Taille de page maximale
+)
+
+// PageRequest représente une demande de page de photos
+// Le curseur est opaque: il faut renvoyer tel quel le NextCursor de la page précédente
+type PageRequest struct {
+	Sort       SortField `json:"sort"`       // Critère de tri (défaut: captureDate)
+	Descending bool      `json:"descending"` // Ordre décroissant
+	Seed       int64     `json:"seed"`       // Graine pour le tri aléatoire
+	Cursor     string    `json:"cursor"`     // Curseur de la page précédente (vide pour la première page)
+	Limit      int       `json:"limit"`      // Nombre de photos par page
+}
+
+// PicturePage représente une page de photos
+type PicturePage struct {
+	Pictures   []models.Picture `json:"pictures"`   // Photos de la page
+	NextCursor string           `json:"nextCursor"` // Curseur de la page suivante (vide si dernière page)
+	Total      int64            `json:"total"`      // Nombre total de photos correspondant à la requête
+}
+
+// pageCursor est le contenu décodé d'un curseur de pagination
+// Keyset: valeur de la colonne de tri + chemin pour départager les égalités
+type pageCursor struct {
+	Value json.RawMessage `json:"v"`
+	Path  string          `json:"p"`
+}
+
+// pictureRow permet de récupérer les clés de tri calculées en SQL en plus de la photo
+type pictureRow struct {
+	models.Picture
+	SortKey  int64   `gorm:"column:sort_key"`  // Clé du tri aléatoire ou position dans l'album
+	SortRank float64 `gorm:"column:sort_rank"` // Score de pertinence
+}
+
+// randomModulus borne la clé de tri aléatoire (arithmétique entière SQLite sur 64 bits)
+const randomModulus = 1 << 31
+
+// sortExpression retourne l'expression SQL de la colonne de tri
+func sortExpression(page PageRequest) (string, error) {
+	switch page.Sort {
+	case SortByCaptureDate:
+		return "pictures.created_at", nil
+	case SortByFilename:
+		return "pictures.filename COLLATE NOCASE", nil
+	case SortBySize:
+		return "pictures.size", nil
+	case SortByRating:
+		return "pictures.rating", nil
+	case SortByIndexedDate:
+		return "pictures.indexed_at", nil
+	case SortByRandom:
+		// Hachage des rowid déterminé par la graine: une étape linéaire puis une
+		// étape quadratique pour casser la régularité (les égalités sont départagées par path)
+		seed := uint64(page.Seed)
+		multiplier := (seed*2654435761)%(randomModulus/2)*2 + 1
+		offset := seed % randomModulus
+		h := fmt.Sprintf("((pictures.rowid * %d + %d) %% %d)", multiplier, offset, randomModulus)
+		return fmt.Sprintf("((%s * %s + %d) %% %d)", h, h, offset, randomModulus), nil
+	case SortByRelevance:
+		// bm25 est négatif et d'autant plus petit que la photo est pertinente
+		// La jointure search_fts est ajoutée par applySearchCriteria
+		return "(-search_fts.fts_rank)", nil
+	case SortByAlbumOrder:
+		// La jointure album_pictures est ajoutée par AlbumService
+		return "album_pictures.position", nil
+	case SortByLastViewed:
+		// La jointure view_stats est ajoutée par FavoriteService (les identifiants des vues croissent avec le temps)
+		return "view_stats.last_view_id", nil
+	case SortByViewCount:
+		return "view_stats.view_count", nil
+	default:
+		return "", fmt.Errorf("invalid sort field: %s", page.Sort)
+	}
+}
+
+// cursorValue extrait la valeur de tri d'une photo pour construire le curseur suivant
+func cursorValue(sort SortField, row pictureRow) interface{} {
+	switch sort {
+	case SortByCaptureDate:
+		return row.CreatedAt
+	case SortByFilename:
+		return row.Filename
+	case SortBySize:
+		return row.Size
+	case SortByRating:
+		return int64(row.Rating)
+	case SortByIndexedDate:
+		return row.IndexedAt
+	case SortByRelevance:
+		return row.SortRank
+	default:
+		return row.SortKey
+	}
+}
+
+// encodeCursor encode un curseur de pagination
+func encodeCursor(sort SortField, row pictureRow) (string, error) {
+	value, err := json.Marshal(cursorValue(sort, row))
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(pageCursor{Value: value, Path: row.Path})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor décode un curseur et retourne la valeur typée selon le critère de tri
+func decodeCursor(sort SortField, cursor string) (interface{}, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid cursor: %w", err)
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, "", fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	var value interface{}
+	switch sort {
+	case SortByCaptureDate, SortByIndexedDate:
+		var t time.Time
+		err = json.Unmarshal(c.Value, &t)
+		value = t
+	case SortByFilename:
+		var s string
+		err = json.Unmarshal(c.Value, &s)
+		value = s
+	case SortByRelevance:
+		var f float64
+		err = json.Unmarshal(c.Value, &f)
+		value = f
+	default:
+		var n int64
+		err = json.Unmarshal(c.Value, &n)
+		value = n
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	return value, c.Path, nil
+}
+
+// checkSortAvailable vérifie que le tri demandé a un sens pour la liste consultée
+// La pertinence n'existe que pour une recherche texte, l'ordre manuel que dans un album
+// et les tris par consultation que dans les listes de photos vues
+func checkSortAvailable(sort SortField, hasText, inAlbum bool) error {
+	if sort == SortByLastViewed || sort == SortByViewCount {
+		return fmt.Errorf("view sorts are only available in recently viewed and most viewed listings")
+	}
+	if sort == SortByRelevance && !hasText {
+		return fmt.Errorf("relevance sort requires a text query")
+	}
+	if sort == SortByAlbumOrder && !inAlbum {
+		return fmt.Errorf("album order sort is only available inside an album")
+	}
+	return nil
+}
+
+// paginatePictures applique tri, curseur et limite à une requête sur la table pictures
+// La requête doit déjà contenir les filtres; le total est calculé avant l'application du curseur
+func paginatePictures(query *gorm.DB, page PageRequest) (*PicturePage, error) {
+	if page.Sort == "" {
+		page.Sort = SortByCaptureDate
+	}
+	if page.Limit <= 0 {
+		page.Limit = defaultPageSize
+	}
+	if page.Limit > maxPageSize {
+		page.Limit = maxPageSize
+	}
+
+	expr, err := sortExpression(page)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nombre total de résultats (indépendant du curseur)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("cannot count pictures: %w", err)
+	}
+
+	direction, comparator := "ASC", ">"
+	if page.Descending {
+		direction, comparator = "DESC", "<"
+	}
+
+	// Les clés calculées n'existent pas dans la table: on les récupère pour le curseur
+	columns := "pictures.*"
+	switch page.Sort {
+	case SortByRandom, SortByAlbumOrder, SortByLastViewed, SortByViewCount:
+		columns += ", " + expr + " AS sort_key"
+	case SortByRelevance:
+		columns += ", " + expr + " AS sort_rank"
+	}
+	q := query.Session(&gorm.Session{}).Select(columns)
+
+	// Keyset: (clé, path) strictement après le curseur
+	if page.Cursor != "" {
+		value, path, err := decodeCursor(page.Sort, page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND pictures.path %s ?))", expr, comparator, expr, comparator),
+			value, value, path,
+		)
+	}
+
+	// Une ligne de plus pour savoir s'il existe une page suivante
+	var rows []pictureRow
+	err = q.Order(expr + " " + direction).
+		Order("pictures.path " + direction).
+		Limit(page.Limit + 1).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+	}
+
+	result := &PicturePage{
+		Pictures: make([]models.Picture, 0, len(rows)),
+		Total:    total,
+	}
+
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		if result.NextCursor, err = encodeCursor(page.Sort, rows[len(rows)-1]); err != nil {
+			return nil, fmt.Errorf("cannot encode cursor: %w", err)
+		}
+	}
+
+	for _, row := range rows {
+		result.Pictures = append(result.Pictures, row.Picture)
+	}
+
+	return result, nil
+}
+
+// escapeLike échappe les caractères spéciaux d'un motif LIKE (à utiliser avec ESCAPE '\\')
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// picturesQuery retourne la requête de base sur la table pictures
+func picturesQuery() *gorm.DB {
+	return database.DB.Model(&models.Picture{})
+}
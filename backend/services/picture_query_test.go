@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestCursorWalkVisitsEachPictureOnce(t *testing.T) {
+	indexer, _, paths := setupTestLibrary(t, 7)
+
+	// Des notes identiques forcent le départage par le chemin
+	if _, err := NewCullingService().SetRatings(paths[:3], 4); err != nil {
+		t.Fatalf("cannot rate pictures: %v", err)
+	}
+
+	sorts := []SortField{SortByCaptureDate, SortByFilename, SortBySize, SortByRating, SortByIndexedDate, SortByRandom}
+	for _, sort := range sorts {
+		for _, descending := range []bool{false, true} {
+			walked := walkAllPictures(t, indexer, sort, descending)
+
+			seen := make(map[string]bool)
+			for _, path := range walked {
+				if seen[path] {
+					t.Errorf("sort %s (descending=%v): %s returned twice", sort, descending, path)
+				}
+				seen[path] = true
+			}
+			if len(seen) != len(paths) {
+				t.Errorf("sort %s (descending=%v): got %d pictures, want %d", sort, descending, len(seen), len(paths))
+			}
+		}
+	}
+}
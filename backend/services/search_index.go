@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// searchIndexBatchSize limite le nombre de chemins par requête (limite de variables SQLite)
+const searchIndexBatchSize = 500
+
+// folderWords retourne les noms des dossiers parents d'une photo, séparés par des espaces
+// Exemple: "D:\Photos\2019-07 Bretagne vacances\IMG_001.jpg" -> "Photos 2019-07 Bretagne vacances"
+func folderWords(picturePath string) string {
+	dir := filepath.Dir(picturePath)
+	dir = strings.TrimPrefix(dir, filepath.VolumeName(dir))
+
+	// Les chemins Windows et Unix peuvent coexister dans une même base
+	parts := strings.FieldsFunc(dir, func(r rune) bool {
+		return r == '/' || r == '\\'
+	})
+	return strings.Join(parts, " ")
+}
+
+// captionWords réunit le titre, la légende et les notes d'une photo pour la colonne captions
+func captionWords(picture models.Picture) string {
+	var parts []string
+	for _, text := range []string{picture.Title, picture.Caption, picture.Notes} {
+		if text = strings.TrimSpace(text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// refreshSearchIndex recalcule les lignes de l'index plein texte pour les photos données
+// Les photos absentes de la table pictures sont simplement retirées de l'index
+func refreshSearchIndex(db *gorm.DB, paths ...string) error {
+	for start := 0; start < len(paths); start += searchIndexBatchSize {
+		end := start + searchIndexBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+		if err := refreshSearchIndexBatch(db, paths[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshSearchIndexBatch recalcule un lot de lignes de l'index plein texte
+func refreshSearchIndexBatch(db *gorm.DB, paths []string) error {
+	if err := removeFromSearchIndex(db, paths...); err != nil {
+		return err
+	}
+
+	var pictures []models.Picture
+	if err := db.Where("path IN ?", paths).Find(&pictures).Error; err != nil {
+		return fmt.Errorf("cannot fetch pictures for search index: %w", err)
+	}
+	if len(pictures) == 0 {
+		return nil
+	}
+
+	// Noms des tags par photo
+	var pictureTags []models.PictureTag
+	if err := db.Where("picture_path IN ?", paths).Find(&pictureTags).Error; err != nil {
+		return fmt.Errorf("cannot fetch tags for search index: %w", err)
+	}
+	tagsByPath := make(map[string][]string)
+	for _, pt := range pictureTags {
+		tagsByPath[pt.PicturePath] = append(tagsByPath[pt.PicturePath], pt.TagName)
+	}
+
+	for _, picture := range pictures {
+		err := db.Exec(
+			"INSERT INTO "+database.SearchIndexTable+" (path, filename, folders, tags, captions) VALUES (?, ?, ?, ?, ?)",
+			picture.Path,
+			picture.Filename,
+			folderWords(picture.Path),
+			strings.Join(tagsByPath[picture.Path], " "),
+			captionWords(picture),
+		).Error
+		if err != nil {
+			return fmt.Errorf("cannot update search index: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// removeFromSearchIndex retire des photos de l'index plein texte
+func removeFromSearchIndex(db *gorm.DB, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	if err := db.Exec("DELETE FROM "+database.SearchIndexTable+" WHERE path IN ?", paths).Error; err != nil {
+		return fmt.Errorf("cannot remove from search index: %w", err)
+	}
+	return nil
+}
+
+// EnsureSearchIndex reconstruit l'index plein texte s'il n'est plus aligné sur la table pictures
+// Utile au premier démarrage après la création de l'index sur une base existante
+func EnsureSearchIndex() error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var pictureCount, indexedCount int64
+	if err := database.DB.Model(&models.Picture{}).Count(&pictureCount).Error; err != nil {
+		return fmt.Errorf("cannot count pictures: %w", err)
+	}
+	if err := database.DB.Table(database.SearchIndexTable).Count(&indexedCount).Error; err != nil {
+		return fmt.Errorf("cannot count search index rows: %w", err)
+	}
+	if pictureCount == indexedCount {
+		return nil
+	}
+
+	return RebuildSearchIndex()
+}
+
+// RebuildSearchIndex reconstruit entièrement l'index plein texte
+func RebuildSearchIndex() error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM " + database.SearchIndexTable).Error; err != nil {
+			return fmt.Errorf("cannot clear search index: %w", err)
+		}
+
+		var paths []string
+		if err := tx.Model(&models.Picture{}).Pluck("path", &paths).Error; err != nil {
+			return fmt.Errorf("cannot fetch pictures: %w", err)
+		}
+
+		return refreshSearchIndex(tx, paths...)
+	})
+}
+
+// buildMatchQuery convertit un texte libre en requête FTS5
+// Chaque mot devient une recherche par préfixe, les mots sont combinés avec AND
+// Exemple: `bretagne 2019` -> `"bretagne"* "2019"*`
+func buildMatchQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		// Les guillemets protègent la syntaxe FTS5 (-, :, OR, NEAR...)
+		word = strings.ReplaceAll(word, `"`, `""`)
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
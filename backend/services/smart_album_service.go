@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// SmartAlbumService gère les albums intelligents (recherches enregistrées)
+type SmartAlbumService struct{}
+
+// NewSmartAlbumService crée une nouvelle instance de SmartAlbumService
+func NewSmartAlbumService() *SmartAlbumService {
+	return &SmartAlbumService{}
+}
+
+// SmartAlbumWithCount représente un album intelligent avec ses critères décodés
+// et le nombre de photos qui y correspondent actuellement
+type SmartAlbumWithCount struct {
+	models.SmartAlbum
+	Criteria     SearchCriteria `json:"criteria"`
+	PictureCount int64          `json:"pictureCount"`
+}
+
+// CreateSmartAlbum enregistre des critères de recherche sous un nom
+func (ss *SmartAlbumService) CreateSmartAlbum(name string, criteria SearchCriteria) (*models.SmartAlbum, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("smart album name cannot be empty")
+	}
+
+	// Vérifier si le nom est déjà utilisé
+	var existing models.SmartAlbum
+	if err := database.DB.Where("name = ?", name).First(&existing).Error; err == nil {
+		return nil, fmt.Errorf("smart album '%s' already exists", name)
+	}
+
+	data, err := json.Marshal(criteria)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode criteria: %w", err)
+	}
+
+	album := models.SmartAlbum{
+		Name:         name,
+		CriteriaJSON: string(data),
+	}
+
+	if err := database.DB.Create(&album).Error; err != nil {
+		return nil, fmt.Errorf("cannot create smart album: %w", err)
+	}
+
+	return &album, nil
+}
+
+// GetSmartAlbums retourne tous les albums intelligents avec leur nombre de photos
+func (ss *SmartAlbumService) GetSmartAlbums() ([]SmartAlbumWithCount, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var albums []models.SmartAlbum
+	if err := database.DB.Order("name").Find(&albums).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch smart albums: %w", err)
+	}
+
+	result := make([]SmartAlbumWithCount, 0, len(albums))
+	for _, album := range albums {
+		criteria, err := decodeSmartAlbumCriteria(album)
+		if err != nil {
+			return nil, err
+		}
+
+		var count int64
+		if err := applySearchCriteria(picturesQuery(), criteria).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("cannot count pictures of smart album '%s': %w", album.Name, err)
+		}
+
+		result = append(result, SmartAlbumWithCount{
+			SmartAlbum:   album,
+			Criteria:     criteria,
+			PictureCount: count,
+		})
+	}
+
+	return result, nil
+}
+
+// UpdateSmartAlbum renomme un album intelligent et remplace ses critères
+func (ss *SmartAlbumService) UpdateSmartAlbum(id uint, name string, criteria SearchCriteria) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("smart album name cannot be empty")
+	}
+
+	var album models.SmartAlbum
+	if err := database.DB.First(&album, id).Error; err != nil {
+		return fmt.Errorf("smart album %d not found", id)
+	}
+
+	// Vérifier que le nouveau nom n'est pas pris par un autre album
+	var existing models.SmartAlbum
+	if err := database.DB.Where("name = ? AND id <> ?", name, id).First(&existing).Error; err == nil {
+		return fmt.Errorf("smart album '%s' already exists", name)
+	}
+
+	data, err := json.Marshal(criteria)
+	if err != nil {
+		return fmt.Errorf("cannot encode criteria: %w", err)
+	}
+
+	album.Name = name
+	album.CriteriaJSON = string(data)
+
+	if err := database.DB.Save(&album).Error; err != nil {
+		return fmt.Errorf("cannot update smart album: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSmartAlbum supprime un album intelligent (les photos ne sont pas touchées)
+func (ss *SmartAlbumService) DeleteSmartAlbum(id uint) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	result := database.DB.Delete(&models.SmartAlbum{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("cannot delete smart album: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("smart album %d not found", id)
+	}
+
+	return nil
+}
+
+// GetSmartAlbumPictures exécute la recherche d'un album intelligent et retourne une page de photos
+func (ss *SmartAlbumService) GetSmartAlbumPictures(id uint, page PageRequest) (*PicturePage, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var album models.SmartAlbum
+	if err := database.DB.First(&album, id).Error; err != nil {
+		return nil, fmt.Errorf("smart album %d not found", id)
+	}
+
+	criteria, err := decodeSmartAlbumCriteria(album)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchPictures(criteria, page)
+}
+
+// decodeSmartAlbumCriteria décode les critères enregistrés d'un album intelligent
+func decodeSmartAlbumCriteria(album models.SmartAlbum) (SearchCriteria, error) {
+	var criteria SearchCriteria
+	if err := json.Unmarshal([]byte(album.CriteriaJSON), &criteria); err != nil {
+		return criteria, fmt.Errorf("invalid criteria for smart album '%s': %w", album.Name, err)
+	}
+	return criteria, nil
+}
+
+// replaceTagsInSmartAlbums met à jour les critères enregistrés après un renommage ou une fusion de tags
+func replaceTagsInSmartAlbums(tx *gorm.DB, mapping map[string]string) error {
+	var albums []models.SmartAlbum
+	if err := tx.Find(&albums).Error; err != nil {
+		return fmt.Errorf("cannot fetch smart albums: %w", err)
+	}
+
+	for _, album := range albums {
+		criteria, err := decodeSmartAlbumCriteria(album)
+		if err != nil {
+			return err
+		}
+
+		criteria.replaceTags(mapping)
+
+		data, err := json.Marshal(criteria)
+		if err != nil {
+			return fmt.Errorf("cannot encode criteria: %w", err)
+		}
+		if string(data) == album.CriteriaJSON {
+			continue
+		}
+
+		if err := tx.Model(&album).Update("criteria", string(data)).Error; err != nil {
+			return fmt.Errorf("cannot update smart album '%s': %w", album.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// normalizeTagName retourne la forme de comparaison d'un nom de tag:
+// sans accents, en minuscules, espaces superflus retirés ("  Compiègne " -> "compiegne")
+func normalizeTagName(name string) string {
+	stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(stripAccents, name)
+	if err != nil {
+		folded = name
+	}
+	return strings.ToLower(strings.Join(strings.Fields(folded), " "))
+}
+
+// levenshtein calcule la distance d'édition entre deux chaînes (en runes)
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// similarityThreshold retourne la distance d'édition maximale pour considérer deux noms comme proches
+func similarityThreshold(name string) int {
+	if len([]rune(name)) <= 5 {
+		return 1
+	}
+	return 2
+}
+
+// resolveTagName retourne le nom canonique correspondant à un nom saisi
+// Ordre de résolution: nom exact, alias, puis nom sans tenir compte de la casse ni des accents
+func resolveTagName(db *gorm.DB, name string) (string, bool, error) {
+	name = strings.TrimSpace(name)
+
+	var tag models.Tag
+	if err := db.Where("name = ?", name).Limit(1).Find(&tag).Error; err != nil {
+		return "", false, fmt.Errorf("cannot fetch tag: %w", err)
+	}
+	if tag.Name != "" {
+		return tag.Name, true, nil
+	}
+
+	key := normalizeTagName(name)
+
+	var alias models.TagAlias
+	if err := db.Where("key = ?", key).Limit(1).Find(&alias).Error; err != nil {
+		return "", false, fmt.Errorf("cannot fetch tag alias: %w", err)
+	}
+	if alias.TagName != "" {
+		return alias.TagName, true, nil
+	}
+
+	if err := db.Where("name_key = ?", key).Order("name").Limit(1).Find(&tag).Error; err != nil {
+		return "", false, fmt.Errorf("cannot fetch tag: %w", err)
+	}
+	if tag.Name != "" {
+		return tag.Name, true, nil
+	}
+
+	return name, false, nil
+}
+
+// resolveTagNames résout une liste de noms; les noms inconnus sont conservés tels quels
+func resolveTagNames(db *gorm.DB, names []string) ([]string, error) {
+	resolved := make([]string, 0, len(names))
+	for _, name := range names {
+		canonical, found, err := resolveTagName(db, name)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			name = canonical
+		}
+		resolved = append(resolved, name)
+	}
+	return resolved, nil
+}
+
+// fillTagNameKeys calcule la forme normalisée des tags qui n'en ont pas
+// (tags créés avant son ajout, ou restaurés depuis un historique plus ancien)
+func fillTagNameKeys(db *gorm.DB) error {
+	var names []string
+	if err := db.Model(&models.Tag{}).Where("name_key = ''").Pluck("name", &names).Error; err != nil {
+		return fmt.Errorf("cannot fetch tags: %w", err)
+	}
+	for _, name := range names {
+		if err := db.Model(&models.Tag{}).Where("name = ?", name).Update("name_key", normalizeTagName(name)).Error; err != nil {
+			return fmt.Errorf("cannot update tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// EnsureTagNameKeys complète la forme normalisée des noms de tags
+// Utile au premier démarrage après l'ajout de la colonne sur une base existante
+func EnsureTagNameKeys() error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	return fillTagNameKeys(database.DB)
+}
+
+// checkTagNameAvailable vérifie qu'un nom n'entre en conflit avec aucun tag ni alias
+// (comparaison insensible à la casse et aux accents); exceptName permet d'ignorer le tag en cours de renommage
+func checkTagNameAvailable(db *gorm.DB, name string, exceptName string) error {
+	key := normalizeTagName(name)
+
+	var existing models.Tag
+	if err := db.Where("name_key = ? AND name <> ?", key, exceptName).Limit(1).Find(&existing).Error; err != nil {
+		return fmt.Errorf("cannot fetch tags: %w", err)
+	}
+	if existing.Name != "" {
+		return fmt.Errorf("tag '%s' already exists", existing.Name)
+	}
+
+	var alias models.TagAlias
+	if err := db.Where("key = ?", key).Limit(1).Find(&alias).Error; err != nil {
+		return fmt.Errorf("cannot fetch tag alias: %w", err)
+	}
+	if alias.TagName != "" && alias.TagName != exceptName {
+		return fmt.Errorf("'%s' is already an alias of tag '%s'", alias.Alias, alias.TagName)
+	}
+
+	return nil
+}
+
+// findSimilarTags retourne les tags dont le nom est proche (distance d'édition) du nom donné
+func findSimilarTags(db *gorm.DB, name string) ([]string, error) {
+	key := normalizeTagName(name)
+	threshold := similarityThreshold(key)
+
+	var names []string
+	if err := db.Model(&models.Tag{}).Pluck("name", &names).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tags: %w", err)
+	}
+
+	similar := []string{}
+	for _, existing := range names {
+		if existing == name {
+			continue
+		}
+		if levenshtein(key, normalizeTagName(existing)) <= threshold {
+			similar = append(similar, existing)
+		}
+	}
+	return similar, nil
+}
+
+// FindSimilarTags retourne les tags existants dont le nom ressemble au nom donné
+// Permet d'avertir l'utilisateur avant de créer un quasi-doublon ("Romaic" / "Romaric")
+func (ts *TagService) FindSimilarTags(name string) ([]string, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	return findSimilarTags(database.DB, strings.TrimSpace(name))
+}
+
+// AddTagAlias ajoute un alias à un tag
+func (ts *TagService) AddTagAlias(tagName string, alias string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+
+	key := normalizeTagName(alias)
+	scopes := []historyScope{{"tag_aliases", "key = ?", []interface{}{key}}}
+	return recordOperation(fmt.Sprintf("add alias '%s' to tag '%s'", alias, tagName), scopes, func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Where("name = ?", tagName).First(&tag).Error; err != nil {
+			return fmt.Errorf("tag '%s' not found", tagName)
+		}
+
+		if key == normalizeTagName(tag.Name) {
+			return fmt.Errorf("alias '%s' already matches tag '%s'", alias, tag.Name)
+		}
+		if err := checkTagNameAvailable(tx, alias, ""); err != nil {
+			return err
+		}
+
+		tagAlias := models.TagAlias{
+			Key:     key,
+			Alias:   alias,
+			TagName: tag.Name,
+		}
+
+		if err := tx.Create(&tagAlias).Error; err != nil {
+			return fmt.Errorf("cannot create tag alias: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RemoveTagAlias supprime un alias
+func (ts *TagService) RemoveTagAlias(alias string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	key := normalizeTagName(alias)
+	scopes := []historyScope{{"tag_aliases", "key = ?", []interface{}{key}}}
+	return recordOperation(fmt.Sprintf("remove alias '%s'", strings.TrimSpace(alias)), scopes, func(tx *gorm.DB) error {
+		result := tx.Where("key = ?", key).Delete(&models.TagAlias{})
+		if result.Error != nil {
+			return fmt.Errorf("cannot delete tag alias: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("alias '%s' not found", alias)
+		}
+
+		return nil
+	})
+}
+
+// GetTagAliases retourne les alias d'un tag
+func (ts *TagService) GetTagAliases(tagName string) ([]models.TagAlias, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var aliases []models.TagAlias
+	if err := database.DB.Where("tag_name = ?", tagName).Order("alias").Find(&aliases).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tag aliases: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// addAliasesForMergedTags rattache à la cible les alias des tags fusionnés,
+// et enregistre les noms des tags fusionnés comme alias de la cible
+func addAliasesForMergedTags(tx *gorm.DB, sourceNames []string, targetName string) error {
+	if err := tx.Model(&models.TagAlias{}).Where("tag_name IN ?", sourceNames).Update("tag_name", targetName).Error; err != nil {
+		return fmt.Errorf("cannot move tag aliases: %w", err)
+	}
+
+	targetKey := normalizeTagName(targetName)
+	for _, name := range sourceNames {
+		key := normalizeTagName(name)
+		if key == targetKey {
+			continue
+		}
+		alias := models.TagAlias{
+			Key:     key,
+			Alias:   name,
+			TagName: targetName,
+		}
+		if err := tx.Where(models.TagAlias{Key: key}).FirstOrCreate(&alias).Error; err != nil {
+			return fmt.Errorf("cannot create tag alias: %w", err)
+		}
+	}
+
+	return nil
+}
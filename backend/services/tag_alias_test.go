@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+)
+
+func TestResolveTagNameIgnoresCaseAndAccents(t *testing.T) {
+	setupTestDB(t)
+	tags := NewTagService()
+
+	if _, err := tags.CreateTag("Compiègne", models.TagTypeLocation, "", ""); err != nil {
+		t.Fatalf("cannot create tag: %v", err)
+	}
+
+	canonical, found, err := resolveTagName(database.DB, "  COMPIEGNE ")
+	if err != nil || !found || canonical != "Compiègne" {
+		t.Errorf("resolved to %q (found=%v, err=%v), want Compiègne", canonical, found, err)
+	}
+	if _, err := tags.CreateTag("compiegne", models.TagTypeLocation, "", ""); err == nil {
+		t.Errorf("a case and accent variant of an existing tag was created")
+	}
+
+	// Tag créé avant l'ajout de la forme normalisée
+	if err := database.DB.Create(&models.Tag{Name: "Élodie", Type: models.TagTypePerson}).Error; err != nil {
+		t.Fatalf("cannot create tag: %v", err)
+	}
+	if err := EnsureTagNameKeys(); err != nil {
+		t.Fatalf("cannot fill tag keys: %v", err)
+	}
+	if canonical, found, _ := resolveTagName(database.DB, "elodie"); !found || canonical != "Élodie" {
+		t.Errorf("legacy tag resolved to %q (found=%v)", canonical, found)
+	}
+}
+
+func TestTagAliasesAreUndoable(t *testing.T) {
+	setupTestDB(t)
+	tags := NewTagService()
+	history := NewHistoryService()
+
+	if _, err := tags.CreateTag("Robert", models.TagTypePerson, "", ""); err != nil {
+		t.Fatalf("cannot create tag: %v", err)
+	}
+	if err := tags.AddTagAlias("Robert", "Bob"); err != nil {
+		t.Fatalf("cannot add alias: %v", err)
+	}
+
+	if _, err := history.Undo(); err != nil {
+		t.Fatalf("cannot undo: %v", err)
+	}
+	if n := countRows(t, "tag_aliases", "tag_name = ?", "Robert"); n != 0 {
+		t.Errorf("alias still present after undo")
+	}
+	if _, err := history.Redo(); err != nil {
+		t.Fatalf("cannot redo: %v", err)
+	}
+
+	if err := tags.RemoveTagAlias("bob"); err != nil {
+		t.Fatalf("cannot remove alias: %v", err)
+	}
+	if _, err := history.Undo(); err != nil {
+		t.Fatalf("cannot undo: %v", err)
+	}
+	if canonical, found, _ := resolveTagName(database.DB, "BOB"); !found || canonical != "Robert" {
+		t.Errorf("alias not restored by undo: %q (found=%v)", canonical, found)
+	}
+}
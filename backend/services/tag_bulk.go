@@ -0,0 +1,248 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BulkTagStatus représente le résultat d'une opération de tag en masse pour un couple photo/tag
+type BulkTagStatus string
+
+const (
+	BulkTagAdded           BulkTagStatus = "added"           // Association créée
+	BulkTagRemoved         BulkTagStatus = "removed"         // Association supprimée
+	BulkTagUnchanged       BulkTagStatus = "unchanged"       // Rien à faire (déjà présent ou déjà absent)
+	BulkTagPictureNotFound BulkTagStatus = "pictureNotFound" // Photo non indexée
+	BulkTagTagNotFound     BulkTagStatus = "tagNotFound"     // Tag inconnu
+)
+
+// bulkTagBatchSize limite le nombre de chemins par requête (limite de variables SQLite)
+const bulkTagBatchSize = 500
+
+// BulkTagResult représente le résultat d'une opération en masse pour un couple photo/tag
+type BulkTagResult struct {
+	PicturePath string        `json:"picturePath"`
+	TagName     string        `json:"tagName"` // Nom canonique du tag (ou nom saisi s'il est inconnu)
+	Status      BulkTagStatus `json:"status"`
+}
+
+// bulkTagContext regroupe les données chargées une fois pour toute l'opération
+type bulkTagContext struct {
+	paths    []string            // Chemins demandés, sans doublon
+	indexed  map[string]bool     // Chemins présents en base
+	tags     []string            // Noms canoniques des tags connus, sans doublon
+	unknown  []string            // Noms de tags inconnus
+	existing map[string][]string // Tags actuels de chaque photo
+}
+
+// has indique si la photo porte déjà le tag
+func (c *bulkTagContext) has(path, tag string) bool {
+	for _, existing := range c.existing[path] {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// loadBulkTagContext résout les tags, vérifie les photos et charge leurs tags actuels
+func loadBulkTagContext(tx *gorm.DB, picturePaths []string, tagNames []string) (*bulkTagContext, error) {
+	c := &bulkTagContext{
+		indexed:  make(map[string]bool),
+		existing: make(map[string][]string),
+	}
+
+	seenPaths := make(map[string]bool, len(picturePaths))
+	for _, path := range picturePaths {
+		if !seenPaths[path] {
+			seenPaths[path] = true
+			c.paths = append(c.paths, path)
+		}
+	}
+
+	// Les alias et variantes de casse désignent le tag canonique
+	seenTags := make(map[string]bool, len(tagNames))
+	for _, name := range tagNames {
+		canonical, found, err := resolveTagName(tx, name)
+		if err != nil {
+			return nil, err
+		}
+		if seenTags[canonical] {
+			continue
+		}
+		seenTags[canonical] = true
+		if found {
+			c.tags = append(c.tags, canonical)
+		} else {
+			c.unknown = append(c.unknown, canonical)
+		}
+	}
+
+	for start := 0; start < len(c.paths); start += bulkTagBatchSize {
+		end := min(start+bulkTagBatchSize, len(c.paths))
+		batch := c.paths[start:end]
+
+		var indexed []string
+		if err := tx.Model(&models.Picture{}).Where("path IN ?", batch).Pluck("path", &indexed).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+		}
+		for _, path := range indexed {
+			c.indexed[path] = true
+		}
+
+		var associations []models.PictureTag
+		if err := tx.Where("picture_path IN ?", batch).Find(&associations).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch picture tags: %w", err)
+		}
+		for _, a := range associations {
+			c.existing[a.PicturePath] = append(c.existing[a.PicturePath], a.TagName)
+		}
+	}
+
+	return c, nil
+}
+
+// applyBulkTags crée puis supprime des associations en une seule transaction et rafraîchit l'index plein texte
+func applyBulkTags(tx *gorm.DB, toAdd []models.PictureTag, toRemove []models.PictureTag) error {
+	if len(toAdd) > 0 {
+		// ON CONFLICT DO NOTHING: une association créée entre-temps n'est pas une erreur
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&toAdd, bulkTagBatchSize).Error; err != nil {
+			return fmt.Errorf("cannot add tags to pictures: %w", err)
+		}
+	}
+
+	byTag := make(map[string][]string)
+	for _, a := range toRemove {
+		byTag[a.TagName] = append(byTag[a.TagName], a.PicturePath)
+	}
+	for tag, paths := range byTag {
+		for start := 0; start < len(paths); start += bulkTagBatchSize {
+			end := min(start+bulkTagBatchSize, len(paths))
+			if err := tx.Where("tag_name = ? AND picture_path IN ?", tag, paths[start:end]).Delete(&models.PictureTag{}).Error; err != nil {
+				return fmt.Errorf("cannot remove tags from pictures: %w", err)
+			}
+		}
+	}
+
+	if err := unassignFaceRegions(tx, toRemove); err != nil {
+		return err
+	}
+
+	touched := make(map[string]bool)
+	var paths []string
+	for _, list := range [][]models.PictureTag{toAdd, toRemove} {
+		for _, a := range list {
+			if !touched[a.PicturePath] {
+				touched[a.PicturePath] = true
+				paths = append(paths, a.PicturePath)
+			}
+		}
+	}
+
+	return refreshSearchIndex(tx, paths...)
+}
+
+// bulkTags exécute une opération de tag en masse
+// add indique si les tags sont ajoutés ou retirés; replace retire en plus les autres tags des photos
+func bulkTags(description string, picturePaths []string, tagNames []string, replace bool, add bool) ([]BulkTagResult, error) {
+	var results []BulkTagResult
+
+	description = fmt.Sprintf("%s on %d pictures", description, len(picturePaths))
+	scopes := append(historyScopesIn("picture_tags", "picture_path", picturePaths),
+		historyScopesIn("face_regions", "picture_path", picturePaths)...)
+	err := recordOperation(description, scopes, func(tx *gorm.DB) error {
+		c, err := loadBulkTagContext(tx, picturePaths, tagNames)
+		if err != nil {
+			return err
+		}
+
+		// Un remplacement avec un tag inconnu retirerait des tags sans poser le tag voulu
+		if replace && len(c.unknown) > 0 {
+			return fmt.Errorf("tag not found: %s", c.unknown[0])
+		}
+
+		results = make([]BulkTagResult, 0, len(c.paths)*(len(c.tags)+len(c.unknown)))
+		var toAdd, toRemove []models.PictureTag
+
+		for _, path := range c.paths {
+			for _, tag := range c.unknown {
+				results = append(results, BulkTagResult{PicturePath: path, TagName: tag, Status: BulkTagTagNotFound})
+			}
+
+			if !c.indexed[path] {
+				for _, tag := range c.tags {
+					results = append(results, BulkTagResult{PicturePath: path, TagName: tag, Status: BulkTagPictureNotFound})
+				}
+				continue
+			}
+
+			for _, tag := range c.tags {
+				status := BulkTagUnchanged
+				switch {
+				case add && !c.has(path, tag):
+					toAdd = append(toAdd, models.PictureTag{PicturePath: path, TagName: tag})
+					status = BulkTagAdded
+				case !add && c.has(path, tag):
+					toRemove = append(toRemove, models.PictureTag{PicturePath: path, TagName: tag})
+					status = BulkTagRemoved
+				}
+				results = append(results, BulkTagResult{PicturePath: path, TagName: tag, Status: status})
+			}
+
+			// Remplacement: les tags actuels absents de la liste demandée sont retirés
+			if replace {
+				wanted := make(map[string]bool, len(c.tags))
+				for _, tag := range c.tags {
+					wanted[tag] = true
+				}
+				for _, tag := range c.existing[path] {
+					if !wanted[tag] {
+						toRemove = append(toRemove, models.PictureTag{PicturePath: path, TagName: tag})
+						results = append(results, BulkTagResult{PicturePath: path, TagName: tag, Status: BulkTagRemoved})
+					}
+				}
+			}
+		}
+
+		return applyBulkTags(tx, toAdd, toRemove)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// AddTagsToPictures associe plusieurs tags à plusieurs photos en une seule transaction
+// Retourne le résultat de chaque couple photo/tag; les photos ou tags inconnus ne bloquent pas l'opération
+func (ts *TagService) AddTagsToPictures(picturePaths []string, tagNames []string) ([]BulkTagResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	return bulkTags("add tags "+strings.Join(tagNames, ", "), picturePaths, tagNames, false, true)
+}
+
+// RemoveTagsFromPictures dissocie plusieurs tags de plusieurs photos en une seule transaction
+func (ts *TagService) RemoveTagsFromPictures(picturePaths []string, tagNames []string) ([]BulkTagResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	return bulkTags("remove tags "+strings.Join(tagNames, ", "), picturePaths, tagNames, false, false)
+}
+
+// ReplaceTagsOnPictures remplace l'ensemble des tags de plusieurs photos par la liste donnée
+// Les tags absents de la liste sont retirés, les tags manquants sont ajoutés (liste vide = retirer tous les tags)
+func (ts *TagService) ReplaceTagsOnPictures(picturePaths []string, tagNames []string) ([]BulkTagResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	return bulkTags("replace tags with "+strings.Join(tagNames, ", "), picturePaths, tagNames, true, true)
+}
@@ -1,11 +1,14 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"easygallery/backend/database"
 	"easygallery/backend/models"
+
+	"gorm.io/gorm"
 )
 
 // TagService gère les opérations sur les tags
@@ -16,46 +19,83 @@ func NewTagService() *TagService {
 	return &TagService{}
 }
 
-// CreateTag crée un nouveau tag
-func (ts *TagService) CreateTag(name string, tagType models.TagType, color string) error {
+// CreateTag crée un nouveau tag, éventuellement rangé sous un tag parent (vide = racine)
+// Retourne les noms des tags existants proches du nouveau nom, à titre d'avertissement
+func (ts *TagService) CreateTag(name string, tagType models.TagType, color string, parentName string) ([]string, error) {
 	if err := checkDB(); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Valider le nom
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return fmt.Errorf("tag name cannot be empty")
+		return nil, fmt.Errorf("tag name cannot be empty")
 	}
 
-	// Valider le type
-	validTypes := map[models.TagType]bool{
-		models.TagTypePerson:   true,
-		models.TagTypeLocation: true,
-		models.TagTypeEvent:    true,
-		models.TagTypeOther:    true,
+	// Valider le type (la couleur par défaut du type s'applique si aucune n'est choisie)
+	typeDef, err := findTagType(database.DB, tagType)
+	if err != nil {
+		return nil, err
 	}
-	if !validTypes[tagType] {
-		return fmt.Errorf("invalid tag type: %s", tagType)
+	if color == "" {
+		color = typeDef.Color
 	}
 
-	// Vérifier si le tag existe déjà
-	var existing models.Tag
-	if err := database.DB.Where("name = ?", name).First(&existing).Error; err == nil {
-		return fmt.Errorf("tag '%s' already exists", name)
+	// Vérifier si le tag existe déjà (sans tenir compte de la casse ni des accents) ou s'il s'agit d'un alias
+	if err := checkTagNameAvailable(database.DB, name, ""); err != nil {
+		return nil, err
+	}
+
+	// Valider le parent (un nouveau tag n'a pas d'enfants: pas de cycle possible)
+	parent, err := tagParentRef(name, parentName)
+	if err != nil {
+		return nil, err
 	}
 
 	tag := models.Tag{
-		Name:  name,
-		Type:  tagType,
-		Color: color,
+		Name:       name,
+		NameKey:    normalizeTagName(name),
+		Type:       tagType,
+		Color:      color,
+		ParentName: parent,
 	}
 
-	if err := database.DB.Create(&tag).Error; err != nil {
-		return fmt.Errorf("cannot create tag: %w", err)
+	scopes := []historyScope{{"tags", "name = ?", []interface{}{name}}}
+	err = recordOperation(fmt.Sprintf("create tag '%s'", name), scopes, func(tx *gorm.DB) error {
+		if err := tx.Create(&tag).Error; err != nil {
+			return fmt.Errorf("cannot create tag: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// Avertir des quasi-doublons ("Romaic" / "Romaric")
+	similar, err := findSimilarTags(database.DB, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return similar, nil
+}
+
+// tagParentRef valide le tag parent et retourne la référence à stocker (nil = racine)
+func tagParentRef(name string, parentName string) (*string, error) {
+	parentName = strings.TrimSpace(parentName)
+	if parentName == "" {
+		return nil, nil
+	}
+	if parentName == name {
+		return nil, fmt.Errorf("tag '%s' cannot be its own parent", name)
+	}
+
+	var parent models.Tag
+	if err := database.DB.Where("name = ?", parentName).First(&parent).Error; err != nil {
+		return nil, fmt.Errorf("parent tag not found: %s", parentName)
+	}
+
+	return &parent.Name, nil
 }
 
 // GetAllTags retourne tous les tags
@@ -75,9 +115,19 @@ func (ts *TagService) GetAllTags() ([]models.Tag, error) {
 // TagWithCount représente un tag avec son nombre de photos associées
 type TagWithCount struct {
 	models.Tag
-	PictureCount int64 `json:"pictureCount"`
+	PictureCount        int64    `json:"pictureCount"`        // Photos portant directement le tag
+	SubtreePictureCount int64    `json:"subtreePictureCount"` // Photos portant le tag ou l'un de ses descendants
+	Aliases             []string `json:"aliases"`             // Autres noms du tag
 }
 
+// tagSubtreeSQL sélectionne les noms des tags donnés et de tous leurs descendants
+// UNION (et non UNION ALL) garantit la terminaison même si la base contenait un cycle
+const tagSubtreeSQL = `WITH RECURSIVE subtree(name) AS (
+	SELECT name FROM tags WHERE name IN (?)
+	UNION
+	SELECT tags.name FROM tags JOIN subtree ON tags.parent_name = subtree.name
+) SELECT name FROM subtree`
+
 // GetAllTagsWithCount retourne tous les tags avec leur nombre de photos
 func (ts *TagService) GetAllTagsWithCount() ([]TagWithCount, error) {
 	if err := checkDB(); err != nil {
@@ -89,21 +139,73 @@ func (ts *TagService) GetAllTagsWithCount() ([]TagWithCount, error) {
 		return nil, fmt.Errorf("cannot fetch tags: %w", err)
 	}
 
+	type tagCount struct {
+		Name  string
+		Count int64
+	}
+
+	// Nombre de photos par tag
+	var direct []tagCount
+	err := database.DB.Model(&models.PictureTag{}).
+		Select("tag_name AS name, COUNT(*) AS count").
+		Group("tag_name").
+		Scan(&direct).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot count tag pictures: %w", err)
+	}
+
+	// Nombre de photos distinctes par sous-arbre
+	var subtree []tagCount
+	err = database.DB.Raw(`
+		WITH RECURSIVE subtree(root, name) AS (
+			SELECT name, name FROM tags
+			UNION
+			SELECT subtree.root, tags.name FROM tags JOIN subtree ON tags.parent_name = subtree.name
+		)
+		SELECT subtree.root AS name, COUNT(DISTINCT picture_tags.picture_path) AS count
+		FROM subtree JOIN picture_tags ON picture_tags.tag_name = subtree.name
+		GROUP BY subtree.root`).Scan(&subtree).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot count tag subtree pictures: %w", err)
+	}
+
+	directCounts := make(map[string]int64, len(direct))
+	for _, c := range direct {
+		directCounts[c.Name] = c.Count
+	}
+	subtreeCounts := make(map[string]int64, len(subtree))
+	for _, c := range subtree {
+		subtreeCounts[c.Name] = c.Count
+	}
+
+	var aliases []models.TagAlias
+	if err := database.DB.Order("alias").Find(&aliases).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tag aliases: %w", err)
+	}
+	aliasesByTag := make(map[string][]string)
+	for _, alias := range aliases {
+		aliasesByTag[alias.TagName] = append(aliasesByTag[alias.TagName], alias.Alias)
+	}
+
 	result := make([]TagWithCount, len(tags))
 	for i, tag := range tags {
-		var count int64
-		database.DB.Model(&models.PictureTag{}).Where("tag_name = ?", tag.Name).Count(&count)
+		tagAliases := aliasesByTag[tag.Name]
+		if tagAliases == nil {
+			tagAliases = []string{}
+		}
 		result[i] = TagWithCount{
-			Tag:          tag,
-			PictureCount: count,
+			Tag:                 tag,
+			PictureCount:        directCounts[tag.Name],
+			SubtreePictureCount: subtreeCounts[tag.Name],
+			Aliases:             tagAliases,
 		}
 	}
 
 	return result, nil
 }
 
-// UpdateTag met à jour un tag existant
-func (ts *TagService) UpdateTag(name string, tagType models.TagType, color string) error {
+// UpdateTag met à jour un tag existant (type, couleur et tag parent, vide = racine)
+func (ts *TagService) UpdateTag(name string, tagType models.TagType, color string, parentName string) error {
 	if err := checkDB(); err != nil {
 		return err
 	}
@@ -113,37 +215,286 @@ func (ts *TagService) UpdateTag(name string, tagType models.TagType, color strin
 		return fmt.Errorf("tag '%s' not found", name)
 	}
 
-	tag.Type = tagType
-	tag.Color = color
+	if _, err := findTagType(database.DB, tagType); err != nil {
+		return err
+	}
 
-	if err := database.DB.Save(&tag).Error; err != nil {
-		return fmt.Errorf("cannot update tag: %w", err)
+	parent, err := tagParentRef(name, parentName)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	// Prévention des cycles: le nouveau parent ne doit pas être un descendant du tag
+	if parent != nil {
+		var descendants []string
+		if err := database.DB.Raw(tagSubtreeSQL, []string{name}).Scan(&descendants).Error; err != nil {
+			return fmt.Errorf("cannot check tag hierarchy: %w", err)
+		}
+		for _, descendant := range descendants {
+			if descendant == *parent {
+				return fmt.Errorf("tag '%s' cannot be moved under its own descendant '%s'", name, *parent)
+			}
+		}
+	}
+
+	tag.Type = tagType
+	tag.Color = color
+	tag.ParentName = parent
+
+	scopes := []historyScope{{"tags", "name = ?", []interface{}{name}}}
+	return recordOperation(fmt.Sprintf("update tag '%s'", name), scopes, func(tx *gorm.DB) error {
+		if err := tx.Save(&tag).Error; err != nil {
+			return fmt.Errorf("cannot update tag: %w", err)
+		}
+		return nil
+	})
 }
 
-// DeleteTag supprime un tag et toutes ses associations
+// DeleteTag supprime un tag et toutes ses associations (annulable via l'historique)
 func (ts *TagService) DeleteTag(name string) error {
 	if err := checkDB(); err != nil {
 		return err
 	}
 
-	// Supprimer d'abord les associations
-	if err := database.DB.Where("tag_name = ?", name).Delete(&models.PictureTag{}).Error; err != nil {
-		return fmt.Errorf("cannot delete tag associations: %w", err)
+	scopes := []historyScope{
+		{"tags", "name = ? OR parent_name = ?", []interface{}{name, name}},
+		{"picture_tags", "tag_name = ?", []interface{}{name}},
+		{"tag_aliases", "tag_name = ?", []interface{}{name}},
+		{"face_regions", "tag_name = ?", []interface{}{name}},
 	}
 
-	// Supprimer le tag
-	result := database.DB.Where("name = ?", name).Delete(&models.Tag{})
-	if result.Error != nil {
-		return fmt.Errorf("cannot delete tag: %w", result.Error)
+	return recordOperation(fmt.Sprintf("delete tag '%s'", name), scopes, func(tx *gorm.DB) error {
+		// Photos concernées, pour mettre à jour l'index plein texte
+		var paths []string
+		if err := tx.Model(&models.PictureTag{}).Where("tag_name = ?", name).Pluck("picture_path", &paths).Error; err != nil {
+			return fmt.Errorf("cannot fetch tag associations: %w", err)
+		}
+
+		// Supprimer d'abord les associations
+		if err := tx.Where("tag_name = ?", name).Delete(&models.PictureTag{}).Error; err != nil {
+			return fmt.Errorf("cannot delete tag associations: %w", err)
+		}
+
+		if err := tx.Where("tag_name = ?", name).Delete(&models.TagAlias{}).Error; err != nil {
+			return fmt.Errorf("cannot delete tag aliases: %w", err)
+		}
+
+		// Les zones de visage sont conservées, sans personne
+		if err := tx.Model(&models.FaceRegion{}).Where("tag_name = ?", name).Update("tag_name", nil).Error; err != nil {
+			return fmt.Errorf("cannot update face regions: %w", err)
+		}
+
+		// Les tags enfants remontent sous le parent du tag supprimé
+		var tag models.Tag
+		if err := tx.Where("name = ?", name).First(&tag).Error; err == nil {
+			if err := tx.Model(&models.Tag{}).Where("parent_name = ?", name).Update("parent_name", tag.ParentName).Error; err != nil {
+				return fmt.Errorf("cannot move child tags: %w", err)
+			}
+		}
+
+		// Supprimer le tag
+		result := tx.Where("name = ?", name).Delete(&models.Tag{})
+		if result.Error != nil {
+			return fmt.Errorf("cannot delete tag: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("tag '%s' not found", name)
+		}
+
+		return refreshSearchIndex(tx, paths...)
+	})
+}
+
+// RenameTag renomme un tag en conservant ses associations, ses enfants et les albums intelligents qui l'utilisent
+func (ts *TagService) RenameTag(oldName string, newName string) error {
+	if err := checkDB(); err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("tag '%s' not found", name)
+
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("tag name cannot be empty")
+	}
+	if newName == oldName {
+		return nil
 	}
 
-	return nil
+	var paths []string
+	description := fmt.Sprintf("rename tag '%s' to '%s'", oldName, newName)
+	err := recordOperation(description, tagHistoryScopes([]string{oldName, newName}), func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.Where("name = ?", oldName).First(&tag).Error; err != nil {
+			return fmt.Errorf("tag '%s' not found", oldName)
+		}
+
+		// Un changement de casse ou d'accent du même tag est autorisé
+		if err := checkTagNameAvailable(tx, newName, oldName); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.PictureTag{}).Where("tag_name = ?", oldName).Pluck("picture_path", &paths).Error; err != nil {
+			return fmt.Errorf("cannot fetch tag associations: %w", err)
+		}
+
+		// Le nom est la clé primaire: créer le nouveau tag puis supprimer l'ancien
+		renamed := tag
+		renamed.Name = newName
+		renamed.NameKey = normalizeTagName(newName)
+		if err := tx.Create(&renamed).Error; err != nil {
+			return fmt.Errorf("cannot create renamed tag: %w", err)
+		}
+
+		if err := tx.Model(&models.PictureTag{}).Where("tag_name = ?", oldName).Update("tag_name", newName).Error; err != nil {
+			return fmt.Errorf("cannot update tag associations: %w", err)
+		}
+		if err := tx.Model(&models.Tag{}).Where("parent_name = ?", oldName).Update("parent_name", newName).Error; err != nil {
+			return fmt.Errorf("cannot update child tags: %w", err)
+		}
+		if err := tx.Model(&models.FaceRegion{}).Where("tag_name = ?", oldName).Update("tag_name", newName).Error; err != nil {
+			return fmt.Errorf("cannot update face regions: %w", err)
+		}
+
+		// Les alias suivent le tag; un alias identique au nouveau nom devient inutile
+		if err := tx.Where("tag_name = ? AND key = ?", oldName, normalizeTagName(newName)).Delete(&models.TagAlias{}).Error; err != nil {
+			return fmt.Errorf("cannot update tag aliases: %w", err)
+		}
+		if err := tx.Model(&models.TagAlias{}).Where("tag_name = ?", oldName).Update("tag_name", newName).Error; err != nil {
+			return fmt.Errorf("cannot update tag aliases: %w", err)
+		}
+
+		if err := tx.Where("name = ?", oldName).Delete(&models.Tag{}).Error; err != nil {
+			return fmt.Errorf("cannot delete old tag: %w", err)
+		}
+
+		if err := replaceTagsInSmartAlbums(tx, map[string]string{oldName: newName}); err != nil {
+			return err
+		}
+
+		return refreshSearchIndex(tx, paths...)
+	})
+
+	return err
+}
+
+// MergeTags fusionne des tags dans un tag cible
+// Les photos des tags sources reçoivent le tag cible (sans doublon), les enfants des sources
+// sont rattachés à la cible (sauf ses propres ancêtres), puis les tags sources sont supprimés
+func (ts *TagService) MergeTags(sourceNames []string, targetName string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	names := append(append([]string{}, sourceNames...), targetName)
+	description := fmt.Sprintf("merge tags %s into '%s'", strings.Join(sourceNames, ", "), targetName)
+	return recordOperation(description, tagHistoryScopes(names), func(tx *gorm.DB) error {
+		var target models.Tag
+		if err := tx.Where("name = ?", targetName).First(&target).Error; err != nil {
+			return fmt.Errorf("tag '%s' not found", targetName)
+		}
+
+		var sources []models.Tag
+		if err := tx.Where("name IN ?", sourceNames).Find(&sources).Error; err != nil {
+			return fmt.Errorf("cannot fetch tags: %w", err)
+		}
+
+		sourceParents := make(map[string]*string, len(sources))
+		mapping := make(map[string]string, len(sources))
+		var names []string
+		for _, source := range sources {
+			if source.Name == targetName {
+				return fmt.Errorf("cannot merge tag '%s' into itself", targetName)
+			}
+			sourceParents[source.Name] = source.ParentName
+			mapping[source.Name] = targetName
+			names = append(names, source.Name)
+		}
+		for _, name := range sourceNames {
+			if _, ok := mapping[name]; !ok {
+				return fmt.Errorf("tag '%s' not found", name)
+			}
+		}
+
+		var paths []string
+		if err := tx.Model(&models.PictureTag{}).Where("tag_name IN ?", names).Distinct().Pluck("picture_path", &paths).Error; err != nil {
+			return fmt.Errorf("cannot fetch tag associations: %w", err)
+		}
+
+		// Réattribuer les associations; les photos qui ont déjà la cible sont ignorées
+		err := tx.Exec(`INSERT INTO picture_tags (picture_path, tag_name, created_at)
+			SELECT picture_path, ?, MIN(created_at) FROM picture_tags WHERE tag_name IN ? GROUP BY picture_path
+			ON CONFLICT DO NOTHING`, targetName, names).Error
+		if err != nil {
+			return fmt.Errorf("cannot merge tag associations: %w", err)
+		}
+		if err := tx.Where("tag_name IN ?", names).Delete(&models.PictureTag{}).Error; err != nil {
+			return fmt.Errorf("cannot delete merged tag associations: %w", err)
+		}
+
+		// Premier ancêtre conservé: un parent fusionné est remplacé par son propre parent
+		keptParent := func(parent *string) *string {
+			for parent != nil {
+				grandParent, merged := sourceParents[*parent]
+				if !merged {
+					break
+				}
+				parent = grandParent
+			}
+			return parent
+		}
+
+		// Si la cible était sous une source, elle remonte au premier ancêtre conservé
+		parent := keptParent(target.ParentName)
+		if err := tx.Model(&target).Update("parent_name", parent).Error; err != nil {
+			return fmt.Errorf("cannot update target tag: %w", err)
+		}
+
+		// Les zones de visage des sources désignent la cible
+		if err := tx.Model(&models.FaceRegion{}).Where("tag_name IN ?", names).Update("tag_name", targetName).Error; err != nil {
+			return fmt.Errorf("cannot update face regions: %w", err)
+		}
+
+		// Ancêtres de la cible après sa remontée
+		ancestors := make(map[string]bool)
+		for name := parent; name != nil; {
+			ancestors[*name] = true
+			var ancestor models.Tag
+			if err := tx.Where("name = ?", *name).First(&ancestor).Error; err != nil {
+				return fmt.Errorf("cannot check tag hierarchy: %w", err)
+			}
+			name = ancestor.ParentName
+		}
+
+		// Les enfants des sources passent sous la cible; un ancêtre de la cible remonte
+		// au premier ancêtre conservé de sa source pour ne pas créer de cycle
+		var children []models.Tag
+		if err := tx.Where("parent_name IN ? AND name <> ?", names, targetName).Find(&children).Error; err != nil {
+			return fmt.Errorf("cannot fetch child tags: %w", err)
+		}
+		for _, child := range children {
+			childParent := &targetName
+			if ancestors[child.Name] {
+				childParent = keptParent(sourceParents[*child.ParentName])
+			}
+			if err := tx.Model(&models.Tag{}).Where("name = ?", child.Name).Update("parent_name", childParent).Error; err != nil {
+				return fmt.Errorf("cannot move child tags: %w", err)
+			}
+		}
+
+		// Les alias des sources et les noms des sources deviennent des alias de la cible
+		if err := addAliasesForMergedTags(tx, names, targetName); err != nil {
+			return err
+		}
+
+		if err := tx.Where("name IN ?", names).Delete(&models.Tag{}).Error; err != nil {
+			return fmt.Errorf("cannot delete merged tags: %w", err)
+		}
+
+		if err := replaceTagsInSmartAlbums(tx, mapping); err != nil {
+			return err
+		}
+
+		return refreshSearchIndex(tx, paths...)
+	})
 }
 
 // AddTagToPicture associe un tag à une photo
@@ -158,9 +509,12 @@ func (ts *TagService) AddTagToPicture(picturePath string, tagName string) error
 		return fmt.Errorf("picture not found: %s", picturePath)
 	}
 
-	// Vérifier que le tag existe
-	var tag models.Tag
-	if err := database.DB.Where("name = ?", tagName).First(&tag).Error; err != nil {
+	// Vérifier que le tag existe (les alias et variantes de casse désignent le tag canonique)
+	tagName, found, err := resolveTagName(database.DB, tagName)
+	if err != nil {
+		return err
+	}
+	if !found {
 		return fmt.Errorf("tag not found: %s", tagName)
 	}
 
@@ -176,11 +530,13 @@ func (ts *TagService) AddTagToPicture(picturePath string, tagName string) error
 		TagName:     tagName,
 	}
 
-	if err := database.DB.Create(&pictureTag).Error; err != nil {
-		return fmt.Errorf("cannot add tag to picture: %w", err)
-	}
-
-	return nil
+	scopes := []historyScope{{"picture_tags", "picture_path = ?", []interface{}{picturePath}}}
+	return recordOperation(fmt.Sprintf("add tag '%s'", tagName), scopes, func(tx *gorm.DB) error {
+		if err := tx.Create(&pictureTag).Error; err != nil {
+			return fmt.Errorf("cannot add tag to picture: %w", err)
+		}
+		return refreshSearchIndex(tx, picturePath)
+	})
 }
 
 // RemoveTagFromPicture dissocie un tag d'une photo
@@ -189,12 +545,26 @@ func (ts *TagService) RemoveTagFromPicture(picturePath string, tagName string) e
 		return err
 	}
 
-	result := database.DB.Where("picture_path = ? AND tag_name = ?", picturePath, tagName).Delete(&models.PictureTag{})
-	if result.Error != nil {
-		return fmt.Errorf("cannot remove tag from picture: %w", result.Error)
+	if canonical, found, err := resolveTagName(database.DB, tagName); err != nil {
+		return err
+	} else if found {
+		tagName = canonical
 	}
 
-	return nil
+	scopes := []historyScope{
+		{"picture_tags", "picture_path = ?", []interface{}{picturePath}},
+		{"face_regions", "picture_path = ?", []interface{}{picturePath}},
+	}
+	return recordOperation(fmt.Sprintf("remove tag '%s'", tagName), scopes, func(tx *gorm.DB) error {
+		result := tx.Where("picture_path = ? AND tag_name = ?", picturePath, tagName).Delete(&models.PictureTag{})
+		if result.Error != nil {
+			return fmt.Errorf("cannot remove tag from picture: %w", result.Error)
+		}
+		if err := unassignFaceRegions(tx, []models.PictureTag{{PicturePath: picturePath, TagName: tagName}}); err != nil {
+			return err
+		}
+		return refreshSearchIndex(tx, picturePath)
+	})
 }
 
 // GetTagsForPicture retourne tous les tags d'une photo
@@ -216,109 +586,193 @@ func (ts *TagService) GetTagsForPicture(picturePath string) ([]models.Tag, error
 	return tags, nil
 }
 
-// TagCriteria représente les critères de recherche pour un type de tag
+// TagCriteria représente un groupe de critères de recherche, généralement les tags d'un même type
 type TagCriteria struct {
-	Tags     []string `json:"tags"`     // Liste des noms de tags
-	Operator string   `json:"operator"` // "AND" ou "OR"
+	Type     models.TagType `json:"type"`     // Type des tags du groupe (regroupement dans l'UI)
+	Tags     []string       `json:"tags"`     // Liste des noms de tags
+	Operator string         `json:"operator"` // "AND" ou "OR"
 }
 
 // SearchCriteria représente les critères de recherche avancée
 // Exemple: (Clara AND Romaric) AND (Paris OR Compiegne)
 type SearchCriteria struct {
-	Persons   TagCriteria `json:"persons"`   // Tags de type person
-	Locations TagCriteria `json:"locations"` // Tags de type location
-	Events    TagCriteria `json:"events"`    // Tags de type event
-	Others    TagCriteria `json:"others"`    // Tags de type other
+	Groups []TagCriteria `json:"groups"` // Groupes de tags, combinés avec AND entre eux
+	Text   string        `json:"text"`   // Texte libre (nom de fichier, dossiers, tags, légendes)
+
+	// Filtres de tri, combinés avec AND avec les tags
+	MinRating       int                 `json:"minRating"`       // Note minimale (0 = pas de filtre)
+	MaxRating       int                 `json:"maxRating"`       // Note maximale (0 = pas de filtre)
+	ColorLabels     []models.ColorLabel `json:"colorLabels"`     // Au moins une de ces étiquettes ("" = sans étiquette)
+	Flags           []models.PickFlag   `json:"flags"`           // Au moins une de ces marques
+	ExcludeRejected bool                `json:"excludeRejected"` // Exclure les photos rejetées
+	FavoritesOnly   bool                `json:"favoritesOnly"`   // Uniquement les photos favorites
 }
 
-// SearchPicturesAdvanced effectue une recherche avancée avec critères par type
-// Chaque groupe de type utilise son opérateur interne (AND/OR)
-// Les groupes non-vides sont combinés avec AND entre eux
-//
-// Requête SQL optimisée générée dynamiquement
-func (ts *TagService) SearchPicturesAdvanced(criteria SearchCriteria) ([]models.Picture, error) {
-	if err := checkDB(); err != nil {
-		return nil, err
+// UnmarshalJSON décode des critères, y compris l'ancien format à un champ par type
+// (persons, locations, events, others) encore présent dans les albums intelligents enregistrés
+func (c *SearchCriteria) UnmarshalJSON(data []byte) error {
+	type searchCriteriaFields SearchCriteria
+	var decoded struct {
+		searchCriteriaFields
+		Persons   *TagCriteria `json:"persons"`
+		Locations *TagCriteria `json:"locations"`
+		Events    *TagCriteria `json:"events"`
+		Others    *TagCriteria `json:"others"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
 	}
 
-	// Collecter tous les groupes non-vides avec leurs critères
-	type groupCriteria struct {
-		tags     []string
-		operator string
+	*c = SearchCriteria(decoded.searchCriteriaFields)
+
+	legacy := []struct {
+		tagType  models.TagType
+		criteria *TagCriteria
+	}{
+		{models.TagTypePerson, decoded.Persons},
+		{models.TagTypeLocation, decoded.Locations},
+		{models.TagTypeEvent, decoded.Events},
+		{models.TagTypeOther, decoded.Others},
+	}
+	for _, l := range legacy {
+		if l.criteria == nil || len(l.criteria.Tags) == 0 {
+			continue
+		}
+		group := *l.criteria
+		group.Type = l.tagType
+		c.Groups = append(c.Groups, group)
 	}
-	var groups []groupCriteria
 
-	if len(criteria.Persons.Tags) > 0 {
-		groups = append(groups, groupCriteria{criteria.Persons.Tags, criteria.Persons.Operator})
+	return nil
+}
+
+// applyCullingCriteria ajoute les filtres de note, d'étiquette de couleur, de marque et de favori
+func applyCullingCriteria(query *gorm.DB, criteria SearchCriteria) *gorm.DB {
+	if criteria.MinRating > 0 {
+		query = query.Where("pictures.rating >= ?", criteria.MinRating)
+	}
+	if criteria.MaxRating > 0 {
+		query = query.Where("pictures.rating <= ?", criteria.MaxRating)
+	}
+	if len(criteria.ColorLabels) > 0 {
+		query = query.Where("pictures.color_label IN ?", criteria.ColorLabels)
 	}
-	if len(criteria.Locations.Tags) > 0 {
-		groups = append(groups, groupCriteria{criteria.Locations.Tags, criteria.Locations.Operator})
+	if len(criteria.Flags) > 0 {
+		query = query.Where("pictures.flag IN ?", criteria.Flags)
 	}
-	if len(criteria.Events.Tags) > 0 {
-		groups = append(groups, groupCriteria{criteria.Events.Tags, criteria.Events.Operator})
+	if criteria.ExcludeRejected {
+		query = query.Where("pictures.flag <> ?", models.PickFlagReject)
 	}
-	if len(criteria.Others.Tags) > 0 {
-		groups = append(groups, groupCriteria{criteria.Others.Tags, criteria.Others.Operator})
+	if criteria.FavoritesOnly {
+		query = query.Where("pictures.favorite = ?", true)
 	}
+	return query
+}
 
-	// Si aucun critère, retourner toutes les photos
-	if len(groups) == 0 {
-		var pictures []models.Picture
-		if err := database.DB.Find(&pictures).Error; err != nil {
-			return nil, err
+// replaceTags remplace des noms de tags dans les critères (renommage ou fusion) en évitant les doublons
+func (c *SearchCriteria) replaceTags(mapping map[string]string) {
+	for i := range c.Groups {
+		group := &c.Groups[i]
+		seen := make(map[string]bool, len(group.Tags))
+		tags := make([]string, 0, len(group.Tags))
+		for _, tag := range group.Tags {
+			if replacement, ok := mapping[tag]; ok {
+				tag = replacement
+			}
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+		group.Tags = tags
+	}
+}
+
+// SearchPicturesAdvanced effectue une recherche avancée par groupes de tags
+// Chaque groupe utilise son opérateur interne (AND/OR)
+// Les groupes non-vides sont combinés avec AND entre eux
+// Les résultats sont paginés et triés selon page
+func (ts *TagService) SearchPicturesAdvanced(criteria SearchCriteria, page PageRequest) (*PicturePage, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	return searchPictures(criteria, page)
+}
+
+// searchPictures applique les critères et retourne la page demandée
+func searchPictures(criteria SearchCriteria, page PageRequest) (*PicturePage, error) {
+	if err := checkSortAvailable(page.Sort, strings.TrimSpace(criteria.Text) != "", false); err != nil {
+		return nil, err
+	}
+
+	return paginatePictures(applySearchCriteria(picturesQuery(), criteria), page)
+}
+
+// applySearchCriteria ajoute à la requête les filtres correspondant aux critères
+//
+// Requête SQL optimisée générée dynamiquement
+func applySearchCriteria(query *gorm.DB, criteria SearchCriteria) *gorm.DB {
+	// Texte libre: jointure sur l'index FTS5, qui fournit aussi le score de pertinence
+	if match := buildMatchQuery(criteria.Text); match != "" {
+		query = query.Joins(
+			"JOIN (SELECT path AS fts_path, rank AS fts_rank FROM "+database.SearchIndexTable+
+				" WHERE "+database.SearchIndexTable+" MATCH ?) AS search_fts ON search_fts.fts_path = pictures.path",
+			match,
+		)
+	}
+
+	query = applyCullingCriteria(query, criteria)
+
+	// Collecter tous les groupes non-vides
+	var groups []TagCriteria
+	for _, g := range criteria.Groups {
+		if len(g.Tags) > 0 {
+			groups = append(groups, g)
 		}
-		return pictures, nil
+	}
+
+	// Si aucun critère, toutes les photos correspondent
+	if len(groups) == 0 {
+		return query
 	}
 
 	// Construction de la requête SQL optimisée
-	// Stratégie: pour chaque groupe, on crée une sous-requête qui retourne les picture_path
+	// Stratégie: pour chaque groupe, on crée une ou plusieurs sous-requêtes qui retournent les picture_path
 	// puis on fait l'intersection (AND) de toutes ces sous-requêtes
+	// Un tag correspond aussi aux photos portant l'un de ses descendants (France -> Paris)
 	//
-	// Pour un groupe avec OR: SELECT DISTINCT picture_path FROM picture_tags WHERE tag_name IN (...)
-	// Pour un groupe avec AND: SELECT picture_path FROM picture_tags WHERE tag_name IN (...) GROUP BY picture_path HAVING COUNT(DISTINCT tag_name) = n
+	// Pour un groupe avec OR: une sous-requête sur le sous-arbre de tous les tags du groupe
+	// Pour un groupe avec AND: une sous-requête par tag, sur le sous-arbre de ce tag
 
 	var subQueries []string
 	var allArgs []interface{}
 
+	subtreeQuery := "SELECT DISTINCT picture_path FROM picture_tags WHERE tag_name IN (" + tagSubtreeSQL + ")"
+
 	for _, g := range groups {
-		if strings.ToUpper(g.operator) == "OR" || len(g.tags) == 1 {
-			// OR: au moins un tag du groupe
-			subQueries = append(subQueries,
-				"SELECT DISTINCT picture_path FROM picture_tags WHERE tag_name IN (?)")
-			allArgs = append(allArgs, g.tags)
+		// Les alias et variantes de casse désignent le tag canonique
+		tags, err := resolveTagNames(database.DB, g.Tags)
+		if err != nil {
+			query.AddError(err)
+			return query
+		}
+		if strings.ToUpper(g.Operator) == "OR" || len(tags) == 1 {
+			// OR: au moins un tag du groupe (ou un descendant)
+			subQueries = append(subQueries, subtreeQuery)
+			allArgs = append(allArgs, tags)
 		} else {
-			// AND: tous les tags du groupe
-			subQueries = append(subQueries,
-				"SELECT picture_path FROM picture_tags WHERE tag_name IN (?) GROUP BY picture_path HAVING COUNT(DISTINCT tag_name) = ?")
-			allArgs = append(allArgs, g.tags, len(g.tags))
+			// AND: chaque tag du groupe (ou l'un de ses descendants)
+			for _, tag := range tags {
+				subQueries = append(subQueries, subtreeQuery)
+				allArgs = append(allArgs, []string{tag})
+			}
 		}
 	}
 
-	// Intersection de toutes les sous-requêtes
-	// Utilise INTERSECT pour combiner les résultats (AND entre groupes)
-	var finalQuery string
-	if len(subQueries) == 1 {
-		finalQuery = subQueries[0]
-	} else {
-		// SQLite supporte INTERSECT
-		finalQuery = "(" + strings.Join(subQueries, ") INTERSECT (") + ")"
-	}
-
-	// Exécuter la requête pour obtenir les paths
-	var paths []string
-	if err := database.DB.Raw(finalQuery, allArgs...).Scan(&paths).Error; err != nil {
-		return nil, fmt.Errorf("cannot execute search query: %w", err)
-	}
-
-	if len(paths) == 0 {
-		return []models.Picture{}, nil
-	}
-
-	// Récupérer les photos correspondantes
-	var pictures []models.Picture
-	if err := database.DB.Where("path IN ?", paths).Find(&pictures).Error; err != nil {
-		return nil, fmt.Errorf("cannot fetch pictures: %w", err)
-	}
+	// Intersection de toutes les sous-requêtes (AND entre groupes)
+	// SQLite n'accepte pas de parenthèses autour des membres d'un INTERSECT
+	finalQuery := strings.Join(subQueries, " INTERSECT ")
 
-	return pictures, nil
+	return query.Where("pictures.path IN ("+finalQuery+")", allArgs...)
 }
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"testing"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+)
+
+// tagParent retourne le parent enregistré d'un tag (vide = racine)
+func tagParent(t *testing.T, name string) string {
+	t.Helper()
+
+	var tag models.Tag
+	if err := database.DB.Where("name = ?", name).First(&tag).Error; err != nil {
+		t.Fatalf("cannot fetch tag %s: %v", name, err)
+	}
+	if tag.ParentName == nil {
+		return ""
+	}
+	return *tag.ParentName
+}
+
+func TestRenameTagKeepsAssociationsAndChildren(t *testing.T) {
+	_, _, paths := setupTestLibrary(t, 2)
+	tags := NewTagService()
+
+	for _, tag := range [][2]string{{"France", ""}, {"Paris", "France"}} {
+		if _, err := tags.CreateTag(tag[0], models.TagTypeLocation, "", tag[1]); err != nil {
+			t.Fatalf("cannot create tag: %v", err)
+		}
+	}
+	if err := tags.AddTagToPicture(paths[0], "France"); err != nil {
+		t.Fatalf("cannot tag picture: %v", err)
+	}
+
+	if err := tags.RenameTag("France", "République française"); err != nil {
+		t.Fatalf("cannot rename tag: %v", err)
+	}
+
+	if n := countRows(t, "tags", "name = ?", "France"); n != 0 {
+		t.Errorf("old tag still present")
+	}
+	if n := countRows(t, "picture_tags", "picture_path = ? AND tag_name = ?", paths[0], "République française"); n != 1 {
+		t.Errorf("picture lost its tag")
+	}
+	if parent := tagParent(t, "Paris"); parent != "République française" {
+		t.Errorf("child tag under %q, want the renamed tag", parent)
+	}
+	if canonical, found, _ := resolveTagName(database.DB, "republique francaise"); !found || canonical != "République française" {
+		t.Errorf("renamed tag resolved to %q (found=%v)", canonical, found)
+	}
+}
+
+func TestMergeTagsMovesPicturesAndKeepsSourceAsAlias(t *testing.T) {
+	_, _, paths := setupTestLibrary(t, 2)
+	tags := NewTagService()
+
+	for _, name := range []string{"Bob", "Robert"} {
+		if _, err := tags.CreateTag(name, models.TagTypePerson, "", ""); err != nil {
+			t.Fatalf("cannot create tag: %v", err)
+		}
+	}
+	for _, tag := range [][2]string{{paths[0], "Bob"}, {paths[0], "Robert"}, {paths[1], "Bob"}} {
+		if err := tags.AddTagToPicture(tag[0], tag[1]); err != nil {
+			t.Fatalf("cannot tag picture: %v", err)
+		}
+	}
+
+	if err := tags.MergeTags([]string{"Bob"}, "Robert"); err != nil {
+		t.Fatalf("cannot merge tags: %v", err)
+	}
+
+	for _, path := range paths {
+		if n := countRows(t, "picture_tags", "picture_path = ? AND tag_name = ?", path, "Robert"); n != 1 {
+			t.Errorf("%s has the target tag %d times, want once", path, n)
+		}
+	}
+	if n := countRows(t, "tags", "name = ?", "Bob"); n != 0 {
+		t.Errorf("source tag still present")
+	}
+	if canonical, found, _ := resolveTagName(database.DB, "bob"); !found || canonical != "Robert" {
+		t.Errorf("source name resolved to %q (found=%v), want Robert", canonical, found)
+	}
+}
+
+func TestMergeTagIntoDescendantKeepsTreeAcyclic(t *testing.T) {
+	setupTestDB(t)
+	tags := NewTagService()
+
+	for _, tag := range [][2]string{{"Europe", ""}, {"France", "Europe"}, {"Allemagne", "Europe"}, {"Paris", "France"}} {
+		if _, err := tags.CreateTag(tag[0], models.TagTypeLocation, "", tag[1]); err != nil {
+			t.Fatalf("cannot create tag: %v", err)
+		}
+	}
+
+	if err := tags.MergeTags([]string{"Europe"}, "Paris"); err != nil {
+		t.Fatalf("cannot merge tags: %v", err)
+	}
+
+	// France, ancêtre de la cible, remonte à la racine au lieu de passer sous Paris
+	for name, want := range map[string]string{"France": "", "Paris": "France", "Allemagne": "Paris"} {
+		if parent := tagParent(t, name); parent != want {
+			t.Errorf("%s under %q, want %q", name, parent, want)
+		}
+	}
+
+	var roots, reachable []string
+	if err := database.DB.Model(&models.Tag{}).Where("parent_name IS NULL").Pluck("name", &roots).Error; err != nil {
+		t.Fatalf("cannot fetch root tags: %v", err)
+	}
+	if err := database.DB.Raw(tagSubtreeSQL, roots).Scan(&reachable).Error; err != nil {
+		t.Fatalf("cannot walk tag tree: %v", err)
+	}
+	if n := countRows(t, "tags", "1 = 1"); int(n) != len(reachable) {
+		t.Errorf("%d tags reachable from a root, want %d", len(reachable), n)
+	}
+}
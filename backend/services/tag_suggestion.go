@@ -0,0 +1,301 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxTagSuggestions   = 10            // Nombre maximal de suggestions retournées
+	suggestionTimeSpan  = 3 * time.Hour // Écart maximal de date pour les photos "prises au même moment"
+	suggestionNeighbors = 2             // Photos voisines prises en compte de chaque côté (ordre des fichiers)
+
+	// Poids de chaque source de suggestion
+	cooccurrenceWeight = 1.0
+	folderWeight       = 0.8
+	dateWeight         = 0.8
+	neighborWeight     = 0.6
+)
+
+// TagSuggestion représente un tag suggéré pour une photo, avec ses justifications
+type TagSuggestion struct {
+	TagName string   `json:"tagName"`
+	Score   float64  `json:"score"`   // Score de pertinence (plus grand = plus pertinent)
+	Reasons []string `json:"reasons"` // Explications courtes (ex: "often tagged with Clara (12 of 14 pictures)")
+}
+
+// tagSuggestionIndex met en cache les statistiques de co-occurrence des tags
+// Les photos modifiées sont marquées et recalculées à la demande (mise à jour incrémentale)
+type tagSuggestionIndex struct {
+	mu          sync.Mutex
+	loaded      bool
+	dirty       map[string]bool            // Photos dont les tags ont changé depuis le dernier calcul
+	pictureTags map[string][]string        // Tags de chaque photo pris en compte dans les compteurs
+	tagCounts   map[string]int             // Nombre de photos par tag
+	pairCounts  map[string]map[string]int  // Nombre de photos portant les deux tags
+	results     map[string][]TagSuggestion // Suggestions déjà calculées, par photo
+}
+
+// suggestionIndex est le cache partagé par les services (les photos changent via plusieurs services)
+var suggestionIndex = &tagSuggestionIndex{dirty: make(map[string]bool)}
+
+// invalidate marque des photos comme modifiées et vide le cache des suggestions calculées
+// Doit être appelé une fois les changements validés (après la transaction), sinon le cache
+// pourrait être recalculé entre-temps à partir des données d'avant la modification
+func (si *tagSuggestionIndex) invalidate(paths ...string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	for _, path := range paths {
+		si.dirty[path] = true
+	}
+	si.results = nil
+}
+
+// count ajoute (delta = 1) ou retire (delta = -1) la contribution d'une photo aux compteurs
+func (si *tagSuggestionIndex) count(tags []string, delta int) {
+	for _, a := range tags {
+		si.tagCounts[a] += delta
+		if si.tagCounts[a] <= 0 {
+			delete(si.tagCounts, a)
+		}
+		for _, b := range tags {
+			if a == b {
+				continue
+			}
+			if si.pairCounts[a] == nil {
+				si.pairCounts[a] = make(map[string]int)
+			}
+			si.pairCounts[a][b] += delta
+			if si.pairCounts[a][b] <= 0 {
+				delete(si.pairCounts[a], b)
+			}
+		}
+	}
+}
+
+// refresh charge les statistiques au premier appel, puis recalcule uniquement les photos modifiées
+// Doit être appelé avec le verrou
+func (si *tagSuggestionIndex) refresh(db *gorm.DB) error {
+	if !si.loaded {
+		var associations []models.PictureTag
+		if err := db.Find(&associations).Error; err != nil {
+			return fmt.Errorf("cannot fetch picture tags: %w", err)
+		}
+
+		si.pictureTags = make(map[string][]string)
+		for _, a := range associations {
+			si.pictureTags[a.PicturePath] = append(si.pictureTags[a.PicturePath], a.TagName)
+		}
+		si.tagCounts = make(map[string]int)
+		si.pairCounts = make(map[string]map[string]int)
+		for _, tags := range si.pictureTags {
+			si.count(tags, 1)
+		}
+
+		si.loaded = true
+		si.dirty = make(map[string]bool)
+		return nil
+	}
+
+	if len(si.dirty) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(si.dirty))
+	for path := range si.dirty {
+		paths = append(paths, path)
+	}
+
+	current := make(map[string][]string, len(paths))
+	for start := 0; start < len(paths); start += searchIndexBatchSize {
+		end := min(start+searchIndexBatchSize, len(paths))
+		var associations []models.PictureTag
+		if err := db.Where("picture_path IN ?", paths[start:end]).Find(&associations).Error; err != nil {
+			return fmt.Errorf("cannot fetch picture tags: %w", err)
+		}
+		for _, a := range associations {
+			current[a.PicturePath] = append(current[a.PicturePath], a.TagName)
+		}
+	}
+
+	for _, path := range paths {
+		si.count(si.pictureTags[path], -1)
+		if tags := current[path]; len(tags) > 0 {
+			si.pictureTags[path] = tags
+			si.count(tags, 1)
+		} else {
+			delete(si.pictureTags, path)
+		}
+	}
+
+	si.dirty = make(map[string]bool)
+	return nil
+}
+
+// suggestionScores accumule les scores et les explications par tag
+type suggestionScores struct {
+	exclude map[string]bool
+	scores  map[string]float64
+	reasons map[string][]string
+}
+
+// add ajoute une contribution au score d'un tag (ignoré s'il est déjà sur la photo)
+func (s *suggestionScores) add(tag string, score float64, reason string) {
+	if s.exclude[tag] || score <= 0 {
+		return
+	}
+	s.scores[tag] += score
+	s.reasons[tag] = append(s.reasons[tag], reason)
+}
+
+// addShared ajoute les tags portés par un groupe de photos, proportionnellement à leur fréquence
+func (s *suggestionScores) addShared(si *tagSuggestionIndex, paths []string, weight float64, describe func(count, total int) string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, path := range paths {
+		for _, tag := range si.pictureTags[path] {
+			counts[tag]++
+		}
+	}
+	for tag, count := range counts {
+		s.add(tag, weight*float64(count)/float64(len(paths)), describe(count, len(paths)))
+	}
+}
+
+// SuggestTags propose des tags pour une photo, classés par pertinence
+// Sources: tags souvent associés à ceux de la photo, tags du même dossier,
+// tags des photos prises au même moment et tags des photos voisines
+func (ts *TagService) SuggestTags(picturePath string) ([]TagSuggestion, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var picture models.Picture
+	if err := database.DB.Where("path = ?", picturePath).First(&picture).Error; err != nil {
+		return nil, fmt.Errorf("picture not found: %s", picturePath)
+	}
+
+	si := suggestionIndex
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	if err := si.refresh(database.DB); err != nil {
+		return nil, err
+	}
+	if cached, ok := si.results[picturePath]; ok {
+		return cached, nil
+	}
+
+	own := si.pictureTags[picturePath]
+	s := &suggestionScores{
+		exclude: make(map[string]bool, len(own)),
+		scores:  make(map[string]float64),
+		reasons: make(map[string][]string),
+	}
+	for _, tag := range own {
+		s.exclude[tag] = true
+	}
+
+	// Co-occurrence: P(tag | tag déjà présent)
+	for _, tag := range own {
+		total := si.tagCounts[tag]
+		for other, count := range si.pairCounts[tag] {
+			s.add(other, cooccurrenceWeight*float64(count)/float64(total),
+				fmt.Sprintf("often tagged with %s (%d of %d pictures)", tag, count, total))
+		}
+	}
+
+	// Photos du même dossier (sans les sous-dossiers), triées par nom pour trouver les voisines
+	dir := filepath.Dir(picturePath)
+	var siblings []models.Picture
+	err := database.DB.Select("path", "filename").
+		Where("path LIKE ? ESCAPE '\\'", escapeLike(dir)+"%").
+		Order("filename COLLATE NOCASE").
+		Find(&siblings).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch folder pictures: %w", err)
+	}
+
+	var folderPaths []string
+	position := -1
+	for _, sibling := range siblings {
+		if filepath.Dir(sibling.Path) != dir {
+			continue
+		}
+		if sibling.Path == picturePath {
+			position = len(folderPaths)
+		}
+		folderPaths = append(folderPaths, sibling.Path)
+	}
+
+	if position >= 0 {
+		others := append(append([]string{}, folderPaths[:position]...), folderPaths[position+1:]...)
+		s.addShared(si, others, folderWeight, func(count, total int) string {
+			return fmt.Sprintf("on %d of %d pictures in this folder", count, total)
+		})
+
+		for offset := -suggestionNeighbors; offset <= suggestionNeighbors; offset++ {
+			i := position + offset
+			if offset == 0 || i < 0 || i >= len(folderPaths) {
+				continue
+			}
+			distance := offset
+			if distance < 0 {
+				distance = -distance
+			}
+			neighbor := folderPaths[i]
+			for _, tag := range si.pictureTags[neighbor] {
+				s.add(tag, neighborWeight/float64(distance),
+					fmt.Sprintf("on neighbouring picture %s", filepath.Base(neighbor)))
+			}
+		}
+	}
+
+	// Photos prises au même moment (tous dossiers confondus)
+	if !picture.CreatedAt.IsZero() {
+		var nearby []string
+		err := database.DB.Model(&models.Picture{}).
+			Where("created_at BETWEEN ? AND ? AND path <> ?",
+				picture.CreatedAt.Add(-suggestionTimeSpan), picture.CreatedAt.Add(suggestionTimeSpan), picturePath).
+			Pluck("path", &nearby).Error
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch pictures taken at the same time: %w", err)
+		}
+		s.addShared(si, nearby, dateWeight, func(count, total int) string {
+			return fmt.Sprintf("on %d of %d pictures taken within %.0f hours", count, total, suggestionTimeSpan.Hours())
+		})
+	}
+
+	suggestions := make([]TagSuggestion, 0, len(s.scores))
+	for tag, score := range s.scores {
+		suggestions = append(suggestions, TagSuggestion{TagName: tag, Score: score, Reasons: s.reasons[tag]})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].TagName < suggestions[j].TagName
+	})
+	if len(suggestions) > maxTagSuggestions {
+		suggestions = suggestions[:maxTagSuggestions]
+	}
+
+	if si.results == nil {
+		si.results = make(map[string][]TagSuggestion)
+	}
+	si.results[picturePath] = suggestions
+
+	return suggestions, nil
+}
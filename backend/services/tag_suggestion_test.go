@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+func TestSuggestionsIgnoreUncommittedChanges(t *testing.T) {
+	_, _, paths := setupTestLibrary(t, 2)
+	tags := NewTagService()
+
+	if _, err := tags.CreateTag("Plage", models.TagTypeOther, "", ""); err != nil {
+		t.Fatalf("cannot create tag: %v", err)
+	}
+	if _, err := tags.SuggestTags(paths[1]); err != nil {
+		t.Fatalf("cannot suggest tags: %v", err)
+	}
+
+	// Suggestions demandées pendant la transaction: elles ne voient pas encore le nouveau tag
+	scopes := []historyScope{{"picture_tags", "picture_path = ?", []interface{}{paths[0]}}}
+	err := recordOperation("add tag 'Plage'", scopes, func(tx *gorm.DB) error {
+		if err := tx.Create(&models.PictureTag{PicturePath: paths[0], TagName: "Plage"}).Error; err != nil {
+			return err
+		}
+		if err := refreshSearchIndex(tx, paths[0]); err != nil {
+			return err
+		}
+		_, err := tags.SuggestTags(paths[1])
+		return err
+	})
+	if err != nil {
+		t.Fatalf("cannot tag picture: %v", err)
+	}
+
+	suggestions, err := tags.SuggestTags(paths[1])
+	if err != nil {
+		t.Fatalf("cannot suggest tags: %v", err)
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.TagName == "Plage" {
+			return
+		}
+	}
+	t.Errorf("suggestions computed before the commit are still cached: %+v", suggestions)
+}
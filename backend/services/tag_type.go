@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// GetTagTypes retourne tous les types de tags, dans l'ordre d'affichage
+func (ts *TagService) GetTagTypes() ([]models.TagTypeDefinition, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var types []models.TagTypeDefinition
+	if err := database.DB.Order("sort_order").Order("label").Find(&types).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tag types: %w", err)
+	}
+
+	return types, nil
+}
+
+// CreateTagType crée un type de tag défini par l'utilisateur (ex: "project", "client", "camera body")
+func (ts *TagService) CreateTagType(name string, label string, icon string, color string, sortOrder int) (*models.TagTypeDefinition, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("tag type name cannot be empty")
+	}
+	label = strings.TrimSpace(label)
+	if label == "" {
+		label = name
+	}
+
+	// Les types ne doivent pas se distinguer uniquement par la casse ou les accents
+	var existing []models.TagType
+	if err := database.DB.Model(&models.TagTypeDefinition{}).Pluck("name", &existing).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tag types: %w", err)
+	}
+	for _, existingName := range existing {
+		if normalizeTagName(string(existingName)) == normalizeTagName(name) {
+			return nil, fmt.Errorf("tag type '%s' already exists", existingName)
+		}
+	}
+
+	tagType := models.TagTypeDefinition{
+		Name:      models.TagType(name),
+		Label:     label,
+		Icon:      icon,
+		Color:     color,
+		SortOrder: sortOrder,
+	}
+
+	if err := database.DB.Create(&tagType).Error; err != nil {
+		return nil, fmt.Errorf("cannot create tag type: %w", err)
+	}
+
+	return &tagType, nil
+}
+
+// UpdateTagType met à jour le libellé, l'icône, la couleur par défaut et l'ordre d'un type
+// L'identifiant d'un type ne change pas: les tags et critères enregistrés y font référence
+func (ts *TagService) UpdateTagType(name string, label string, icon string, color string, sortOrder int) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var tagType models.TagTypeDefinition
+	if err := database.DB.Where("name = ?", name).First(&tagType).Error; err != nil {
+		return fmt.Errorf("tag type '%s' not found", name)
+	}
+
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return fmt.Errorf("tag type label cannot be empty")
+	}
+
+	tagType.Label = label
+	tagType.Icon = icon
+	tagType.Color = color
+	tagType.SortOrder = sortOrder
+
+	if err := database.DB.Save(&tagType).Error; err != nil {
+		return fmt.Errorf("cannot update tag type: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTagType supprime un type défini par l'utilisateur
+// Les tags de ce type sont rattachés au type "other"; les types prédéfinis ne peuvent pas être supprimés
+func (ts *TagService) DeleteTagType(name string) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var tagType models.TagTypeDefinition
+	if err := database.DB.Where("name = ?", name).First(&tagType).Error; err != nil {
+		return fmt.Errorf("tag type '%s' not found", name)
+	}
+	if tagType.BuiltIn {
+		return fmt.Errorf("built-in tag type '%s' cannot be deleted", name)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Tag{}).Where("type = ?", name).Update("type", models.TagTypeOther).Error; err != nil {
+			return fmt.Errorf("cannot update tags of type '%s': %w", name, err)
+		}
+		if err := tx.Delete(&tagType).Error; err != nil {
+			return fmt.Errorf("cannot delete tag type: %w", err)
+		}
+		return nil
+	})
+}
+
+// findTagType retourne la définition d'un type de tag, ou une erreur si le type n'existe pas
+func findTagType(db *gorm.DB, name models.TagType) (*models.TagTypeDefinition, error) {
+	var tagType models.TagTypeDefinition
+	if err := db.Where("name = ?", name).Limit(1).Find(&tagType).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tag type: %w", err)
+	}
+	if tagType.Name == "" {
+		return nil, fmt.Errorf("invalid tag type: %s", name)
+	}
+	return &tagType, nil
+}
@@ -0,0 +1,635 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// trashRetentionDays est la durée de conservation des photos dans la corbeille avant suppression définitive
+const trashRetentionDays = 30
+
+// trashedFile désigne un fichier déplacé dans la corbeille
+type trashedFile struct {
+	path     string // Emplacement du fichier dans la corbeille
+	infoPath string // Fichier .trashinfo (corbeille freedesktop.org, vide sinon)
+}
+
+// trashSnapshot conserve l'état en base d'une photo mise à la corbeille
+type trashSnapshot struct {
+	Picture     map[string]interface{} `json:"picture"`     // Ligne de la table pictures
+	Tags        []string               `json:"tags"`        // Tags associés
+	Albums      []uint                 `json:"albums"`      // Albums contenant la photo
+	FaceRegions []models.FaceRegion    `json:"faceRegions"` // Zones de visage (les signatures sont recalculées)
+	FaceScan    *models.FaceScan       `json:"faceScan"`    // Dernière détection de visages
+	Views       []time.Time            `json:"views"`       // Historique de consultation
+}
+
+// freedesktopTrashDir retourne la corbeille de l'utilisateur selon la spécification freedesktop.org
+// (vide hors Linux)
+func freedesktopTrashDir() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash")
+}
+
+// trashFileName retourne le n-ième nom candidat dans la corbeille (photo.jpg, photo.2.jpg, ...)
+func trashFileName(name string, n int) string {
+	if n == 1 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + strconv.Itoa(n) + ext
+}
+
+// moveToTrash déplace un fichier dans la corbeille freedesktop.org sous Linux,
+// ou dans la corbeille de l'application (dataDir/trash) si elle est indisponible
+func moveToTrash(dataDir string, path string) (trashedFile, error) {
+	if trashDir := freedesktopTrashDir(); trashDir != "" {
+		file, err := moveToFreedesktopTrash(trashDir, path)
+		if err == nil {
+			return file, nil
+		}
+		fmt.Printf("Warning: cannot use system trash for %s, using application trash: %v\n", path, err)
+	}
+	return moveToAppTrash(filepath.Join(dataDir, "trash"), path)
+}
+
+// moveToFreedesktopTrash déplace un fichier dans trashDir/files et décrit son origine dans trashDir/info
+// Le fichier doit être sur le même volume que la corbeille (simple renommage)
+func moveToFreedesktopTrash(trashDir string, path string) (trashedFile, error) {
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return trashedFile{}, err
+		}
+	}
+
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+
+	for n := 1; ; n++ {
+		name := trashFileName(filepath.Base(path), n)
+		infoPath := filepath.Join(infoDir, name+".trashinfo")
+		filePath := filepath.Join(filesDir, name)
+
+		// La création exclusive du .trashinfo réserve le nom
+		infoFile, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return trashedFile{}, err
+		}
+		if _, err := os.Lstat(filePath); err == nil {
+			infoFile.Close()
+			os.Remove(infoPath)
+			continue
+		}
+
+		_, err = infoFile.WriteString(info)
+		if closeErr := infoFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(path, filePath)
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return trashedFile{}, err
+		}
+		return trashedFile{path: filePath, infoPath: infoPath}, nil
+	}
+}
+
+// moveToAppTrash déplace un fichier dans la corbeille de l'application
+func moveToAppTrash(trashDir string, path string) (trashedFile, error) {
+	if err := os.MkdirAll(trashDir, 0755); err != nil {
+		return trashedFile{}, err
+	}
+
+	for n := 1; ; n++ {
+		filePath := filepath.Join(trashDir, trashFileName(filepath.Base(path), n))
+		if _, err := os.Lstat(filePath); err == nil {
+			continue
+		}
+		if err := moveFile(path, filePath); err != nil {
+			return trashedFile{}, err
+		}
+		return trashedFile{path: filePath}, nil
+	}
+}
+
+// moveFile déplace un fichier, par copie puis suppression s'il change de volume
+// La destination ne doit pas exister
+func moveFile(src string, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("file already exists: %s", dst)
+	}
+
+	renameErr := os.Rename(src, dst)
+	if renameErr == nil {
+		return nil
+	}
+	if _, err := os.Stat(src); err != nil {
+		return renameErr
+	}
+
+	if err := copyFile(src, dst); err != nil {
+		return fmt.Errorf("cannot move %s: %w", src, err)
+	}
+	if err := os.Remove(src); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("cannot move %s: %w", src, err)
+	}
+	return nil
+}
+
+// copyFile copie un fichier en conservant ses droits et sa date de modification
+// (l'indexeur ne le considère pas comme modifié)
+func copyFile(src string, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// removeTrashedFile supprime définitivement un fichier de la corbeille (un fichier déjà absent n'est pas une erreur)
+func removeTrashedFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// trashPicture déplace une photo et son sidecar XMP dans la corbeille et la retire de l'index
+// Ses tags, ses albums et ses métadonnées sont conservés pour pouvoir la restaurer
+func trashPicture(dataDir string, picturePath string) error {
+	var row map[string]interface{}
+	if err := database.DB.Table("pictures").Where("path = ?", picturePath).Take(&row).Error; err != nil {
+		return fmt.Errorf("picture not found in database: %s", picturePath)
+	}
+	normalizeHistoryValues(row)
+
+	fileInfo, err := os.Stat(picturePath)
+	if err != nil {
+		return fmt.Errorf("picture file not found: %w", err)
+	}
+	sidecar, _ := findXMPSidecar(picturePath)
+
+	file, err := moveToTrash(dataDir, picturePath)
+	if err != nil {
+		return fmt.Errorf("cannot move picture to trash: %w", err)
+	}
+	var sidecarFile trashedFile
+	if sidecar != "" {
+		if sidecarFile, err = moveToTrash(dataDir, sidecar); err != nil {
+			untrashFile(file, picturePath)
+			return fmt.Errorf("cannot move sidecar to trash: %w", err)
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		snapshot := trashSnapshot{Picture: row}
+		if err := tx.Model(&models.PictureTag{}).Where("picture_path = ?", picturePath).Order("tag_name").Pluck("tag_name", &snapshot.Tags).Error; err != nil {
+			return fmt.Errorf("cannot fetch tags of picture: %w", err)
+		}
+		if err := tx.Model(&models.AlbumPicture{}).Where("picture_path = ?", picturePath).Order("album_id").Pluck("album_id", &snapshot.Albums).Error; err != nil {
+			return fmt.Errorf("cannot fetch albums of picture: %w", err)
+		}
+		if snapshot.FaceRegions, err = faceRegionsForPicture(tx, picturePath); err != nil {
+			return err
+		}
+		var scans []models.FaceScan
+		if err := tx.Where("picture_path = ?", picturePath).Limit(1).Find(&scans).Error; err != nil {
+			return fmt.Errorf("cannot fetch face scan: %w", err)
+		}
+		if len(scans) > 0 {
+			snapshot.FaceScan = &scans[0]
+		}
+		if err := tx.Model(&models.PictureView{}).Where("picture_path = ?", picturePath).Order("viewed_at").Pluck("viewed_at", &snapshot.Views).Error; err != nil {
+			return fmt.Errorf("cannot fetch views of picture: %w", err)
+		}
+		encoded, err := json.Marshal(snapshot)
+		if err != nil {
+			return fmt.Errorf("cannot encode picture: %w", err)
+		}
+
+		if err := tx.Delete(&models.Picture{}, "path = ?", picturePath).Error; err != nil {
+			return fmt.Errorf("cannot delete picture from database: %w", err)
+		}
+		if err := tx.Where("picture_path = ?", picturePath).Delete(&models.PictureTag{}).Error; err != nil {
+			return fmt.Errorf("cannot remove tags from picture: %w", err)
+		}
+		if err := removePicturesFromAllAlbums(tx, picturePath); err != nil {
+			return err
+		}
+		if err := deletePictureData(tx, picturePath); err != nil {
+			return err
+		}
+		if err := removeFromSearchIndex(tx, picturePath); err != nil {
+			return err
+		}
+		// L'historique ne doit plus recréer la photo: elle se restaure depuis la corbeille
+		if err := rewriteHistoryPaths(tx, map[string]string{picturePath: ""}); err != nil {
+			return err
+		}
+
+		trashed := models.TrashedPicture{
+			OriginalPath:         picturePath,
+			TrashPath:            file.path,
+			TrashInfoPath:        file.infoPath,
+			SidecarPath:          sidecar,
+			SidecarTrashPath:     sidecarFile.path,
+			SidecarTrashInfoPath: sidecarFile.infoPath,
+			Size:                 fileInfo.Size(),
+			Snapshot:             string(encoded),
+		}
+		if err := tx.Create(&trashed).Error; err != nil {
+			return fmt.Errorf("cannot record trashed picture: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// Remettre les fichiers en place: la photo reste dans l'index
+		untrashFile(file, picturePath)
+		if sidecar != "" {
+			untrashFile(sidecarFile, sidecar)
+		}
+		return err
+	}
+
+	suggestionIndex.invalidate(picturePath)
+	return nil
+}
+
+// deletePictureData supprime les zones de visage (et leurs signatures), la détection de visages
+// et l'historique de consultation d'une photo retirée de l'index
+func deletePictureData(tx *gorm.DB, picturePath string) error {
+	err := tx.Where("region_id IN (SELECT id FROM face_regions WHERE picture_path = ?)", picturePath).Delete(&models.FaceEmbedding{}).Error
+	if err != nil {
+		return fmt.Errorf("cannot delete face signatures: %w", err)
+	}
+	if err := tx.Where("picture_path = ?", picturePath).Delete(&models.FaceRegion{}).Error; err != nil {
+		return fmt.Errorf("cannot delete face regions: %w", err)
+	}
+	if err := tx.Where("picture_path = ?", picturePath).Delete(&models.FaceScan{}).Error; err != nil {
+		return fmt.Errorf("cannot delete face scan: %w", err)
+	}
+	if err := tx.Where("picture_path = ?", picturePath).Delete(&models.PictureView{}).Error; err != nil {
+		return fmt.Errorf("cannot delete views of picture: %w", err)
+	}
+	return nil
+}
+
+// restorePictureData recrée les zones de visage, la détection de visages et l'historique de consultation d'une photo
+// Les zones reçoivent de nouveaux identifiants; une personne supprimée entre-temps redevient un visage non identifié
+func restorePictureData(tx *gorm.DB, picturePath string, snapshot trashSnapshot) error {
+	for _, region := range snapshot.FaceRegions {
+		region.ID = 0
+		region.PicturePath = picturePath
+		if region.TagName != nil {
+			var count int64
+			if err := tx.Model(&models.Tag{}).Where("name = ?", *region.TagName).Count(&count).Error; err != nil {
+				return fmt.Errorf("cannot fetch tags: %w", err)
+			}
+			if count == 0 {
+				region.TagName = nil
+			}
+		}
+		if err := tx.Create(&region).Error; err != nil {
+			return fmt.Errorf("cannot restore face region: %w", err)
+		}
+	}
+
+	if snapshot.FaceScan != nil {
+		scan := *snapshot.FaceScan
+		scan.PicturePath = picturePath
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&scan).Error; err != nil {
+			return fmt.Errorf("cannot restore face scan: %w", err)
+		}
+	}
+
+	for _, viewedAt := range snapshot.Views {
+		view := models.PictureView{PicturePath: picturePath, ViewedAt: viewedAt}
+		if err := tx.Create(&view).Error; err != nil {
+			return fmt.Errorf("cannot restore views of picture: %w", err)
+		}
+	}
+	return nil
+}
+
+// untrashFile remet un fichier de la corbeille à son emplacement d'origine
+func untrashFile(file trashedFile, originalPath string) error {
+	if err := moveFile(file.path, originalPath); err != nil {
+		return err
+	}
+	if err := removeTrashedFile(file.infoPath); err != nil {
+		fmt.Printf("Warning: cannot remove trash info %s: %v\n", file.infoPath, err)
+	}
+	return nil
+}
+
+// TrashService gère la corbeille: restauration des photos supprimées et vidage
+type TrashService struct {
+	dataDir string
+}
+
+// NewTrashService crée une nouvelle instance de TrashService
+func NewTrashService(dataDir string) *TrashService {
+	return &TrashService{
+		dataDir: dataDir,
+	}
+}
+
+// TrashPurgeResult résume un vidage de la corbeille
+type TrashPurgeResult struct {
+	Deleted int      `json:"deleted"` // Photos supprimées définitivement
+	Freed   int64    `json:"freed"`   // Espace libéré en bytes
+	Errors  []string `json:"errors"`  // Fichiers en échec
+}
+
+// GetTrash retourne les photos de la corbeille, de la plus récemment supprimée à la plus ancienne
+func (ts *TrashService) GetTrash() ([]models.TrashedPicture, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var trashed []models.TrashedPicture
+	if err := database.DB.Order("deleted_at DESC, id DESC").Find(&trashed).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch trash: %w", err)
+	}
+	return trashed, nil
+}
+
+// RestorePicture remet une photo de la corbeille à son emplacement d'origine
+// et la réindexe avec ses tags, ses albums et ses métadonnées
+func (ts *TrashService) RestorePicture(id uint) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	var trashed models.TrashedPicture
+	if err := database.DB.First(&trashed, id).Error; err != nil {
+		return fmt.Errorf("trashed picture not found: %d", id)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(trashed.Snapshot)))
+	decoder.UseNumber()
+	var snapshot trashSnapshot
+	if err := decoder.Decode(&snapshot); err != nil {
+		return fmt.Errorf("invalid trash entry %d: %w", id, err)
+	}
+
+	// Une photo remise en place hors de l'application (ex: depuis la corbeille du système)
+	// n'est plus dans la corbeille mais déjà à son emplacement d'origine: il reste à la réindexer,
+	// en remplaçant ce qu'une indexation a pu enregistrer entre-temps par l'état conservé
+	file := trashedFile{path: trashed.TrashPath, infoPath: trashed.TrashInfoPath}
+	_, trashErr := os.Stat(file.path)
+	_, originalErr := os.Lstat(trashed.OriginalPath)
+	restoredOutside := trashErr != nil && originalErr == nil
+	if originalErr == nil && !restoredOutside {
+		return fmt.Errorf("a file already exists at %s", trashed.OriginalPath)
+	}
+	var existing int64
+	if err := database.DB.Model(&models.Picture{}).Where("path = ?", trashed.OriginalPath).Count(&existing).Error; err != nil {
+		return fmt.Errorf("cannot check picture: %w", err)
+	}
+	if existing > 0 && !restoredOutside {
+		return fmt.Errorf("a picture is already indexed at %s", trashed.OriginalPath)
+	}
+
+	// Remettre les fichiers en place (le dossier d'origine a pu être supprimé entre-temps)
+	sidecarFile := trashedFile{path: trashed.SidecarTrashPath, infoPath: trashed.SidecarTrashInfoPath}
+	sidecarRestored := false
+	if !restoredOutside {
+		if trashErr != nil {
+			return fmt.Errorf("picture is no longer in the trash: %s", filepath.Base(trashed.OriginalPath))
+		}
+		if err := os.MkdirAll(filepath.Dir(trashed.OriginalPath), 0755); err != nil {
+			return fmt.Errorf("cannot create folder: %w", err)
+		}
+		if err := moveFile(file.path, trashed.OriginalPath); err != nil {
+			return fmt.Errorf("cannot restore picture file: %w", err)
+		}
+		if trashed.SidecarPath != "" {
+			if err := moveFile(sidecarFile.path, trashed.SidecarPath); err != nil {
+				fmt.Printf("Warning: cannot restore sidecar %s: %v\n", trashed.SidecarPath, err)
+			} else {
+				sidecarRestored = true
+			}
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if existing > 0 {
+			if err := deletePictureData(tx, trashed.OriginalPath); err != nil {
+				return err
+			}
+		}
+		dateColumns, err := historyDateColumns(tx, "pictures")
+		if err != nil {
+			return err
+		}
+		if err := setHistoryRow(tx, "pictures", snapshot.Picture, snapshot.Picture, dateColumns); err != nil {
+			return err
+		}
+
+		// Tags et albums supprimés entre-temps ne sont pas recréés
+		var tagNames []string
+		if len(snapshot.Tags) > 0 {
+			if err := tx.Model(&models.Tag{}).Where("name IN ?", snapshot.Tags).Pluck("name", &tagNames).Error; err != nil {
+				return fmt.Errorf("cannot fetch tags: %w", err)
+			}
+		}
+		for _, name := range tagNames {
+			pictureTag := models.PictureTag{PicturePath: trashed.OriginalPath, TagName: name}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&pictureTag).Error; err != nil {
+				return fmt.Errorf("cannot restore tag %s: %w", name, err)
+			}
+		}
+
+		var albumIDs []uint
+		if len(snapshot.Albums) > 0 {
+			if err := tx.Model(&models.Album{}).Where("id IN ?", snapshot.Albums).Pluck("id", &albumIDs).Error; err != nil {
+				return fmt.Errorf("cannot fetch albums: %w", err)
+			}
+		}
+		for _, albumID := range albumIDs {
+			// La photo revient à la fin de l'album
+			var count int64
+			if err := tx.Model(&models.AlbumPicture{}).Where("album_id = ?", albumID).Count(&count).Error; err != nil {
+				return fmt.Errorf("cannot fetch album pictures: %w", err)
+			}
+			entry := models.AlbumPicture{AlbumID: albumID, PicturePath: trashed.OriginalPath, Position: int(count)}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error; err != nil {
+				return fmt.Errorf("cannot restore album entry: %w", err)
+			}
+			if err := touchAlbum(tx, albumID); err != nil {
+				return err
+			}
+		}
+
+		if err := restorePictureData(tx, trashed.OriginalPath, snapshot); err != nil {
+			return err
+		}
+
+		if err := refreshSearchIndex(tx, trashed.OriginalPath); err != nil {
+			return err
+		}
+		if err := tx.Delete(&trashed).Error; err != nil {
+			return fmt.Errorf("cannot remove trash entry: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// Renvoyer les fichiers dans la corbeille: l'entrée reste restaurable
+		if !restoredOutside {
+			moveFile(trashed.OriginalPath, file.path)
+		}
+		if sidecarRestored {
+			moveFile(trashed.SidecarPath, sidecarFile.path)
+		}
+		return err
+	}
+
+	suggestionIndex.invalidate(trashed.OriginalPath)
+
+	for _, info := range []string{file.infoPath, sidecarFile.infoPath} {
+		if err := removeTrashedFile(info); err != nil {
+			fmt.Printf("Warning: cannot remove trash info %s: %v\n", info, err)
+		}
+	}
+
+	// Le fichier remis en place hors de l'application a pu changer: mettre à jour ses métadonnées
+	if restoredOutside {
+		folders, err := watchedFoldersByDepth(database.DB)
+		if err != nil {
+			return err
+		}
+		if _, err := NewIndexer(ts.dataDir).indexImage(trashed.OriginalPath, metadataPolicyFor(folders, trashed.OriginalPath)); err != nil {
+			return fmt.Errorf("cannot index picture: %w", err)
+		}
+	}
+	return nil
+}
+
+// EmptyTrash supprime définitivement les photos mises à la corbeille depuis plus de olderThanDays jours
+// (0 = toute la corbeille)
+func (ts *TrashService) EmptyTrash(olderThanDays int) (*TrashPurgeResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+	if olderThanDays < 0 {
+		return nil, fmt.Errorf("invalid retention: %d days", olderThanDays)
+	}
+
+	query := database.DB.Order("deleted_at")
+	if olderThanDays > 0 {
+		query = query.Where("deleted_at < ?", time.Now().AddDate(0, 0, -olderThanDays))
+	}
+	var trashed []models.TrashedPicture
+	if err := query.Find(&trashed).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch trash: %w", err)
+	}
+
+	result := &TrashPurgeResult{Errors: []string{}}
+	for _, item := range trashed {
+		var failed error
+		for _, path := range []string{item.TrashPath, item.TrashInfoPath, item.SidecarTrashPath, item.SidecarTrashInfoPath} {
+			if err := removeTrashedFile(path); err != nil && failed == nil {
+				failed = err
+			}
+		}
+		if failed != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", item.OriginalPath, failed))
+			continue
+		}
+
+		// Les tags et albums de la photo disparaissent avec son entrée
+		// Les données rattachées au chemin (conservées par les entrées plus anciennes) sont supprimées
+		// si aucune photo n'a été indexée depuis au même emplacement
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var existing int64
+			if err := tx.Model(&models.Picture{}).Where("path = ?", item.OriginalPath).Count(&existing).Error; err != nil {
+				return fmt.Errorf("cannot check picture: %w", err)
+			}
+			if existing == 0 {
+				if err := deletePictureData(tx, item.OriginalPath); err != nil {
+					return err
+				}
+			}
+			if err := tx.Delete(&item).Error; err != nil {
+				return fmt.Errorf("cannot remove trash entry: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Deleted++
+		result.Freed += item.Size
+	}
+	return result, nil
+}
+
+// PurgeExpiredTrash supprime définitivement les photos restées dans la corbeille au-delà de la durée de conservation
+func (ts *TrashService) PurgeExpiredTrash() (*TrashPurgeResult, error) {
+	return ts.EmptyTrash(trashRetentionDays)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"os"
+	"testing"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+)
+
+// trashTestPicture met une photo à la corbeille après lui avoir ajouté une zone de visage et une consultation
+// Retourne l'entrée de la corbeille et la date de création enregistrée de la photo
+func trashTestPicture(t *testing.T, indexer *Indexer, path string) (models.TrashedPicture, string) {
+	t.Helper()
+
+	region := models.FaceRegion{PicturePath: path, X: 0.1, Y: 0.2, Width: 0.3, Height: 0.4}
+	if err := database.DB.Create(&region).Error; err != nil {
+		t.Fatalf("cannot create face region: %v", err)
+	}
+	if err := database.DB.Create(&models.PictureView{PicturePath: path}).Error; err != nil {
+		t.Fatalf("cannot create view: %v", err)
+	}
+
+	var createdAt string
+	if err := database.DB.Raw("SELECT CAST(created_at AS TEXT) FROM pictures WHERE path = ?", path).Scan(&createdAt).Error; err != nil {
+		t.Fatalf("cannot fetch picture: %v", err)
+	}
+
+	if err := indexer.DeletePicture(path, true); err != nil {
+		t.Fatalf("cannot trash picture: %v", err)
+	}
+	var trashed models.TrashedPicture
+	if err := database.DB.Where("original_path = ?", path).First(&trashed).Error; err != nil {
+		t.Fatalf("trash entry not found: %v", err)
+	}
+	return trashed, createdAt
+}
+
+func TestTrashAndRestoreKeepsPictureData(t *testing.T) {
+	indexer, _, paths := setupTestLibrary(t, 2)
+	trashed, createdAt := trashTestPicture(t, indexer, paths[0])
+
+	if n := countRows(t, "face_regions", "picture_path = ?", paths[0]); n != 0 {
+		t.Errorf("face regions of the trashed picture are still listed")
+	}
+
+	if err := NewTrashService(indexer.dataDir).RestorePicture(trashed.ID); err != nil {
+		t.Fatalf("cannot restore picture: %v", err)
+	}
+	if n := countRows(t, "pictures", "path = ? AND CAST(created_at AS TEXT) = ?", paths[0], createdAt); n != 1 {
+		t.Errorf("restored picture does not keep its stored created_at %q", createdAt)
+	}
+	if n := countRows(t, "face_regions", "picture_path = ? AND width = 0.3", paths[0]); n != 1 {
+		t.Errorf("face region not restored")
+	}
+	if n := countRows(t, "picture_views", "picture_path = ?", paths[0]); n != 1 {
+		t.Errorf("views not restored")
+	}
+	if walked := walkAllPictures(t, indexer, SortByIndexedDate, true); len(walked) != 2 {
+		t.Errorf("got %d pictures after restore, want 2", len(walked))
+	}
+}
+
+func TestEmptyTrashDeletesPictureData(t *testing.T) {
+	indexer, _, paths := setupTestLibrary(t, 2)
+	trashTestPicture(t, indexer, paths[0])
+
+	// Données laissées par une mise à la corbeille antérieure
+	if err := database.DB.Create(&models.FaceScan{PicturePath: paths[0], Detector: "test"}).Error; err != nil {
+		t.Fatalf("cannot create face scan: %v", err)
+	}
+
+	result, err := NewTrashService(indexer.dataDir).EmptyTrash(0)
+	if err != nil {
+		t.Fatalf("cannot empty trash: %v", err)
+	}
+	if result.Deleted != 1 {
+		t.Fatalf("got %d deleted pictures, want 1: %v", result.Deleted, result.Errors)
+	}
+	for _, table := range []string{"face_regions", "face_scans", "picture_views"} {
+		if n := countRows(t, table, "picture_path = ?", paths[0]); n != 0 {
+			t.Errorf("%s rows left after emptying the trash", table)
+		}
+	}
+}
+
+func TestRestorePictureAlreadyPutBack(t *testing.T) {
+	indexer, _, paths := setupTestLibrary(t, 2)
+	trashed, _ := trashTestPicture(t, indexer, paths[0])
+
+	// Photo remise en place depuis la corbeille du système
+	if err := os.Rename(trashed.TrashPath, paths[0]); err != nil {
+		t.Fatalf("cannot put picture back: %v", err)
+	}
+
+	if err := NewTrashService(indexer.dataDir).RestorePicture(trashed.ID); err != nil {
+		t.Fatalf("cannot restore picture: %v", err)
+	}
+	if n := countRows(t, "pictures", "path = ?", paths[0]); n != 1 {
+		t.Errorf("picture not indexed again")
+	}
+	if n := countRows(t, "trashed_pictures", "id = ?", trashed.ID); n != 0 {
+		t.Errorf("trash entry not dropped")
+	}
+}
+
+func TestUndoAfterTrashDoesNotRecreatePicture(t *testing.T) {
+	indexer, _, paths := setupTestLibrary(t, 2)
+
+	if err := NewCullingService().SetRating(paths[0], 3); err != nil {
+		t.Fatalf("cannot rate picture: %v", err)
+	}
+	if err := indexer.DeletePicture(paths[0], true); err != nil {
+		t.Fatalf("cannot trash picture: %v", err)
+	}
+
+	if _, err := NewHistoryService().Undo(); err != nil {
+		t.Fatalf("cannot undo: %v", err)
+	}
+	if n := countRows(t, "pictures", "path = ?", paths[0]); n != 0 {
+		t.Errorf("undo recreated the trashed picture")
+	}
+}
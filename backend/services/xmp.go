@@ -0,0 +1,560 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"easygallery/backend/models"
+)
+
+// Espaces de noms XMP lus par l'import des métadonnées
+const (
+	xmpNSRDF       = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xmpNSDC        = "http://purl.org/dc/elements/1.1/"
+	xmpNSXMP       = "http://ns.adobe.com/xap/1.0/"
+	xmpNSLightroom = "http://ns.adobe.com/lightroom/1.0/"
+	xmpNSDigiKam   = "http://www.digikam.org/ns/1.0/"
+	xmpNSPhotoshop = "http://ns.adobe.com/photoshop/1.0/"
+	xmpNSIptcCore  = "http://iptc.org/std/Iptc4xmpCore/1.0/xmlns/"
+	xmpNSIptcExt   = "http://iptc.org/std/Iptc4xmpExt/2008-02-29/"
+	xmpNSRegions   = "http://www.metadataworkinggroup.com/schemas/regions/"
+	xmpNSArea      = "http://ns.adobe.com/xmp/sType/Area#"
+)
+
+// Jeux de données IPTC-IIM (enregistrement 2) utilisés
+const (
+	iptcObjectName  = 5   // Titre
+	iptcKeywords    = 25  // Mot-clé (répétable)
+	iptcCity        = 90  // Ville
+	iptcSublocation = 92  // Lieu précis
+	iptcState       = 95  // Région / état
+	iptcCountry     = 101 // Pays
+	iptcCaption     = 120 // Légende
+)
+
+// xmpMaxEmbeddedScan limite la lecture des formats autres que JPEG à la recherche d'un paquet XMP
+const xmpMaxEmbeddedScan = 64 * 1024 * 1024
+
+// xmpHierarchySeparator sépare les niveaux d'un mot-clé hiérarchique (convention Lightroom)
+const xmpHierarchySeparator = "|"
+
+// FileMetadata contient les métadonnées descriptives lues dans un fichier (XMP sidecar ou intégré, IPTC)
+type FileMetadata struct {
+	Title                string           `json:"title"`                // dc:title, titre IPTC
+	Caption              string           `json:"caption"`              // dc:description, légende IPTC ou EXIF ImageDescription
+	Keywords             []string         `json:"keywords"`             // dc:subject et mots-clés IPTC
+	HierarchicalKeywords []string         `json:"hierarchicalKeywords"` // lr:hierarchicalSubject ("Lieux|France|Paris")
+	PersonsInImage       []string         `json:"personsInImage"`       // Iptc4xmpExt:PersonInImage
+	Location             string           `json:"location"`             // Iptc4xmpCore:Location (lieu précis)
+	City                 string           `json:"city"`                 // photoshop:City
+	State                string           `json:"state"`                // photoshop:State
+	Country              string           `json:"country"`              // photoshop:Country
+	Rating               *int             `json:"rating"`               // xmp:Rating (-1 = rejetée, 0 à 5)
+	Label                string           `json:"label"`                // xmp:Label (étiquette de couleur: "Red", "Yellow"...)
+	PickLabel            *int             `json:"pickLabel"`            // digiKam:PickLabel (1 = rejetée, 2 = en attente, 3 = retenue)
+	Regions              []FileFaceRegion `json:"regions"`              // Régions de visage nommées (MWG)
+	Sources              []string         `json:"sources"`              // Fichiers lus (sidecar, image)
+}
+
+// FileFaceRegion est une région de visage nommée lue dans le XMP (rectangle normalisé, origine en haut à gauche)
+type FileFaceRegion struct {
+	Name   string  `json:"name"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// hasDescriptiveData indique si le fichier porte des mots-clés, des personnes ou un lieu (titre et légende exclus)
+func (m *FileMetadata) hasDescriptiveData() bool {
+	return len(m.Keywords) > 0 || len(m.HierarchicalKeywords) > 0 || len(m.PersonsInImage) > 0 ||
+		len(m.Regions) > 0 || m.Location != "" || m.City != "" || m.State != "" || m.Country != ""
+}
+
+// hasCaptionData indique si le fichier porte un titre ou une légende
+func (m *FileMetadata) hasCaptionData() bool {
+	return m.Title != "" || m.Caption != ""
+}
+
+// hasCullingData indique si le fichier contient une note, une étiquette de couleur ou une marque
+func (m *FileMetadata) hasCullingData() bool {
+	return m.Rating != nil || m.Label != "" || m.PickLabel != nil
+}
+
+// xmpColorLabels associe les libellés xmp:Label (Lightroom, Bridge, éventuellement traduits) aux étiquettes
+var xmpColorLabels = map[string]models.ColorLabel{
+	"red":    models.ColorLabelRed,
+	"rouge":  models.ColorLabelRed,
+	"yellow": models.ColorLabelYellow,
+	"jaune":  models.ColorLabelYellow,
+	"green":  models.ColorLabelGreen,
+	"vert":   models.ColorLabelGreen,
+	"blue":   models.ColorLabelBlue,
+	"bleu":   models.ColorLabelBlue,
+	"purple": models.ColorLabelPurple,
+	"violet": models.ColorLabelPurple,
+}
+
+// colorLabel retourne l'étiquette de couleur du fichier (false si absente ou inconnue)
+func (m *FileMetadata) colorLabel() (models.ColorLabel, bool) {
+	label, ok := xmpColorLabels[strings.ToLower(strings.TrimSpace(m.Label))]
+	return label, ok
+}
+
+// pickFlag retourne la marque du fichier: digiKam:PickLabel, sinon une note -1 (rejetée)
+// Une note positive ou nulle sans PickLabel correspond à une photo non marquée
+func (m *FileMetadata) pickFlag() (models.PickFlag, bool) {
+	if m.PickLabel != nil {
+		switch *m.PickLabel {
+		case 1:
+			return models.PickFlagReject, true
+		case 2:
+			return models.PickFlagNone, true
+		case 3:
+			return models.PickFlagPick, true
+		}
+	}
+	if m.Rating != nil {
+		if *m.Rating < 0 {
+			return models.PickFlagReject, true
+		}
+		return models.PickFlagNone, true
+	}
+	return "", false
+}
+
+// merge complète les métadonnées avec celles d'une autre source
+// Les listes sont réunies; les valeurs simples et les régions de other l'emportent si elles sont renseignées
+func (m *FileMetadata) merge(other *FileMetadata) {
+	m.Keywords = appendUnique(m.Keywords, other.Keywords...)
+	m.HierarchicalKeywords = appendUnique(m.HierarchicalKeywords, other.HierarchicalKeywords...)
+	m.PersonsInImage = appendUnique(m.PersonsInImage, other.PersonsInImage...)
+	for _, field := range []struct{ dst, src *string }{
+		{&m.Title, &other.Title},
+		{&m.Caption, &other.Caption},
+		{&m.Location, &other.Location},
+		{&m.City, &other.City},
+		{&m.State, &other.State},
+		{&m.Country, &other.Country},
+	} {
+		if *field.src != "" {
+			*field.dst = *field.src
+		}
+	}
+	if other.Rating != nil {
+		m.Rating = other.Rating
+	}
+	if other.Label != "" {
+		m.Label = other.Label
+	}
+	if other.PickLabel != nil {
+		m.PickLabel = other.PickLabel
+	}
+	if len(other.Regions) > 0 {
+		m.Regions = other.Regions
+	}
+	m.Sources = append(m.Sources, other.Sources...)
+}
+
+// appendUnique ajoute des valeurs non vides à une liste, sans doublon (casse ignorée)
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		duplicate := false
+		for _, existing := range list {
+			if strings.EqualFold(existing, value) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// findXMPSidecar retourne le sidecar XMP d'une photo ("" si aucun)
+// Conventions reconnues: "photo.jpg.xmp" (darktable, digiKam) puis "photo.xmp" (Lightroom)
+func findXMPSidecar(imagePath string) (string, os.FileInfo) {
+	base := strings.TrimSuffix(imagePath, filepath.Ext(imagePath))
+	for _, candidate := range []string{imagePath + ".xmp", imagePath + ".XMP", base + ".xmp", base + ".XMP"} {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, info
+		}
+	}
+	return "", nil
+}
+
+// sidecarModTime retourne la date de modification du sidecar XMP d'une photo (zéro si aucun)
+func sidecarModTime(imagePath string) time.Time {
+	if _, info := findXMPSidecar(imagePath); info != nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// readFileMetadata lit les métadonnées intégrées à l'image puis celles du sidecar, qui l'emportent
+func readFileMetadata(imagePath string) (*FileMetadata, error) {
+	metadata, err := readEmbeddedMetadata(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if sidecar, _ := findXMPSidecar(imagePath); sidecar != "" {
+		data, err := os.ReadFile(sidecar)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read xmp sidecar: %w", err)
+		}
+		fromSidecar, err := parseXMP(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xmp sidecar %s: %w", filepath.Base(sidecar), err)
+		}
+		fromSidecar.Sources = []string{sidecar}
+		metadata.merge(fromSidecar)
+	}
+
+	return metadata, nil
+}
+
+// readEmbeddedMetadata lit le XMP et l'IPTC intégrés à une image
+// JPEG: segments APP1 (XMP) et APP13 (IPTC); autres formats: premier paquet XMP trouvé dans le fichier
+func readEmbeddedMetadata(imagePath string) (*FileMetadata, error) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	metadata := &FileMetadata{}
+	var packets [][]byte
+	var iptc []byte
+	var exif []byte
+
+	r := bufio.NewReader(file)
+	if head, _ := r.Peek(2); bytes.Equal(head, []byte{0xFF, 0xD8}) {
+		exifHeader := []byte("Exif\x00\x00")
+		xmpHeader := []byte("http://ns.adobe.com/xap/1.0/\x00")
+		photoshopHeader := []byte("Photoshop 3.0\x00")
+		err = walkJPEGSegments(r, func(marker byte, segment []byte) bool {
+			switch {
+			case marker == 0xE1 && exif == nil && bytes.HasPrefix(segment, exifHeader):
+				exif = segment[len(exifHeader):]
+			case marker == 0xE1 && bytes.HasPrefix(segment, xmpHeader):
+				packets = append(packets, segment[len(xmpHeader):])
+			case marker == 0xED && bytes.HasPrefix(segment, photoshopHeader):
+				iptc = append(iptc, findIPTCResource(segment[len(photoshopHeader):])...)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := io.ReadAll(io.LimitReader(r, xmpMaxEmbeddedScan))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read image: %w", err)
+		}
+		if start := bytes.Index(data, []byte("<x:xmpmeta")); start >= 0 {
+			if end := bytes.Index(data[start:], []byte("</x:xmpmeta>")); end >= 0 {
+				packets = append(packets, data[start:start+end+len("</x:xmpmeta>")])
+			}
+		}
+	}
+
+	// Priorité croissante: EXIF, IPTC puis XMP
+	hasExifCaption := false
+	if exif != nil {
+		if data, err := parseExif(exif); err == nil && data.Description != "" {
+			metadata.Caption = data.Description
+			hasExifCaption = true
+		}
+	}
+	if len(iptc) > 0 {
+		metadata.merge(parseIPTC(iptc))
+	}
+	// Le XMP, plus récent et plus riche, l'emporte sur l'IPTC
+	for _, packet := range packets {
+		fromXMP, err := parseXMP(packet)
+		if err != nil {
+			fmt.Printf("Warning: invalid embedded xmp in %s: %v\n", imagePath, err)
+			continue
+		}
+		metadata.merge(fromXMP)
+	}
+	if hasExifCaption || len(iptc) > 0 || len(packets) > 0 {
+		metadata.Sources = []string{imagePath}
+	}
+
+	return metadata, nil
+}
+
+// findIPTCResource extrait le bloc IPTC-IIM (ressource 0x0404) des ressources Photoshop d'un segment APP13
+func findIPTCResource(data []byte) []byte {
+	for len(data) >= 12 && bytes.HasPrefix(data, []byte("8BIM")) {
+		id := binary.BigEndian.Uint16(data[4:6])
+
+		// Nom en chaîne Pascal, complété pour occuper un nombre pair d'octets
+		nameLength := int(data[6]) + 1
+		nameLength += nameLength % 2
+		if len(data) < 6+nameLength+4 {
+			return nil
+		}
+		pos := 6 + nameLength
+		size := int(binary.BigEndian.Uint32(data[pos:]))
+		pos += 4
+		if size < 0 || len(data) < pos+size {
+			return nil
+		}
+
+		if id == 0x0404 {
+			return data[pos : pos+size]
+		}
+		data = data[pos+size+size%2:]
+	}
+	return nil
+}
+
+// parseIPTC décode les mots-clés et le lieu d'un bloc IPTC-IIM
+func parseIPTC(data []byte) *FileMetadata {
+	metadata := &FileMetadata{}
+	for len(data) >= 5 && data[0] == 0x1C {
+		record, dataset := data[1], data[2]
+		size := int(binary.BigEndian.Uint16(data[3:5]))
+		// Taille étendue (bit de poids fort): jamais utilisée pour les champs texte lus ici
+		if size&0x8000 != 0 || len(data) < 5+size {
+			break
+		}
+		value := iptcString(data[5 : 5+size])
+		data = data[5+size:]
+
+		if record != 2 {
+			continue
+		}
+		switch dataset {
+		case iptcObjectName:
+			metadata.Title = value
+		case iptcCaption:
+			metadata.Caption = value
+		case iptcKeywords:
+			metadata.Keywords = appendUnique(metadata.Keywords, value)
+		case iptcCity:
+			metadata.City = value
+		case iptcSublocation:
+			metadata.Location = value
+		case iptcState:
+			metadata.State = value
+		case iptcCountry:
+			metadata.Country = value
+		}
+	}
+	return metadata
+}
+
+// iptcString décode un champ IPTC: UTF-8 si valide, sinon Latin-1 (ancien encodage par défaut)
+func iptcString(data []byte) string {
+	if utf8.Valid(data) {
+		return strings.TrimSpace(string(data))
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return strings.TrimSpace(string(runes))
+}
+
+// parseXMP décode un paquet XMP (RDF/XML)
+// Les propriétés peuvent être écrites en attributs de rdf:Description ou en éléments, les listes en rdf:Bag/Seq/Alt
+func parseXMP(data []byte) (*FileMetadata, error) {
+	metadata := &FileMetadata{}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []xml.Name
+	var text strings.Builder
+	var region map[string]string // Champs de la région de visage en cours de lecture
+	regionDepth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse xmp: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name)
+			text.Reset()
+
+			if region == nil && t.Name == (xml.Name{Space: xmpNSRDF, Local: "li"}) && insideXMPRegionList(stack) {
+				region = make(map[string]string)
+				regionDepth = len(stack)
+			}
+
+			for _, attr := range t.Attr {
+				if region != nil {
+					setXMPRegionField(region, attr.Name, attr.Value)
+				} else if t.Name == (xml.Name{Space: xmpNSRDF, Local: "Description"}) {
+					metadata.setXMPValue(attr.Name, attr.Value)
+				}
+			}
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			text.Reset()
+
+			switch {
+			case region != nil:
+				if len(stack) == regionDepth {
+					metadata.addXMPRegion(region)
+					region = nil
+				} else if value != "" {
+					setXMPRegionField(region, t.Name, value)
+				}
+			case t.Name == xml.Name{Space: xmpNSRDF, Local: "li"}:
+				if property, ok := xmpListProperty(stack); ok {
+					metadata.addXMPListItem(property, value)
+				}
+			case value != "" && len(stack) >= 2 && stack[len(stack)-2] == xml.Name{Space: xmpNSRDF, Local: "Description"}:
+				metadata.setXMPValue(t.Name, value)
+			}
+
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	return metadata, nil
+}
+
+// insideXMPRegionList indique si l'élément courant est dans une liste de régions MWG
+func insideXMPRegionList(stack []xml.Name) bool {
+	for _, name := range stack {
+		if name.Space == xmpNSRegions && name.Local == "RegionList" {
+			return true
+		}
+	}
+	return false
+}
+
+// setXMPRegionField retient le nom, le type et le rectangle d'une région MWG
+// (les dimensions de l'image de référence, en pixels, sont ignorées)
+func setXMPRegionField(region map[string]string, name xml.Name, value string) {
+	if name.Space == xmpNSRegions || name.Space == xmpNSArea {
+		region[name.Local] = value
+	}
+}
+
+// xmpListProperty retourne la propriété qui contient l'élément rdf:li courant
+func xmpListProperty(stack []xml.Name) (xml.Name, bool) {
+	for i := len(stack) - 2; i >= 0; i-- {
+		if stack[i].Space != xmpNSRDF {
+			return stack[i], true
+		}
+	}
+	return xml.Name{}, false
+}
+
+// setXMPValue enregistre une propriété simple
+func (m *FileMetadata) setXMPValue(name xml.Name, value string) {
+	value = strings.TrimSpace(value)
+	switch name {
+	case xml.Name{Space: xmpNSXMP, Local: "Rating"}:
+		if rating, err := strconv.ParseFloat(value, 64); err == nil {
+			r := max(-1, min(5, int(rating)))
+			m.Rating = &r
+		}
+	case xml.Name{Space: xmpNSXMP, Local: "Label"}:
+		m.Label = value
+	case xml.Name{Space: xmpNSDigiKam, Local: "PickLabel"}:
+		if pick, err := strconv.Atoi(value); err == nil {
+			m.PickLabel = &pick
+		}
+	case xml.Name{Space: xmpNSIptcCore, Local: "Location"}:
+		m.Location = value
+	case xml.Name{Space: xmpNSPhotoshop, Local: "City"}:
+		m.City = value
+	case xml.Name{Space: xmpNSPhotoshop, Local: "State"}:
+		m.State = value
+	case xml.Name{Space: xmpNSPhotoshop, Local: "Country"}:
+		m.Country = value
+	}
+}
+
+// addXMPListItem ajoute un élément de liste (rdf:Bag / rdf:Seq)
+func (m *FileMetadata) addXMPListItem(property xml.Name, value string) {
+	switch property {
+	case xml.Name{Space: xmpNSDC, Local: "subject"}:
+		m.Keywords = appendUnique(m.Keywords, value)
+	case xml.Name{Space: xmpNSLightroom, Local: "hierarchicalSubject"}:
+		m.HierarchicalKeywords = appendUnique(m.HierarchicalKeywords, value)
+	case xml.Name{Space: xmpNSDigiKam, Local: "TagsList"}:
+		// digiKam sépare les niveaux par "/"
+		m.HierarchicalKeywords = appendUnique(m.HierarchicalKeywords, strings.ReplaceAll(value, "/", xmpHierarchySeparator))
+	case xml.Name{Space: xmpNSIptcExt, Local: "PersonInImage"}:
+		m.PersonsInImage = appendUnique(m.PersonsInImage, value)
+	case xml.Name{Space: xmpNSDC, Local: "title"}:
+		// rdf:Alt: la première langue (x-default en principe) est retenue
+		if m.Title == "" {
+			m.Title = strings.TrimSpace(value)
+		}
+	case xml.Name{Space: xmpNSDC, Local: "description"}:
+		if m.Caption == "" {
+			m.Caption = strings.TrimSpace(value)
+		}
+	}
+}
+
+// addXMPRegion ajoute une région de visage MWG nommée
+// Les coordonnées MWG désignent le centre de la région (stArea:x, stArea:y)
+func (m *FileMetadata) addXMPRegion(fields map[string]string) {
+	name := strings.TrimSpace(fields["Name"])
+	if name == "" || (fields["Type"] != "" && fields["Type"] != "Face") {
+		return
+	}
+	if unit := fields["unit"]; unit != "" && unit != "normalized" {
+		return
+	}
+
+	var values [4]float64
+	for i, key := range []string{"x", "y", "w", "h"} {
+		v, err := strconv.ParseFloat(fields[key], 64)
+		if err != nil {
+			return
+		}
+		values[i] = v
+	}
+
+	region := FileFaceRegion{
+		Name:   name,
+		X:      max(0, values[0]-values[2]/2),
+		Y:      max(0, values[1]-values[3]/2),
+		Width:  values[2],
+		Height: values[3],
+	}
+	region.Width = min(region.Width, 1-region.X)
+	region.Height = min(region.Height, 1-region.Y)
+	if region.Width <= 0 || region.Height <= 0 {
+		return
+	}
+	m.Regions = append(m.Regions, region)
+}
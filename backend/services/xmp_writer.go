@@ -0,0 +1,644 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"easygallery/backend/database"
+	"easygallery/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Espaces de noms écrits en plus de ceux lus par l'import
+const (
+	xmpNSMeta       = "adobe:ns:meta/"
+	xmpNSDimensions = "http://ns.adobe.com/xap/1.0/sType/Dimensions#"
+)
+
+// xmpManagedProperties sont les propriétés réécrites par la galerie; le reste du sidecar est conservé tel quel
+var xmpManagedProperties = map[xml.Name]bool{
+	{Space: xmpNSDC, Local: "title"}:                      true,
+	{Space: xmpNSDC, Local: "description"}:                true,
+	{Space: xmpNSDC, Local: "subject"}:                    true,
+	{Space: xmpNSLightroom, Local: "hierarchicalSubject"}: true,
+	{Space: xmpNSDigiKam, Local: "TagsList"}:              true,
+	{Space: xmpNSIptcExt, Local: "PersonInImage"}:         true,
+	{Space: xmpNSIptcCore, Local: "Location"}:             true,
+	{Space: xmpNSXMP, Local: "Rating"}:                    true,
+	{Space: xmpNSXMP, Local: "Label"}:                     true,
+	{Space: xmpNSDigiKam, Local: "PickLabel"}:             true,
+	{Space: xmpNSRegions, Local: "Regions"}:               true,
+}
+
+// xmpPickLabels associe les marques aux valeurs digiKam:PickLabel (une photo non marquée n'en a pas)
+var xmpPickLabels = map[models.PickFlag]int{
+	models.PickFlagReject: 1,
+	models.PickFlagPick:   3,
+}
+
+// xmpSidecarData contient ce que la galerie écrit dans le sidecar d'une photo
+type xmpSidecarData struct {
+	title        string              // dc:title
+	caption      string              // dc:description (les notes privées ne sont jamais écrites)
+	subjects     []string            // dc:subject: noms des tags
+	hierarchical []string            // lr:hierarchicalSubject: "Libellé du type|Parent|Tag"
+	persons      []string            // Iptc4xmpExt:PersonInImage
+	location     string              // Iptc4xmpCore:Location: lieu le plus précis
+	rating       int                 // xmp:Rating (0 = non écrit)
+	colorLabel   models.ColorLabel   // xmp:Label
+	flag         models.PickFlag     // digiKam:PickLabel, et xmp:Rating -1 pour une photo rejetée
+	regions      []models.FaceRegion // Zones de visage nommées (régions MWG)
+	width        int
+	height       int
+}
+
+// xmpSidecarPathForWrite retourne le sidecar existant d'une photo, ou "photo.jpg.xmp" s'il n'y en a pas
+func xmpSidecarPathForWrite(imagePath string) string {
+	if sidecar, _ := findXMPSidecar(imagePath); sidecar != "" {
+		return sidecar
+	}
+	return imagePath + ".xmp"
+}
+
+// writeSidecarsFor indique si l'écriture des sidecars est activée pour le dossier surveillé le plus proche
+func writeSidecarsFor(folders []models.WatchedFolder, picturePath string) bool {
+	for _, folder := range folders {
+		if _, ok := relativeToRoot(folder.Path, picturePath); ok {
+			return folder.WriteSidecars
+		}
+	}
+	return false
+}
+
+// tagHierarchyPath retourne la hiérarchie d'un tag ("Lieux|France|Paris")
+// Le libellé du type sert de racine, sauf pour les tags "autre" (relu par l'import comme catégorie)
+func tagHierarchyPath(tag models.Tag, tags map[string]models.Tag, labels map[models.TagType]string) string {
+	levels := []string{tag.Name}
+	seen := map[string]bool{tag.Name: true}
+	for current := tag; current.ParentName != nil && !seen[*current.ParentName]; {
+		parent, ok := tags[*current.ParentName]
+		if !ok {
+			break
+		}
+		seen[parent.Name] = true
+		levels = append([]string{parent.Name}, levels...)
+		current = parent
+	}
+
+	if tag.Type != models.TagTypeOther && labels[tag.Type] != "" {
+		levels = append([]string{labels[tag.Type]}, levels...)
+	}
+	return strings.Join(levels, xmpHierarchySeparator)
+}
+
+// loadXMPSidecarData prépare le contenu des sidecars de plusieurs photos
+func loadXMPSidecarData(db *gorm.DB, paths []string) (map[string]*xmpSidecarData, error) {
+	var allTags []models.Tag
+	if err := db.Find(&allTags).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tags: %w", err)
+	}
+	tags := make(map[string]models.Tag, len(allTags))
+	for _, tag := range allTags {
+		tags[tag.Name] = tag
+	}
+
+	var types []models.TagTypeDefinition
+	if err := db.Find(&types).Error; err != nil {
+		return nil, fmt.Errorf("cannot fetch tag types: %w", err)
+	}
+	labels := make(map[models.TagType]string, len(types))
+	for _, t := range types {
+		labels[t.Name] = t.Label
+	}
+
+	data := make(map[string]*xmpSidecarData, len(paths))
+	for start := 0; start < len(paths); start += bulkTagBatchSize {
+		batch := paths[start:min(start+bulkTagBatchSize, len(paths))]
+
+		var pictures []models.Picture
+		if err := db.Where("path IN ?", batch).Find(&pictures).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+		}
+		for _, picture := range pictures {
+			data[picture.Path] = &xmpSidecarData{
+				title:      picture.Title,
+				caption:    picture.Caption,
+				rating:     picture.Rating,
+				colorLabel: picture.ColorLabel,
+				flag:       picture.Flag,
+				width:      picture.Width,
+				height:     picture.Height,
+			}
+		}
+
+		var associations []models.PictureTag
+		if err := db.Where("picture_path IN ?", batch).Order("tag_name").Find(&associations).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch picture tags: %w", err)
+		}
+		locations := make(map[string][]models.Tag)
+		for _, a := range associations {
+			d, tag := data[a.PicturePath], tags[a.TagName]
+			if d == nil || tag.Name == "" {
+				continue
+			}
+			d.subjects = append(d.subjects, tag.Name)
+			if path := tagHierarchyPath(tag, tags, labels); path != tag.Name {
+				d.hierarchical = append(d.hierarchical, path)
+			}
+			switch tag.Type {
+			case models.TagTypePerson:
+				d.persons = append(d.persons, tag.Name)
+			case models.TagTypeLocation:
+				locations[a.PicturePath] = append(locations[a.PicturePath], tag)
+			}
+		}
+
+		// Lieu le plus précis: un lieu qui n'est le parent d'aucun autre lieu de la photo
+		for path, places := range locations {
+			parents := make(map[string]bool)
+			for _, place := range places {
+				if place.ParentName != nil {
+					parents[*place.ParentName] = true
+				}
+			}
+			for _, place := range places {
+				if !parents[place.Name] {
+					data[path].location = place.Name
+					break
+				}
+			}
+		}
+
+		var regions []models.FaceRegion
+		if err := db.Where("picture_path IN ? AND tag_name IS NOT NULL", batch).Order("x").Order("id").Find(&regions).Error; err != nil {
+			return nil, fmt.Errorf("cannot fetch face regions: %w", err)
+		}
+		for _, region := range regions {
+			if d := data[region.PicturePath]; d != nil {
+				d.regions = append(d.regions, region)
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// xmpEscape échappe un texte pour un contenu ou un attribut XML
+func xmpEscape(value string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// buildXMPProperties génère les propriétés gérées par la galerie
+// Chaque propriété déclare ses espaces de noms, pour s'insérer dans un sidecar existant sans toucher au reste
+func buildXMPProperties(d *xmpSidecarData) string {
+	var b strings.Builder
+
+	writeBag := func(prefix string, namespace string, local string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n   <%s:%s xmlns:%s=\"%s\" xmlns:rdf=\"%s\">\n    <rdf:Bag>\n", prefix, local, prefix, namespace, xmpNSRDF)
+		for _, value := range values {
+			fmt.Fprintf(&b, "     <rdf:li>%s</rdf:li>\n", xmpEscape(value))
+		}
+		fmt.Fprintf(&b, "    </rdf:Bag>\n   </%s:%s>", prefix, local)
+	}
+
+	// Textes dans une seule langue (rdf:Alt, x-default)
+	writeAlt := func(local string, value string) {
+		if value == "" {
+			return
+		}
+		fmt.Fprintf(&b, "\n   <dc:%s xmlns:dc=\"%s\" xmlns:rdf=\"%s\">\n    <rdf:Alt>\n     <rdf:li xml:lang=\"x-default\">%s</rdf:li>\n    </rdf:Alt>\n   </dc:%s>",
+			local, xmpNSDC, xmpNSRDF, xmpEscape(value), local)
+	}
+
+	writeAlt("title", d.title)
+	writeAlt("description", d.caption)
+	writeBag("dc", xmpNSDC, "subject", d.subjects)
+	writeBag("lr", xmpNSLightroom, "hierarchicalSubject", d.hierarchical)
+	digiKamTags := make([]string, len(d.hierarchical))
+	for i, path := range d.hierarchical {
+		digiKamTags[i] = strings.ReplaceAll(path, xmpHierarchySeparator, "/")
+	}
+	writeBag("digiKam", xmpNSDigiKam, "TagsList", digiKamTags)
+	writeBag("Iptc4xmpExt", xmpNSIptcExt, "PersonInImage", d.persons)
+
+	if d.location != "" {
+		fmt.Fprintf(&b, "\n   <Iptc4xmpCore:Location xmlns:Iptc4xmpCore=\"%s\">%s</Iptc4xmpCore:Location>", xmpNSIptcCore, xmpEscape(d.location))
+	}
+	// Une photo rejetée est notée -1, comme dans Lightroom et darktable
+	if d.flag == models.PickFlagReject {
+		fmt.Fprintf(&b, "\n   <xmp:Rating xmlns:xmp=\"%s\">-1</xmp:Rating>", xmpNSXMP)
+	} else if d.rating > 0 {
+		fmt.Fprintf(&b, "\n   <xmp:Rating xmlns:xmp=\"%s\">%d</xmp:Rating>", xmpNSXMP, d.rating)
+	}
+	if d.colorLabel != models.ColorLabelNone {
+		label := string(d.colorLabel)
+		label = strings.ToUpper(label[:1]) + label[1:] // Libellés Lightroom: "Red", "Yellow"...
+		fmt.Fprintf(&b, "\n   <xmp:Label xmlns:xmp=\"%s\">%s</xmp:Label>", xmpNSXMP, xmpEscape(label))
+	}
+	if pick, ok := xmpPickLabels[d.flag]; ok {
+		fmt.Fprintf(&b, "\n   <digiKam:PickLabel xmlns:digiKam=\"%s\">%d</digiKam:PickLabel>", xmpNSDigiKam, pick)
+	}
+
+	// Régions MWG: centre et taille normalisés
+	if len(d.regions) > 0 {
+		fmt.Fprintf(&b, "\n   <mwg-rs:Regions xmlns:mwg-rs=\"%s\" xmlns:stArea=\"%s\" xmlns:stDim=\"%s\" xmlns:rdf=\"%s\" rdf:parseType=\"Resource\">",
+			xmpNSRegions, xmpNSArea, xmpNSDimensions, xmpNSRDF)
+		fmt.Fprintf(&b, "\n    <mwg-rs:AppliedToDimensions stDim:w=\"%d\" stDim:h=\"%d\" stDim:unit=\"pixel\"/>", d.width, d.height)
+		b.WriteString("\n    <mwg-rs:RegionList>\n     <rdf:Bag>")
+		for _, region := range d.regions {
+			fmt.Fprintf(&b, "\n      <rdf:li><rdf:Description mwg-rs:Name=\"%s\" mwg-rs:Type=\"Face\">", xmpEscape(*region.TagName))
+			fmt.Fprintf(&b, "<mwg-rs:Area stArea:x=\"%.6f\" stArea:y=\"%.6f\" stArea:w=\"%.6f\" stArea:h=\"%.6f\" stArea:unit=\"normalized\"/>",
+				region.X+region.Width/2, region.Y+region.Height/2, region.Width, region.Height)
+			b.WriteString("</rdf:Description></rdf:li>")
+		}
+		b.WriteString("\n     </rdf:Bag>\n    </mwg-rs:RegionList>\n   </mwg-rs:Regions>")
+	}
+
+	return b.String()
+}
+
+// newXMPDocument crée un sidecar ne contenant que les propriétés de la galerie
+func newXMPDocument(properties string) []byte {
+	return []byte(fmt.Sprintf("<x:xmpmeta xmlns:x=\"%s\" x:xmptk=\"EasyGallery\">\n <rdf:RDF xmlns:rdf=\"%s\">\n  <rdf:Description rdf:about=\"\">%s\n  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n",
+		xmpNSMeta, xmpNSRDF, properties))
+}
+
+// xmpEdit remplace les octets [start, end) d'un document
+type xmpEdit struct {
+	start, end int
+	text       string
+}
+
+// rewriteXMPDocument remplace les propriétés gérées d'un sidecar existant et conserve tout le reste à l'octet près
+// Les propriétés gérées sont retirées de chaque rdf:Description de premier niveau (éléments et attributs),
+// puis les nouvelles valeurs sont ajoutées au premier rdf:Description
+func rewriteXMPDocument(doc []byte, properties string) ([]byte, error) {
+	rdfDescription := xml.Name{Space: xmpNSRDF, Local: "Description"}
+	rdfRDF := xml.Name{Space: xmpNSRDF, Local: "RDF"}
+
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+	var edits []xmpEdit
+	var stack []xml.Name
+	var prefixes []map[string]string // Préfixes déclarés à chaque niveau (espace de noms → préfixe)
+
+	managedStart, managedDepth := -1, 0
+	inserted := false
+	var descTag xmpEdit // Balise ouvrante du rdf:Description de premier niveau en cours
+
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse xmp sidecar: %w", err)
+		}
+		end := int(decoder.InputOffset())
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := xml.Name{}
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			inTopDescription := parent == rdfDescription && len(stack) >= 2 && stack[len(stack)-2] == rdfRDF
+
+			declared := make(map[string]string)
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" {
+					declared[attr.Value] = attr.Name.Local
+				}
+			}
+			stack = append(stack, t.Name)
+			prefixes = append(prefixes, declared)
+
+			switch {
+			case t.Name == rdfDescription && parent == rdfRDF:
+				tag := string(doc[start:end])
+				for _, attr := range t.Attr {
+					if xmpManagedProperties[attr.Name] {
+						tag = removeXMPAttribute(tag, lookupXMPPrefix(prefixes, attr.Name.Space), attr.Name.Local)
+					}
+				}
+				descTag = xmpEdit{start, end, tag}
+			case inTopDescription && managedStart < 0 && xmpManagedProperties[t.Name]:
+				managedStart, managedDepth = start, len(stack)
+				// Retirer aussi l'indentation qui précède la propriété
+				for managedStart > 0 && strings.ContainsRune(" \t\r\n", rune(doc[managedStart-1])) {
+					managedStart--
+				}
+			}
+
+		case xml.EndElement:
+			if managedStart >= 0 && len(stack) == managedDepth {
+				edits = append(edits, xmpEdit{managedStart, end, ""})
+				managedStart = -1
+			}
+
+			if t.Name == rdfDescription && len(stack) >= 2 && stack[len(stack)-2] == rdfRDF {
+				tag := descTag.text
+				if !inserted && start == end {
+					// Balise auto-fermante: <rdf:Description .../> devient <rdf:Description ...>...</rdf:Description>
+					qualified := regexp.MustCompile(`^<([^\s/>]+)`).FindStringSubmatch(tag)
+					if len(qualified) < 2 {
+						return nil, fmt.Errorf("cannot parse xmp sidecar: invalid rdf:Description")
+					}
+					tag = strings.TrimSuffix(strings.TrimRight(strings.TrimSuffix(tag, ">"), " \t\r\n/"), "/") + ">" +
+						properties + "\n  </" + qualified[1] + ">"
+					inserted = true
+				} else if !inserted {
+					// L'indentation avant la balise fermante est remplacée pour ne pas laisser de ligne vide
+					from := start
+					for from > descTag.end && strings.ContainsRune(" \t\r\n", rune(doc[from-1])) {
+						from--
+					}
+					edits = append(edits, xmpEdit{from, start, properties + "\n  "})
+					inserted = true
+				}
+				if tag != string(doc[descTag.start:descTag.end]) {
+					edits = append(edits, xmpEdit{descTag.start, descTag.end, tag})
+				}
+			}
+
+			stack = stack[:len(stack)-1]
+			prefixes = prefixes[:len(prefixes)-1]
+		}
+	}
+
+	if !inserted {
+		return nil, fmt.Errorf("cannot update xmp sidecar: no rdf:Description found")
+	}
+
+	// Appliquer les modifications de la fin vers le début pour conserver les positions
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	result := append([]byte(nil), doc...)
+	for _, edit := range edits {
+		result = append(result[:edit.start], append([]byte(edit.text), result[edit.end:]...)...)
+	}
+	return result, nil
+}
+
+// lookupXMPPrefix retrouve le préfixe déclaré pour un espace de noms, du niveau le plus proche au plus lointain
+func lookupXMPPrefix(prefixes []map[string]string, namespace string) string {
+	for i := len(prefixes) - 1; i >= 0; i-- {
+		if prefix, ok := prefixes[i][namespace]; ok {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// removeXMPAttribute retire un attribut "prefix:local" d'une balise ouvrante
+func removeXMPAttribute(tag string, prefix string, local string) string {
+	if prefix == "" {
+		return tag
+	}
+	pattern := regexp.MustCompile(`\s+` + regexp.QuoteMeta(prefix+":"+local) + `\s*=\s*("[^"]*"|'[^']*')`)
+	return pattern.ReplaceAllString(tag, "")
+}
+
+// writeXMPSidecar écrit le sidecar d'une photo sans toucher à l'image
+// Un sidecar existant est mis à jour en place; un sidecar illisible n'est jamais écrasé
+func writeXMPSidecar(imagePath string, d *xmpSidecarData) (string, error) {
+	sidecar := xmpSidecarPathForWrite(imagePath)
+	properties := buildXMPProperties(d)
+
+	var content []byte
+	mode := os.FileMode(0644)
+	if existing, err := os.ReadFile(sidecar); err == nil {
+		if content, err = rewriteXMPDocument(existing, properties); err != nil {
+			return "", err
+		}
+		if bytes.Equal(content, existing) {
+			return sidecar, nil
+		}
+		if info, err := os.Stat(sidecar); err == nil {
+			mode = info.Mode().Perm()
+		}
+	} else if os.IsNotExist(err) {
+		content = newXMPDocument(properties)
+	} else {
+		return "", fmt.Errorf("cannot read xmp sidecar: %w", err)
+	}
+
+	// Écriture atomique: fichier temporaire dans le même dossier puis renommage
+	tmp, err := os.CreateTemp(filepath.Dir(sidecar), "."+filepath.Base(sidecar)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("cannot write xmp sidecar: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("cannot write xmp sidecar: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("cannot write xmp sidecar: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return "", fmt.Errorf("cannot write xmp sidecar: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), sidecar); err != nil {
+		return "", fmt.Errorf("cannot write xmp sidecar: %w", err)
+	}
+
+	return sidecar, nil
+}
+
+// writeXMPSidecars écrit les sidecars des photos dont le dossier a activé l'écriture
+// Retourne le nombre de sidecars écrits et les erreurs par fichier
+func writeXMPSidecars(db *gorm.DB, paths []string) (int, []string, error) {
+	folders, err := watchedFoldersByDepth(db)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var enabled []string
+	for _, path := range paths {
+		if writeSidecarsFor(folders, path) {
+			enabled = append(enabled, path)
+		}
+	}
+	if len(enabled) == 0 {
+		return 0, nil, nil
+	}
+
+	data, err := loadXMPSidecarData(db, enabled)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	written := 0
+	var failures []string
+	for _, path := range enabled {
+		d := data[path]
+		if d == nil {
+			continue // Photo retirée de la galerie: son sidecar reste en l'état
+		}
+
+		sidecar, err := writeXMPSidecar(path, d)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+			continue
+		}
+		written++
+
+		// Le sidecar écrit par la galerie ne doit pas être ré-importé à la prochaine indexation
+		if info, err := os.Stat(sidecar); err == nil {
+			if err := db.Model(&models.Picture{}).Where("path = ?", path).Update("metadata_modified_at", info.ModTime()).Error; err != nil {
+				return written, failures, fmt.Errorf("cannot update picture: %w", err)
+			}
+		}
+	}
+
+	return written, failures, nil
+}
+
+// sidecarPathsForChanges retourne les photos dont le sidecar doit refléter des lignes modifiées
+// Un tag renommé, retypé ou déplacé concerne toutes les photos qui portent ce tag ou l'un de ses descendants
+func sidecarPathsForChanges(db *gorm.DB, changes []rowChange) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(value interface{}) {
+		if path, ok := value.(string); ok && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	var tagNames []string
+	for _, change := range changes {
+		for _, row := range []map[string]interface{}{change.Before, change.After} {
+			if row == nil {
+				continue
+			}
+			switch change.Table {
+			case "pictures":
+				add(row["path"])
+			case "picture_tags", "face_regions":
+				add(row["picture_path"])
+			case "tags":
+				// Un changement de couleur n'apparaît pas dans les sidecars
+				if change.Before == nil || change.After == nil ||
+					fmt.Sprint(change.Before["type"]) != fmt.Sprint(change.After["type"]) ||
+					fmt.Sprint(change.Before["parent_name"]) != fmt.Sprint(change.After["parent_name"]) {
+					if name, ok := row["name"].(string); ok {
+						tagNames = append(tagNames, name)
+					}
+				}
+			}
+		}
+	}
+
+	if len(tagNames) > 0 {
+		var tagged []string
+		err := db.Model(&models.PictureTag{}).
+			Where("tag_name IN ("+tagSubtreeSQL+")", tagNames).
+			Distinct().Pluck("picture_path", &tagged).Error
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch picture tags: %w", err)
+		}
+		for _, path := range tagged {
+			add(path)
+		}
+	}
+
+	return paths, nil
+}
+
+// syncXMPSidecars met à jour les sidecars après une opération validée
+// La base reste la référence: un échec d'écriture est signalé sans annuler l'opération
+func syncXMPSidecars(changes []rowChange) {
+	var enabled int64
+	if err := database.DB.Model(&models.WatchedFolder{}).Where("write_sidecars = ?", true).Count(&enabled).Error; err != nil || enabled == 0 {
+		return
+	}
+
+	paths, err := sidecarPathsForChanges(database.DB, changes)
+	if err != nil {
+		fmt.Printf("Warning: cannot write xmp sidecars: %v\n", err)
+		return
+	}
+	syncXMPSidecarPaths(paths)
+}
+
+// syncXMPSidecarPaths met à jour les sidecars de photos dont les tags ont changé après validation
+// Comme syncXMPSidecars, un échec d'écriture est seulement signalé
+func syncXMPSidecarPaths(paths []string) {
+	_, failures, err := writeXMPSidecars(database.DB, paths)
+	for _, failure := range failures {
+		fmt.Printf("Warning: cannot write xmp sidecar for %s\n", failure)
+	}
+	if err != nil {
+		fmt.Printf("Warning: cannot write xmp sidecars: %v\n", err)
+	}
+}
+
+// SetSidecarWriteBack active ou désactive l'écriture des tags dans les sidecars XMP d'un dossier surveillé
+func (ms *MetadataService) SetSidecarWriteBack(folderPath string, enabled bool) error {
+	if err := checkDB(); err != nil {
+		return err
+	}
+
+	result := database.DB.Model(&models.WatchedFolder{}).Where("path = ?", folderPath).Update("write_sidecars", enabled)
+	if result.Error != nil {
+		return fmt.Errorf("cannot update watched folder: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("watched folder not found: %s", folderPath)
+	}
+
+	return nil
+}
+
+// WriteSidecars écrit les sidecars XMP de toutes les photos d'un dossier surveillé (écriture activée)
+// Utile après l'activation, les sidecars étant ensuite tenus à jour à chaque modification
+func (ms *MetadataService) WriteSidecars(folderPath string) (*SidecarWriteResult, error) {
+	if err := checkDB(); err != nil {
+		return nil, err
+	}
+
+	var folder models.WatchedFolder
+	if err := database.DB.Where("path = ?", folderPath).First(&folder).Error; err != nil {
+		return nil, fmt.Errorf("watched folder not found: %s", folderPath)
+	}
+	if !folder.WriteSidecars {
+		return nil, fmt.Errorf("xmp sidecar write-back is disabled for %s", folderPath)
+	}
+
+	prefix := strings.TrimSuffix(folderPath, string(filepath.Separator)) + string(filepath.Separator)
+	var paths []string
+	err := database.DB.Model(&models.Picture{}).
+		Where("path LIKE ? ESCAPE '\\'", escapeLike(prefix)+"%").
+		Order("path").Pluck("path", &paths).Error
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch pictures: %w", err)
+	}
+
+	written, failures, err := writeXMPSidecars(database.DB, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SidecarWriteResult{Written: written, Errors: failures}, nil
+}
+
+// SidecarWriteResult résume une écriture de sidecars
+type SidecarWriteResult struct {
+	Written int      `json:"written"` // Sidecars écrits
+	Errors  []string `json:"errors"`  // Fichiers en échec
+}
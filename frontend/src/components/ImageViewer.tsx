@@ -1,6 +1,7 @@
 import { useState, useEffect, useCallback } from 'react'
-import { models } from '../../wailsjs/go/models'
-import { DeletePicture, GetAllTags, GetTagsForPicture, AddTagToPicture, RemoveTagFromPicture } from '../../wailsjs/go/main/App'
+import type { MouseEvent as ReactMouseEvent } from 'react'
+import { models, services } from '../../wailsjs/go/models'
+import { DeletePicture, GetAllTags, GetTagsForPicture, AddTagToPicture, RemoveTagFromPicture, SuggestTags, GetFaceRegions, AddFaceRegion, AssignFaceRegion, DeleteFaceRegion, GetPictureCaption, SetPictureCaption, MarkViewed, ToggleFavorite } from '../../wailsjs/go/main/App'
 import { getImageUrl } from '../utils/imageUrl'
 
 interface ImageViewerProps {
@@ -21,6 +22,20 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
   const [pictureTags, setPictureTags] = useState<models.Tag[]>([])
   const [showTagSelector, setShowTagSelector] = useState(false)
   const [tagLoading, setTagLoading] = useState(false)
+  const [suggestions, setSuggestions] = useState<services.TagSuggestion[]>([])
+
+  // Face regions state (normalised 0..1 coordinates)
+  const [faceRegions, setFaceRegions] = useState<models.FaceRegion[]>([])
+  const [drawMode, setDrawMode] = useState(false)
+  const [dragStart, setDragStart] = useState<{ x: number; y: number } | null>(null)
+  const [dragBox, setDragBox] = useState<{ x: number; y: number; width: number; height: number } | null>(null)
+
+  // Favorite state of the current picture (kept locally, the gallery list may be stale)
+  const [favorite, setFavorite] = useState(false)
+
+  // Title, caption and private notes
+  const [caption, setCaption] = useState<services.PictureCaption>({ title: '', caption: '', notes: '' })
+  const [savedCaption, setSavedCaption] = useState<services.PictureCaption>({ title: '', caption: '', notes: '' })
 
   const currentPicture = pictures[currentIndex]
 
@@ -37,6 +52,65 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
     loadAllTags()
   }, [])
 
+  // Load tag suggestions for a picture
+  const loadSuggestions = async (path: string) => {
+    try {
+      const result = await SuggestTags(path)
+      setSuggestions(result || [])
+    } catch (error) {
+      console.error('Failed to load tag suggestions:', error)
+      setSuggestions([])
+    }
+  }
+
+  // Load face regions for a picture
+  const loadFaceRegions = async (path: string) => {
+    try {
+      const regions = await GetFaceRegions(path)
+      setFaceRegions(regions || [])
+    } catch (error) {
+      console.error('Failed to load face regions:', error)
+      setFaceRegions([])
+    }
+  }
+
+  // Load title, caption and notes for a picture
+  const loadCaption = async (path: string) => {
+    const empty = { title: '', caption: '', notes: '' }
+    try {
+      const result = await GetPictureCaption(path)
+      setCaption(result || empty)
+      setSavedCaption(result || empty)
+    } catch (error) {
+      console.error('Failed to load caption:', error)
+      setCaption(empty)
+      setSavedCaption(empty)
+    }
+  }
+
+  const handleToggleFavorite = async () => {
+    if (!currentPicture) return
+    try {
+      const result = await ToggleFavorite(currentPicture.path)
+      setFavorite(result)
+      currentPicture.favorite = result
+    } catch (error) {
+      console.error('Failed to toggle favorite:', error)
+    }
+  }
+
+  // Save caption fields when leaving a field, only if something changed
+  const handleSaveCaption = async () => {
+    if (!currentPicture) return
+    if (caption.title === savedCaption.title && caption.caption === savedCaption.caption && caption.notes === savedCaption.notes) return
+    try {
+      await SetPictureCaption(currentPicture.path, caption)
+      setSavedCaption(caption)
+    } catch (error) {
+      console.error('Failed to save caption:', error)
+    }
+  }
+
   // Load tags for current picture when it changes
   useEffect(() => {
     const loadPictureTags = async () => {
@@ -48,10 +122,92 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
         console.error('Failed to load picture tags:', error)
         setPictureTags([])
       }
+      loadSuggestions(currentPicture.path)
+      loadFaceRegions(currentPicture.path)
+      loadCaption(currentPicture.path)
     }
     loadPictureTags()
+    if (currentPicture) {
+      setFavorite(!!currentPicture.favorite)
+      MarkViewed(currentPicture.path).catch((error) => console.error('Failed to record view:', error))
+    }
   }, [currentPicture?.path])
 
+  // Position of the mouse relative to the image, normalised to 0..1
+  const relativePosition = (e: ReactMouseEvent<HTMLDivElement>) => {
+    const rect = e.currentTarget.getBoundingClientRect()
+    return {
+      x: Math.min(Math.max((e.clientX - rect.left) / rect.width, 0), 1),
+      y: Math.min(Math.max((e.clientY - rect.top) / rect.height, 0), 1),
+    }
+  }
+
+  const handleDrawStart = (e: ReactMouseEvent<HTMLDivElement>) => {
+    if (!drawMode) return
+    e.preventDefault()
+    const start = relativePosition(e)
+    setDragStart(start)
+    setDragBox({ ...start, width: 0, height: 0 })
+  }
+
+  const handleDrawMove = (e: ReactMouseEvent<HTMLDivElement>) => {
+    if (!drawMode || !dragStart) return
+    const pos = relativePosition(e)
+    setDragBox({
+      x: Math.min(dragStart.x, pos.x),
+      y: Math.min(dragStart.y, pos.y),
+      width: Math.abs(pos.x - dragStart.x),
+      height: Math.abs(pos.y - dragStart.y),
+    })
+  }
+
+  const refreshAfterFaceChange = async () => {
+    if (!currentPicture) return
+    const tags = await GetTagsForPicture(currentPicture.path)
+    setPictureTags(tags || [])
+    loadFaceRegions(currentPicture.path)
+    loadSuggestions(currentPicture.path)
+  }
+
+  const handleDrawEnd = async () => {
+    if (!drawMode || !dragBox || !currentPicture) return
+    const box = dragBox
+    setDragStart(null)
+    setDragBox(null)
+    if (box.width < 0.01 || box.height < 0.01) return
+
+    const name = prompt('Person name (leave empty if unknown):') ?? ''
+    try {
+      await AddFaceRegion(currentPicture.path, box.x, box.y, box.width, box.height, name.trim())
+      await refreshAfterFaceChange()
+      setDrawMode(false)
+    } catch (error) {
+      console.error('Failed to add face region:', error)
+      alert(`Failed to add face region: ${error}`)
+    }
+  }
+
+  const handleAssignFace = async (region: models.FaceRegion) => {
+    const name = prompt('Person name (leave empty to clear):', region.tagName || '')
+    if (name === null) return
+    try {
+      await AssignFaceRegion(region.id, name.trim())
+      await refreshAfterFaceChange()
+    } catch (error) {
+      console.error('Failed to assign face region:', error)
+      alert(`Failed to assign face region: ${error}`)
+    }
+  }
+
+  const handleDeleteFace = async (region: models.FaceRegion) => {
+    try {
+      await DeleteFaceRegion(region.id)
+      await refreshAfterFaceChange()
+    } catch (error) {
+      console.error('Failed to delete face region:', error)
+    }
+  }
+
   const handleAddTag = async (tagName: string) => {
     if (!currentPicture || tagLoading) return
     setTagLoading(true)
@@ -59,6 +215,7 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
       await AddTagToPicture(currentPicture.path, tagName)
       const tags = await GetTagsForPicture(currentPicture.path)
       setPictureTags(tags || [])
+      loadSuggestions(currentPicture.path)
     } catch (error) {
       console.error('Failed to add tag:', error)
     } finally {
@@ -74,6 +231,7 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
       await RemoveTagFromPicture(currentPicture.path, tagName)
       const tags = await GetTagsForPicture(currentPicture.path)
       setPictureTags(tags || [])
+      loadSuggestions(currentPicture.path)
     } catch (error) {
       console.error('Failed to remove tag:', error)
     } finally {
@@ -129,6 +287,12 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
   // Keyboard navigation
   useEffect(() => {
     const handleKeyDown = (e: KeyboardEvent) => {
+      // Don't handle keys while typing in a caption field
+      const target = e.target as HTMLElement
+      if (target.tagName === 'INPUT' || target.tagName === 'TEXTAREA') {
+        return
+      }
+
       // Don't handle keys if delete dialog is open
       if (showDeleteDialog) {
         if (e.key === 'Escape') {
@@ -260,14 +424,49 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
           {currentIndex + 1} / {pictures.length}
         </div>
 
-        {/* The image */}
-        <img
-          src={getImageUrl(currentPicture.path)}
-          alt={currentPicture.filename}
-          className="max-w-full max-h-full object-contain"
+        {/* The image, with face regions drawn on top */}
+        <div
+          className={`relative inline-block max-h-full ${drawMode ? 'cursor-crosshair' : ''}`}
           style={{ maxWidth: showInfo ? 'calc(100% - 320px)' : '100%' }}
           onClick={(e) => e.stopPropagation()}
-        />
+          onMouseDown={handleDrawStart}
+          onMouseMove={handleDrawMove}
+          onMouseUp={handleDrawEnd}
+        >
+          <img
+            src={getImageUrl(currentPicture.path)}
+            alt={currentPicture.filename}
+            className="max-w-full max-h-full object-contain select-none"
+            draggable={false}
+          />
+          {faceRegions.map((region) => (
+            <div
+              key={region.id}
+              className="absolute border-2 border-blue-400 pointer-events-none"
+              style={{
+                left: `${region.x * 100}%`,
+                top: `${region.y * 100}%`,
+                width: `${region.width * 100}%`,
+                height: `${region.height * 100}%`,
+              }}
+            >
+              <span className="absolute left-0 top-full mt-1 px-2 py-0.5 rounded bg-black/70 text-white text-xs whitespace-nowrap">
+                {region.tagName || '?'}
+              </span>
+            </div>
+          ))}
+          {dragBox && (
+            <div
+              className="absolute border-2 border-dashed border-white pointer-events-none"
+              style={{
+                left: `${dragBox.x * 100}%`,
+                top: `${dragBox.y * 100}%`,
+                width: `${dragBox.width * 100}%`,
+                height: `${dragBox.height * 100}%`,
+              }}
+            />
+          )}
+        </div>
       </div>
 
       {/* Info panel */}
@@ -278,8 +477,17 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
         style={{ position: showInfo ? 'relative' : 'absolute', right: 0, height: '100%' }}
       >
         <div className="p-6 space-y-6">
-          <div>
+          <div className="flex items-start justify-between gap-2">
             <h3 className="text-xl font-bold text-white break-words">{currentPicture.filename}</h3>
+            <button
+              onClick={handleToggleFavorite}
+              className={`p-1 transition-colors ${favorite ? 'text-yellow-400 hover:text-yellow-300' : 'text-gray-500 hover:text-yellow-400'}`}
+              title={favorite ? 'Retirer des favoris' : 'Ajouter aux favoris'}
+            >
+              <svg className="w-6 h-6" fill={favorite ? 'currentColor' : 'none'} stroke="currentColor" viewBox="0 0 24 24">
+                <path strokeLinecap="round" strokeLinejoin="round" strokeWidth={2} d="M11.48 3.5a.56.56 0 011.04 0l2.13 5.11a.56.56 0 00.47.35l5.52.44c.5.04.7.66.32.99l-4.2 3.6a.56.56 0 00-.18.55l1.28 5.38a.56.56 0 01-.84.61l-4.72-2.88a.56.56 0 00-.59 0l-4.72 2.88a.56.56 0 01-.84-.61l1.28-5.38a.56.56 0 00-.18-.55l-4.2-3.6a.56.56 0 01.32-.99l5.52-.44a.56.56 0 00.47-.35l2.13-5.11z" />
+              </svg>
+            </button>
           </div>
 
           <div className="space-y-4">
@@ -316,6 +524,35 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
             </div>
           </div>
 
+          {/* Caption Section */}
+          <div className="space-y-2">
+            <span className="text-gray-400 text-sm">Légende</span>
+            <input
+              type="text"
+              value={caption.title}
+              onChange={(e) => setCaption({ ...caption, title: e.target.value })}
+              onBlur={handleSaveCaption}
+              placeholder="Titre"
+              className="w-full px-3 py-1.5 bg-gray-800 border border-gray-600 rounded text-white text-sm focus:outline-none focus:border-blue-500"
+            />
+            <textarea
+              value={caption.caption}
+              onChange={(e) => setCaption({ ...caption, caption: e.target.value })}
+              onBlur={handleSaveCaption}
+              placeholder="Légende (ex: Alice à gauche)"
+              rows={2}
+              className="w-full px-3 py-1.5 bg-gray-800 border border-gray-600 rounded text-white text-sm focus:outline-none focus:border-blue-500 resize-y"
+            />
+            <textarea
+              value={caption.notes}
+              onChange={(e) => setCaption({ ...caption, notes: e.target.value })}
+              onBlur={handleSaveCaption}
+              placeholder="Notes privées"
+              rows={2}
+              className="w-full px-3 py-1.5 bg-gray-800 border border-gray-600 rounded text-gray-300 text-sm focus:outline-none focus:border-blue-500 resize-y"
+            />
+          </div>
+
           {/* Tags Section */}
           <div>
             <div className="flex items-center justify-between mb-2">
@@ -380,11 +617,74 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
               <p className="text-gray-500 text-sm">Aucun tag</p>
             )}
 
+            {/* Suggested tags */}
+            {suggestions.length > 0 && (
+              <div className="mt-3">
+                <span className="text-gray-500 text-xs">Suggestions</span>
+                <div className="flex flex-wrap gap-2 mt-1">
+                  {suggestions.slice(0, 5).map((suggestion) => (
+                    <button
+                      key={suggestion.tagName}
+                      onClick={() => handleAddTag(suggestion.tagName)}
+                      disabled={tagLoading}
+                      className="px-3 py-1 rounded-full text-sm text-gray-300 border border-dashed border-gray-500 hover:border-blue-400 hover:text-white transition-colors"
+                      title={suggestion.reasons.join('\n')}
+                    >
+                      + {suggestion.tagName}
+                    </button>
+                  ))}
+                </div>
+              </div>
+            )}
+
             {allTags.length === 0 && (
               <p className="text-gray-500 text-xs mt-2">Creez des tags dans l'onglet "Tags"</p>
             )}
           </div>
 
+          {/* Faces Section */}
+          <div>
+            <div className="flex items-center justify-between mb-2">
+              <span className="text-gray-400 text-sm">Visages</span>
+              <button
+                onClick={() => setDrawMode(!drawMode)}
+                className={`px-2 py-0.5 rounded text-xs transition-colors ${
+                  drawMode ? 'bg-blue-600 text-white' : 'text-gray-400 hover:text-blue-400'
+                }`}
+                title="Dessiner un rectangle sur la photo"
+              >
+                {drawMode ? 'Dessinez sur la photo...' : '+ Visage'}
+              </button>
+            </div>
+            {faceRegions.length > 0 ? (
+              <div className="space-y-1">
+                {faceRegions.map((region) => (
+                  <div key={region.id} className="flex items-center gap-2 text-sm">
+                    <button
+                      onClick={() => handleAssignFace(region)}
+                      className="text-white hover:text-blue-400 text-left flex-1 truncate"
+                      title="Changer la personne"
+                    >
+                      {region.tagName || 'Inconnu'}
+                    </button>
+                    <span className="text-gray-500 text-xs">{region.source}</span>
+                    <button
+                      onClick={() => handleDeleteFace(region)}
+                      className="opacity-60 hover:opacity-100 text-gray-400"
+                      title="Supprimer la zone"
+                    >
+                      <svg className="w-3.5 h-3.5" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                        <path strokeLinecap="round" strokeLinejoin="round" strokeWidth={2} d="M6 18L18 6M6 6l12 12" />
+                      </svg>
+                    </button>
+                  </div>
+                ))}
+              </div>
+            ) : (
+              <p className="text-gray-500 text-sm">Aucun visage</p>
+            )}
+          </div>
+
           <div className="pt-4 border-t border-gray-700">
             <p className="text-gray-500 text-xs">
               Keyboard shortcuts: Arrow keys to navigate, I to toggle info, Delete to remove, Escape to close
@@ -408,7 +708,7 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
               {currentPicture.filename}
             </p>
             <p className="text-gray-400 text-sm">
-              Choose whether to remove only from the gallery index or also delete the file from disk.
+              Choose whether to remove only from the gallery index or also move the file to the trash (it can be restored later).
             </p>
 
             <div className="flex flex-col gap-3 pt-4">
@@ -424,7 +724,7 @@ export default function ImageViewer({ pictures, initialIndex, onClose, onDelete
                 disabled={isDeleting}
                 className="w-full px-4 py-3 bg-red-600 hover:bg-red-500 disabled:bg-gray-600 text-white rounded-lg transition-colors"
               >
-                {isDeleting ? 'Deleting...' : 'Delete from disk'}
+                {isDeleting ? 'Moving to trash...' : 'Move to trash'}
               </button>
               <button
                 onClick={() => setShowDeleteDialog(false)}
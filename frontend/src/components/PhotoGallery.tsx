@@ -5,27 +5,50 @@ import ImageViewer from './ImageViewer'
 import SearchBar from './SearchBar'
 import { getImageUrl } from '../utils/imageUrl'
 
+const PAGE_SIZE = 100
+
 export default function PhotoGallery() {
-  const [allPictures, setAllPictures] = useState<models.Picture[]>([])
   const [displayedPictures, setDisplayedPictures] = useState<models.Picture[]>([])
   const [totalCount, setTotalCount] = useState(0)
+  const [matchCount, setMatchCount] = useState(0)
+  const [nextCursor, setNextCursor] = useState('')
+  const [criteria, setCriteria] = useState<services.SearchCriteria | null>(null)
   const [loading, setLoading] = useState(true)
+  const [loadingMore, setLoadingMore] = useState(false)
   const [selectedIndex, setSelectedIndex] = useState<number | null>(null)
-  const [isFiltered, setIsFiltered] = useState(false)
+
+  const isFiltered = criteria !== null
 
   useEffect(() => {
     loadPictures()
     loadCount()
   }, [])
 
+  // Charge une page (première page si cursor est vide)
+  const fetchPage = async (searchCriteria: services.SearchCriteria | null, cursor: string) => {
+    // Une recherche texte est triée par pertinence
+    const page = services.PageRequest.createFrom({
+      sort: searchCriteria?.text ? 'relevance' : 'captureDate',
+      descending: true,
+      cursor,
+      limit: PAGE_SIZE,
+    })
+    return searchCriteria
+      ? SearchPicturesAdvanced(searchCriteria, page)
+      : GetIndexedPictures(page)
+  }
+
+  const showFirstPage = (result: services.PicturePage, searchCriteria: services.SearchCriteria | null) => {
+    setDisplayedPictures(result.pictures || [])
+    setNextCursor(result.nextCursor)
+    setMatchCount(result.total)
+    setCriteria(searchCriteria)
+  }
+
   const loadPictures = async () => {
     try {
       setLoading(true)
-      const result = await GetIndexedPictures()
-      const pictures = result || []
-      setAllPictures(pictures)
-      setDisplayedPictures(pictures)
-      setIsFiltered(false)
+      showFirstPage(await fetchPage(null, ''), null)
     } catch (error) {
       console.error('Failed to load pictures:', error)
     } finally {
@@ -33,6 +56,21 @@ export default function PhotoGallery() {
     }
   }
 
+  const loadMore = async () => {
+    if (!nextCursor || loadingMore) return
+    try {
+      setLoadingMore(true)
+      const result = await fetchPage(criteria, nextCursor)
+      setDisplayedPictures((prev) => [...prev, ...(result.pictures || [])])
+      setNextCursor(result.nextCursor)
+      setMatchCount(result.total)
+    } catch (error) {
+      console.error('Failed to load more pictures:', error)
+    } finally {
+      setLoadingMore(false)
+    }
+  }
+
   const loadCount = async () => {
     try {
       const count = await GetPictureCount()
@@ -43,11 +81,9 @@ export default function PhotoGallery() {
   }
 
   // Recherche par tags
-  const handleSearch = useCallback(async (criteria: services.SearchCriteria) => {
+  const handleSearch = useCallback(async (searchCriteria: services.SearchCriteria) => {
     try {
-      const result = await SearchPicturesAdvanced(criteria)
-      setDisplayedPictures(result || [])
-      setIsFiltered(true)
+      showFirstPage(await fetchPage(searchCriteria, ''), searchCriteria)
     } catch (error) {
       console.error('Search failed:', error)
     }
@@ -55,9 +91,8 @@ export default function PhotoGallery() {
 
   // Effacer le filtre
   const handleClearSearch = useCallback(() => {
-    setDisplayedPictures(allPictures)
-    setIsFiltered(false)
-  }, [allPictures])
+    loadPictures()
+  }, [])
 
   if (loading) {
     return (
@@ -74,7 +109,7 @@ export default function PhotoGallery() {
           Photo Gallery
           <span className="ml-3 text-gray-400 text-lg font-normal">
             {isFiltered ? (
-              <>{displayedPictures.length} / {totalCount} photos</>
+              <>{matchCount} / {totalCount} photos</>
             ) : (
               <>({totalCount} {totalCount === 1 ? 'photo' : 'photos'})</>
             )}
@@ -91,7 +126,7 @@ export default function PhotoGallery() {
       {/* Barre de recherche */}
       <SearchBar onSearch={handleSearch} onClear={handleClearSearch} />
 
-      {allPictures.length === 0 ? (
+      {totalCount === 0 ? (
         <div className="text-center py-12 bg-gray-800 rounded-lg">
           <p className="text-gray-400 text-lg">No pictures indexed yet</p>
           <p className="text-gray-500 text-sm mt-2">Add and index a folder to see your photos here</p>
@@ -128,6 +163,18 @@ export default function PhotoGallery() {
             ))}
           </div>
 
+          {nextCursor && (
+            <div className="flex justify-center">
+              <button
+                onClick={loadMore}
+                disabled={loadingMore}
+                className="px-4 py-2 bg-gray-700 hover:bg-gray-600 disabled:opacity-50 text-white rounded-lg transition-colors"
+              >
+                {loadingMore ? 'Loading...' : `Load more (${displayedPictures.length} / ${matchCount})`}
+              </button>
+            </div>
+          )}
+
           {/* Image viewer */}
           {selectedIndex !== null && (
             <ImageViewer
@@ -136,8 +183,8 @@ export default function PhotoGallery() {
               onClose={() => setSelectedIndex(null)}
               onDelete={(deletedPath) => {
                 // Remove the deleted picture from local state
-                setAllPictures((prev) => prev.filter((p) => p.path !== deletedPath))
                 setDisplayedPictures((prev) => prev.filter((p) => p.path !== deletedPath))
+                setMatchCount((prev) => prev - 1)
                 setTotalCount((prev) => prev - 1)
               }}
             />
@@ -1,5 +1,5 @@
 import { useState, useEffect } from 'react'
-import { GetAllTags } from '../../wailsjs/go/main/App'
+import { GetAllTags, GetTagTypes } from '../../wailsjs/go/main/App'
 import { models, services } from '../../wailsjs/go/models'
 
 interface SearchBarProps {
@@ -8,12 +8,7 @@ interface SearchBarProps {
 }
 
 // Groupe les tags par type pour l'affichage
-type TagsByType = {
-  person: models.Tag[]
-  location: models.Tag[]
-  event: models.Tag[]
-  other: models.Tag[]
-}
+type TagsByType = Record<string, models.Tag[]>
 
 // Type d'un critere de recherche par groupe
 interface GroupCriteria {
@@ -21,32 +16,19 @@ interface GroupCriteria {
   operator: 'AND' | 'OR'
 }
 
-// Configuration des types de tags
-const TAG_TYPE_CONFIG = {
-  person: { label: 'Personnes', icon: '👤', color: 'blue' },
-  location: { label: 'Lieux', icon: '📍', color: 'green' },
-  event: { label: 'Evenements', icon: '📅', color: 'purple' },
-  other: { label: 'Autres', icon: '🏷️', color: 'gray' },
-}
+// Opérateur par défaut: une photo contient souvent plusieurs personnes, mais un seul lieu ou événement
+const defaultOperator = (type: string): 'AND' | 'OR' => (type === 'person' ? 'AND' : 'OR')
 
 export default function SearchBar({ onSearch, onClear }: SearchBarProps) {
   const [allTags, setAllTags] = useState<models.Tag[]>([])
-  const [tagsByType, setTagsByType] = useState<TagsByType>({
-    person: [], location: [], event: [], other: []
-  })
+  const [tagTypes, setTagTypes] = useState<models.TagTypeDefinition[]>([])
+  const [tagsByType, setTagsByType] = useState<TagsByType>({})
 
-  // Criteres de recherche par type
-  const [criteria, setCriteria] = useState<{
-    person: GroupCriteria
-    location: GroupCriteria
-    event: GroupCriteria
-    other: GroupCriteria
-  }>({
-    person: { tags: [], operator: 'AND' },
-    location: { tags: [], operator: 'OR' },
-    event: { tags: [], operator: 'OR' },
-    other: { tags: [], operator: 'OR' },
-  })
+  // Criteres de recherche par type (un groupe par type de tag)
+  const [criteria, setCriteria] = useState<Record<string, GroupCriteria>>({})
+
+  // Texte libre (nom de fichier, dossiers, tags, légendes)
+  const [text, setText] = useState('')
 
   const [isExpanded, setIsExpanded] = useState(false)
 
@@ -54,16 +36,19 @@ export default function SearchBar({ onSearch, onClear }: SearchBarProps) {
   useEffect(() => {
     const loadTags = async () => {
       try {
-        const tags = await GetAllTags()
+        const [tags, types] = await Promise.all([GetAllTags(), GetTagTypes()])
         setAllTags(tags || [])
+        setTagTypes(types || [])
 
         // Grouper par type
-        const grouped: TagsByType = { person: [], location: [], event: [], other: [] }
+        const grouped: TagsByType = {}
+        for (const type of (types || [])) {
+          grouped[type.name] = []
+        }
         for (const tag of (tags || [])) {
-          const type = tag.type as keyof TagsByType
-          if (grouped[type]) {
-            grouped[type].push(tag)
-          } else {
+          if (grouped[tag.type]) {
+            grouped[tag.type].push(tag)
+          } else if (grouped.other) {
             grouped.other.push(tag)
           }
         }
@@ -77,67 +62,57 @@ export default function SearchBar({ onSearch, onClear }: SearchBarProps) {
 
   // Déclencher la recherche quand les critères changent
   useEffect(() => {
-    const hasAnyCriteria =
-      criteria.person.tags.length > 0 ||
-      criteria.location.tags.length > 0 ||
-      criteria.event.tags.length > 0 ||
-      criteria.other.tags.length > 0
+    const groups = Object.entries(criteria)
+      .filter(([_, { tags }]) => tags.length > 0)
+      .map(([type, { tags, operator }]) => ({ type, tags, operator }))
 
-    if (hasAnyCriteria) {
+    if (groups.length > 0 || text.trim() !== '') {
       const searchCriteria = new services.SearchCriteria({
-        persons: { tags: criteria.person.tags, operator: criteria.person.operator },
-        locations: { tags: criteria.location.tags, operator: criteria.location.operator },
-        events: { tags: criteria.event.tags, operator: criteria.event.operator },
-        others: { tags: criteria.other.tags, operator: criteria.other.operator },
+        groups,
+        text: text.trim(),
       })
       onSearch(searchCriteria)
     } else {
       onClear()
     }
-  }, [criteria, onSearch, onClear])
+  }, [criteria, text, onSearch, onClear])
 
-  const toggleTag = (type: keyof typeof criteria, tagName: string) => {
+  const groupFor = (type: string): GroupCriteria =>
+    criteria[type] || { tags: [], operator: defaultOperator(type) }
+
+  const toggleTag = (type: string, tagName: string) => {
     setCriteria(prev => {
-      const current = prev[type].tags
-      const newTags = current.includes(tagName)
-        ? current.filter(t => t !== tagName)
-        : [...current, tagName]
+      const group = prev[type] || { tags: [], operator: defaultOperator(type) }
+      const newTags = group.tags.includes(tagName)
+        ? group.tags.filter(t => t !== tagName)
+        : [...group.tags, tagName]
       return {
         ...prev,
-        [type]: { ...prev[type], tags: newTags }
+        [type]: { ...group, tags: newTags }
       }
     })
   }
 
-  const toggleOperator = (type: keyof typeof criteria) => {
-    setCriteria(prev => ({
-      ...prev,
-      [type]: {
-        ...prev[type],
-        operator: prev[type].operator === 'AND' ? 'OR' : 'AND'
+  const toggleOperator = (type: string) => {
+    setCriteria(prev => {
+      const group = prev[type] || { tags: [], operator: defaultOperator(type) }
+      return {
+        ...prev,
+        [type]: {
+          ...group,
+          operator: group.operator === 'AND' ? 'OR' : 'AND'
+        }
       }
-    }))
+    })
   }
 
   const clearAll = () => {
-    setCriteria({
-      person: { tags: [], operator: 'AND' },
-      location: { tags: [], operator: 'OR' },
-      event: { tags: [], operator: 'OR' },
-      other: { tags: [], operator: 'OR' },
-    })
+    setText('')
+    setCriteria({})
   }
 
-  const totalSelected =
-    criteria.person.tags.length +
-    criteria.location.tags.length +
-    criteria.event.tags.length +
-    criteria.other.tags.length
-
-  // Si aucun tag n'existe, ne pas afficher la barre de recherche
-  if (allTags.length === 0) {
-    return null
-  }
+  const totalSelected = Object.values(criteria)
+    .reduce((count, group) => count + group.tags.length, 0)
 
   return (
     <div className="mb-6">
@@ -158,6 +133,14 @@ export default function SearchBar({ onSearch, onClear }: SearchBarProps) {
           <span className="font-medium">Recherche par tags</span>
         </button>
 
+        <input
+          type="text"
+          value={text}
+          onChange={(e) => setText(e.target.value)}
+          placeholder="Rechercher (fichier, dossier, tag...)"
+          className="w-64 px-3 py-1.5 bg-gray-700 text-white text-sm rounded-lg placeholder-gray-400 focus:outline-none focus:ring-2 focus:ring-blue-500"
+        />
+
         {/* Affichage compact des tags sélectionnés */}
         {totalSelected > 0 && (
           <div className="flex items-center gap-2 flex-1 overflow-x-auto">
@@ -174,7 +157,7 @@ export default function SearchBar({ onSearch, onClear }: SearchBarProps) {
                     <button
                       onClick={(e) => {
                         e.stopPropagation()
-                        toggleTag(type as keyof typeof criteria, tagName)
+                        toggleTag(type, tagName)
                       }}
                       className="opacity-70 hover:opacity-100"
                     >
@@ -189,7 +172,7 @@ export default function SearchBar({ onSearch, onClear }: SearchBarProps) {
           </div>
         )}
 
-        {totalSelected > 0 && (
+        {(totalSelected > 0 || text !== '') && (
           <button
             onClick={clearAll}
             className="text-gray-400 hover:text-white text-sm transition-colors"
@@ -206,11 +189,12 @@ export default function SearchBar({ onSearch, onClear }: SearchBarProps) {
             Selectionnez des tags pour filtrer. Les groupes sont combines avec AND entre eux.
           </p>
 
-          {(Object.entries(TAG_TYPE_CONFIG) as [keyof typeof TAG_TYPE_CONFIG, typeof TAG_TYPE_CONFIG.person][]).map(([type, config]) => {
-            const typeTags = tagsByType[type]
+          {tagTypes.map(config => {
+            const type = config.name
+            const typeTags = tagsByType[type] || []
             if (typeTags.length === 0) return null
 
-            const selected = criteria[type]
+            const selected = groupFor(type)
 
             return (
               <div key={type} className="space-y-2">
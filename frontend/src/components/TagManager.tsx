@@ -1,6 +1,6 @@
 import { useState, useEffect } from 'react'
-import { GetAllTagsWithCount, CreateTag, UpdateTag, DeleteTag } from '../../wailsjs/go/main/App'
-import { services } from '../../wailsjs/go/models'
+import { GetAllTagsWithCount, CreateTag, UpdateTag, DeleteTag, RenameTag, GetTagTypes, CreateTagType, DeleteTagType } from '../../wailsjs/go/main/App'
+import { models, services } from '../../wailsjs/go/models'
 
 // Palette de couleurs prédéfinies
 const COLOR_PALETTE = [
@@ -15,16 +15,9 @@ const COLOR_PALETTE = [
   { hex: '#6B7280', name: 'Gris' },
 ]
 
-// Types de tags disponibles
-const TAG_TYPES = [
-  { value: 'person', label: 'Personne' },
-  { value: 'location', label: 'Lieu' },
-  { value: 'event', label: 'Evenement' },
-  { value: 'other', label: 'Autre' },
-]
-
 export default function TagManager() {
   const [tags, setTags] = useState<services.TagWithCount[]>([])
+  const [tagTypes, setTagTypes] = useState<models.TagTypeDefinition[]>([])
   const [loading, setLoading] = useState(false)
   const [showForm, setShowForm] = useState(false)
   const [editingTag, setEditingTag] = useState<string | null>(null)
@@ -33,11 +26,28 @@ export default function TagManager() {
   const [formName, setFormName] = useState('')
   const [formType, setFormType] = useState('other')
   const [formColor, setFormColor] = useState(COLOR_PALETTE[5].hex) // Bleu par défaut
+  const [formParent, setFormParent] = useState('') // Tag parent (vide = racine)
+
+  // Formulaire des types de tags
+  const [showTypeForm, setShowTypeForm] = useState(false)
+  const [typeName, setTypeName] = useState('')
+  const [typeIcon, setTypeIcon] = useState('🏷️')
+  const [typeColor, setTypeColor] = useState(COLOR_PALETTE[8].hex)
 
   useEffect(() => {
     loadTags()
+    loadTagTypes()
   }, [])
 
+  const loadTagTypes = async () => {
+    try {
+      const result = await GetTagTypes()
+      setTagTypes(result || [])
+    } catch (error) {
+      console.error('Failed to load tag types:', error)
+    }
+  }
+
   const loadTags = async () => {
     try {
       const result = await GetAllTagsWithCount()
@@ -51,6 +61,7 @@ export default function TagManager() {
     setFormName('')
     setFormType('other')
     setFormColor(COLOR_PALETTE[5].hex)
+    setFormParent('')
     setShowForm(false)
     setEditingTag(null)
   }
@@ -63,7 +74,10 @@ export default function TagManager() {
 
     try {
       setLoading(true)
-      await CreateTag(formName.trim(), formType, formColor)
+      const similar = await CreateTag(formName.trim(), formType, formColor, formParent)
+      if (similar && similar.length > 0) {
+        alert(`Attention, tags au nom proche: ${similar.join(', ')}`)
+      }
       await loadTags()
       resetForm()
     } catch (error) {
@@ -78,15 +92,25 @@ export default function TagManager() {
     setFormName(tag.name)
     setFormType(tag.type)
     setFormColor(tag.color || COLOR_PALETTE[5].hex)
+    setFormParent(tag.parentName || '')
     setShowForm(true)
   }
 
   const handleUpdate = async () => {
     if (!editingTag) return
+    const newName = formName.trim()
+    if (!newName) {
+      alert('Le nom du tag est requis')
+      return
+    }
 
     try {
       setLoading(true)
-      await UpdateTag(editingTag, formType, formColor)
+      // Renommer d'abord: les associations aux photos sont conservées
+      if (newName !== editingTag) {
+        await RenameTag(editingTag, newName)
+      }
+      await UpdateTag(newName, formType, formColor, formParent)
       await loadTags()
       resetForm()
     } catch (error) {
@@ -110,17 +134,55 @@ export default function TagManager() {
     }
   }
 
+  const handleTypeChange = (type: string) => {
+    setFormType(type)
+    // Couleur par défaut du type pour un nouveau tag
+    const color = tagTypes.find(t => t.name === type)?.color
+    if (!editingTag && color) {
+      setFormColor(color)
+    }
+  }
+
+  const handleCreateType = async () => {
+    if (!typeName.trim()) {
+      alert('Le nom du type est requis')
+      return
+    }
+
+    try {
+      setLoading(true)
+      const maxOrder = Math.max(0, ...tagTypes.map(t => t.sortOrder))
+      await CreateTagType(typeName.trim(), typeName.trim(), typeIcon, typeColor, maxOrder + 1)
+      await loadTagTypes()
+      setTypeName('')
+      setShowTypeForm(false)
+    } catch (error) {
+      alert(`Erreur: ${error}`)
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const handleDeleteType = async (type: models.TagTypeDefinition) => {
+    if (!confirm(`Supprimer le type "${type.label}" ? Ses tags passeront dans "Autres".`)) return
+
+    try {
+      setLoading(true)
+      await DeleteTagType(type.name)
+      await Promise.all([loadTagTypes(), loadTags()])
+    } catch (error) {
+      alert(`Erreur: ${error}`)
+    } finally {
+      setLoading(false)
+    }
+  }
+
   const getTypeLabel = (type: string) => {
-    return TAG_TYPES.find(t => t.value === type)?.label || type
+    return tagTypes.find(t => t.name === type)?.label || type
   }
 
   const getTypeIcon = (type: string) => {
-    switch (type) {
-      case 'person': return '👤'
-      case 'location': return '📍'
-      case 'event': return '📅'
-      default: return '🏷️'
-    }
+    return tagTypes.find(t => t.name === type)?.icon || '🏷️'
   }
 
   return (
@@ -143,7 +205,7 @@ export default function TagManager() {
             {editingTag ? `Modifier "${editingTag}"` : 'Nouveau Tag'}
           </h3>
 
-          <div className="grid grid-cols-1 md:grid-cols-3 gap-4">
+          <div className="grid grid-cols-1 md:grid-cols-4 gap-4">
             {/* Nom */}
             <div>
               <label className="block text-sm text-gray-400 mb-1">Nom</label>
@@ -151,7 +213,6 @@ export default function TagManager() {
                 type="text"
                 value={formName}
                 onChange={(e) => setFormName(e.target.value)}
-                disabled={!!editingTag}
                 placeholder="Ex: Vacances, Marie..."
                 className="w-full px-3 py-2 bg-gray-700 text-white rounded-lg border border-gray-600 focus:border-blue-500 focus:outline-none disabled:opacity-50"
               />
@@ -162,15 +223,32 @@ export default function TagManager() {
               <label className="block text-sm text-gray-400 mb-1">Type</label>
               <select
                 value={formType}
-                onChange={(e) => setFormType(e.target.value)}
+                onChange={(e) => handleTypeChange(e.target.value)}
                 className="w-full px-3 py-2 bg-gray-700 text-white rounded-lg border border-gray-600 focus:border-blue-500 focus:outline-none"
               >
-                {TAG_TYPES.map(type => (
-                  <option key={type.value} value={type.value}>{type.label}</option>
+                {tagTypes.map(type => (
+                  <option key={type.name} value={type.name}>{type.icon} {type.label}</option>
                 ))}
               </select>
             </div>
 
+            {/* Tag parent */}
+            <div>
+              <label className="block text-sm text-gray-400 mb-1">Parent</label>
+              <select
+                value={formParent}
+                onChange={(e) => setFormParent(e.target.value)}
+                className="w-full px-3 py-2 bg-gray-700 text-white rounded-lg border border-gray-600 focus:border-blue-500 focus:outline-none"
+              >
+                <option value="">Aucun</option>
+                {tags
+                  .filter(tag => tag.name !== editingTag)
+                  .map(tag => (
+                    <option key={tag.name} value={tag.name}>{tag.name}</option>
+                  ))}
+              </select>
+            </div>
+
             {/* Couleur */}
             <div>
               <label className="block text-sm text-gray-400 mb-1">Couleur</label>
@@ -201,7 +279,7 @@ export default function TagManager() {
             </button>
             <button
               onClick={editingTag ? handleUpdate : handleCreate}
-              disabled={loading || (!editingTag && !formName.trim())}
+              disabled={loading || !formName.trim()}
               className="px-4 py-2 bg-blue-600 hover:bg-blue-700 text-white rounded-lg transition-colors disabled:opacity-50"
             >
               {loading ? 'Enregistrement...' : (editingTag ? 'Modifier' : 'Creer')}
@@ -210,6 +288,89 @@ export default function TagManager() {
         </div>
       )}
 
+      {/* Types de tags */}
+      <div className="bg-gray-800 rounded-lg p-4 space-y-3">
+        <div className="flex items-center justify-between">
+          <h3 className="text-white font-medium">Types de tags</h3>
+          <button
+            onClick={() => setShowTypeForm(!showTypeForm)}
+            disabled={loading}
+            className="text-sm text-blue-400 hover:text-blue-300 transition-colors"
+          >
+            {showTypeForm ? 'Annuler' : 'Nouveau type'}
+          </button>
+        </div>
+
+        <div className="flex flex-wrap gap-2">
+          {tagTypes.map(type => (
+            <span
+              key={type.name}
+              className="inline-flex items-center gap-1 px-3 py-1 rounded-full text-sm text-white bg-gray-700"
+            >
+              <span>{type.icon}</span>
+              {type.label}
+              {!type.builtIn && (
+                <button
+                  onClick={() => handleDeleteType(type)}
+                  disabled={loading}
+                  className="ml-1 text-gray-400 hover:text-red-400"
+                  title="Supprimer"
+                >
+                  x
+                </button>
+              )}
+            </span>
+          ))}
+        </div>
+
+        {showTypeForm && (
+          <div className="flex flex-wrap items-end gap-3">
+            <div>
+              <label className="block text-sm text-gray-400 mb-1">Nom</label>
+              <input
+                type="text"
+                value={typeName}
+                onChange={(e) => setTypeName(e.target.value)}
+                placeholder="Ex: Projet, Client..."
+                className="px-3 py-2 bg-gray-700 text-white rounded-lg border border-gray-600 focus:border-blue-500 focus:outline-none"
+              />
+            </div>
+            <div>
+              <label className="block text-sm text-gray-400 mb-1">Icone</label>
+              <input
+                type="text"
+                value={typeIcon}
+                onChange={(e) => setTypeIcon(e.target.value)}
+                className="w-16 px-3 py-2 bg-gray-700 text-white rounded-lg border border-gray-600 focus:border-blue-500 focus:outline-none"
+              />
+            </div>
+            <div>
+              <label className="block text-sm text-gray-400 mb-1">Couleur par defaut</label>
+              <div className="flex gap-1">
+                {COLOR_PALETTE.map(color => (
+                  <button
+                    key={color.hex}
+                    onClick={() => setTypeColor(color.hex)}
+                    title={color.name}
+                    className={`w-6 h-6 rounded-full border-2 ${
+                      typeColor === color.hex ? 'border-white' : 'border-transparent'
+                    }`}
+                    style={{ backgroundColor: color.hex }}
+                  />
+                ))}
+              </div>
+            </div>
+            <button
+              onClick={handleCreateType}
+              disabled={loading || !typeName.trim()}
+              className="px-4 py-2 bg-blue-600 hover:bg-blue-700 text-white rounded-lg transition-colors disabled:opacity-50"
+            >
+              Creer
+            </button>
+          </div>
+        )}
+      </div>
+
       {/* Liste des tags */}
       {tags.length === 0 ? (
         <div className="text-center py-12 bg-gray-800 rounded-lg">
@@ -235,7 +396,11 @@ export default function TagManager() {
                   </div>
                   <div className="text-sm text-gray-400">
                     {getTypeLabel(tag.type)} - {tag.pictureCount} photo{tag.pictureCount !== 1 ? 's' : ''}
+                    {tag.subtreePictureCount !== tag.pictureCount && ` (${tag.subtreePictureCount} avec sous-tags)`}
                   </div>
+                  {tag.parentName && (
+                    <div className="text-xs text-gray-500">Sous {tag.parentName}</div>
+                  )}
                 </div>
               </div>
 